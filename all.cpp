@@ -1,8 +1,11 @@
 #include "cpp/oiio.cpp"
+#include "cpp/typedesc.cpp"
+#include "cpp/ioproxy.cpp"
 #include "cpp/imageinput.cpp"
 #include "cpp/imageoutput.cpp"
 #include "cpp/imagespec.cpp"
 #include "cpp/imagebuf.cpp"
+#include "cpp/deepdata.cpp"
 #include "cpp/imagecache.cpp"
 #include "cpp/roi.cpp"
 #include "cpp/imagebufalgo.cpp"
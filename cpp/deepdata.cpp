@@ -0,0 +1,154 @@
+#include <OpenImageIO/imageio.h>
+#if OIIO_VERSION >= 10700
+#include <OpenImageIO/deepdata.h>
+#endif
+
+#include <string>
+#include <vector>
+
+#include "oiio.h"
+
+
+extern OIIO::TypeDesc fromTypeDesc(FullTypeDesc fmt);
+extern FullTypeDesc toTypeDesc(OIIO::TypeDesc fmt);
+
+
+extern "C" {
+
+// The DeepData class API used here is only available since OIIO 1.7.
+// Older releases get stubs that hold no data, and the Go side reports
+// an error for any deep operation that is attempted.
+bool DeepData_supported() {
+#if OIIO_VERSION >= 10700
+	return true;
+#else
+	return false;
+#endif
+}
+
+#if OIIO_VERSION >= 10700
+
+void deleteDeepData(DeepData *dd) {
+	delete static_cast<OIIO::DeepData*>(dd);
+}
+
+DeepData* DeepData_New() {
+	return (DeepData*) new OIIO::DeepData();
+}
+
+void DeepData_init(DeepData *dd, int64_t npix, int nchans, const FullTypeDesc *channeltypes, char **channelnames) {
+	std::vector<OIIO::TypeDesc> vec_types;
+	std::vector<std::string> vec_names;
+	for (int i = 0; i < nchans; i++) {
+		vec_types.push_back(fromTypeDesc(channeltypes[i]));
+		vec_names.push_back(std::string(channelnames[i]));
+	}
+	static_cast<OIIO::DeepData*>(dd)->init(npix, nchans, vec_types, vec_names);
+}
+
+void DeepData_init_spec(DeepData *dd, const ImageSpec *spec) {
+	static_cast<OIIO::DeepData*>(dd)->init(*(static_cast<const OIIO::ImageSpec*>(spec)));
+}
+
+void DeepData_clear(DeepData *dd) {
+	static_cast<OIIO::DeepData*>(dd)->clear();
+}
+
+void DeepData_free(DeepData *dd) {
+	static_cast<OIIO::DeepData*>(dd)->free();
+}
+
+int64_t DeepData_pixels(DeepData *dd) {
+	return static_cast<OIIO::DeepData*>(dd)->pixels();
+}
+
+int DeepData_channels(DeepData *dd) {
+	return static_cast<OIIO::DeepData*>(dd)->channels();
+}
+
+FullTypeDesc DeepData_channeltype(DeepData *dd, int c) {
+	return toTypeDesc(static_cast<OIIO::DeepData*>(dd)->channeltype(c));
+}
+
+size_t DeepData_channelsize(DeepData *dd, int c) {
+	return static_cast<OIIO::DeepData*>(dd)->channelsize(c);
+}
+
+size_t DeepData_samplesize(DeepData *dd) {
+	return static_cast<OIIO::DeepData*>(dd)->samplesize();
+}
+
+int DeepData_samples(DeepData *dd, int64_t pixel) {
+	return static_cast<OIIO::DeepData*>(dd)->samples(pixel);
+}
+
+void DeepData_set_samples(DeepData *dd, int64_t pixel, int samps) {
+	static_cast<OIIO::DeepData*>(dd)->set_samples(pixel, samps);
+}
+
+void DeepData_all_samples(DeepData *dd, uint32_t *out) {
+	// all_samples() returns a view type that differs between releases
+	// (array_view, cspan), so copy the counts one pixel at a time.
+	OIIO::DeepData *d = static_cast<OIIO::DeepData*>(dd);
+	for (int64_t i = 0, n = d->pixels(); i < n; i++) {
+		out[i] = d->samples(i);
+	}
+}
+
+void DeepData_set_all_samples(DeepData *dd, const uint32_t *samples, int64_t npix) {
+	std::vector<unsigned int> vec(samples, samples+npix);
+	static_cast<OIIO::DeepData*>(dd)->set_all_samples(vec);
+}
+
+void DeepData_insert_samples(DeepData *dd, int64_t pixel, int samplepos, int n) {
+	static_cast<OIIO::DeepData*>(dd)->insert_samples(pixel, samplepos, n);
+}
+
+void DeepData_erase_samples(DeepData *dd, int64_t pixel, int samplepos, int n) {
+	static_cast<OIIO::DeepData*>(dd)->erase_samples(pixel, samplepos, n);
+}
+
+float DeepData_deep_value(DeepData *dd, int64_t pixel, int channel, int sample) {
+	return static_cast<OIIO::DeepData*>(dd)->deep_value(pixel, channel, sample);
+}
+
+uint32_t DeepData_deep_value_uint(DeepData *dd, int64_t pixel, int channel, int sample) {
+	return static_cast<OIIO::DeepData*>(dd)->deep_value_uint(pixel, channel, sample);
+}
+
+void DeepData_set_deep_value(DeepData *dd, int64_t pixel, int channel, int sample, float value) {
+	static_cast<OIIO::DeepData*>(dd)->set_deep_value(pixel, channel, sample, value);
+}
+
+void DeepData_set_deep_value_uint(DeepData *dd, int64_t pixel, int channel, int sample, uint32_t value) {
+	static_cast<OIIO::DeepData*>(dd)->set_deep_value(pixel, channel, sample, value);
+}
+
+#else
+
+void deleteDeepData(DeepData *dd) {}
+DeepData* DeepData_New() { return NULL; }
+void DeepData_init(DeepData *dd, int64_t npix, int nchans, const FullTypeDesc *channeltypes, char **channelnames) {}
+void DeepData_init_spec(DeepData *dd, const ImageSpec *spec) {}
+void DeepData_clear(DeepData *dd) {}
+void DeepData_free(DeepData *dd) {}
+int64_t DeepData_pixels(DeepData *dd) { return 0; }
+int DeepData_channels(DeepData *dd) { return 0; }
+FullTypeDesc DeepData_channeltype(DeepData *dd, int c) { return toTypeDesc(OIIO::TypeDesc()); }
+size_t DeepData_channelsize(DeepData *dd, int c) { return 0; }
+size_t DeepData_samplesize(DeepData *dd) { return 0; }
+int DeepData_samples(DeepData *dd, int64_t pixel) { return 0; }
+void DeepData_set_samples(DeepData *dd, int64_t pixel, int samps) {}
+void DeepData_all_samples(DeepData *dd, uint32_t *out) {}
+void DeepData_set_all_samples(DeepData *dd, const uint32_t *samples, int64_t npix) {}
+void DeepData_insert_samples(DeepData *dd, int64_t pixel, int samplepos, int n) {}
+void DeepData_erase_samples(DeepData *dd, int64_t pixel, int samplepos, int n) {}
+float DeepData_deep_value(DeepData *dd, int64_t pixel, int channel, int sample) { return 0; }
+uint32_t DeepData_deep_value_uint(DeepData *dd, int64_t pixel, int channel, int sample) { return 0; }
+void DeepData_set_deep_value(DeepData *dd, int64_t pixel, int channel, int sample, float value) {}
+void DeepData_set_deep_value_uint(DeepData *dd, int64_t pixel, int channel, int sample, uint32_t value) {}
+
+#endif
+
+
+} // extern "C"
@@ -18,6 +18,16 @@ IBStorage toIBStorage(OIIO::ImageBuf::IBStorage s) {
 	return IBSTORAGE_UNINITIALIZED;
 }
 
+OIIO::ImageBuf::WrapMode fromWrapMode(WrapMode w) {
+	switch (w) {
+	case WrapBlack: 	return OIIO::ImageBuf::WrapBlack;
+	case WrapClamp: 	return OIIO::ImageBuf::WrapClamp;
+	case WrapPeriodic: 	return OIIO::ImageBuf::WrapPeriodic;
+	case WrapMirror: 	return OIIO::ImageBuf::WrapMirror;
+	default: 			return OIIO::ImageBuf::WrapDefault;
+	}
+}
+
 extern "C" {
 
 const char* ImageBuf_geterror(ImageBuf* buf) {
@@ -94,38 +104,38 @@ bool ImageBuf_init_spec(ImageBuf* buf, const char* filename, int subimage, int m
 	return static_cast<OIIO::ImageBuf*>(buf)->init_spec(filename, subimage, miplevel);
 }
 
-bool ImageBuf_read(ImageBuf* buf, int subimage, int miplevel, bool force, TypeDesc convert, void *cbk_data) {
+bool ImageBuf_read(ImageBuf* buf, int subimage, int miplevel, bool force, FullTypeDesc convert, uintptr_t cbk_handle) {
 	ProgressCallback cbk = NULL;
-	if (cbk_data != NULL) {
-		cbk = &image_progress_callback;
+	if (cbk_handle != 0) {
+		cbk = &progress_callback;
 	}
 	return static_cast<OIIO::ImageBuf*>(buf)->read(subimage,
 												   miplevel,
 												   force,
 												   fromTypeDesc(convert), 
 												   cbk,
-												   cbk_data);
+												   (void*) cbk_handle);
 }
 
 
-bool ImageBuf_write_file(ImageBuf* buf, const char* filename, const char* fileformat, void *cbk_data) {
+bool ImageBuf_write_file(ImageBuf* buf, const char* filename, const char* fileformat, uintptr_t cbk_handle) {
 	ProgressCallback cbk = NULL;
-	if (cbk_data != NULL) {
-		cbk = &image_progress_callback;
+	if (cbk_handle != 0) {
+		cbk = &progress_callback;
 	}
-	return static_cast<OIIO::ImageBuf*>(buf)->write(filename, fileformat, cbk, cbk_data);
+	return static_cast<OIIO::ImageBuf*>(buf)->write(filename, fileformat, cbk, (void*) cbk_handle);
 }
 
-bool ImageBuf_write_output(ImageBuf* buf, ImageOutput *out, void *cbk_data) {
+bool ImageBuf_write_output(ImageBuf* buf, ImageOutput *out, uintptr_t cbk_handle) {
 	OIIO::ImageOutput *out_ptr = static_cast<OIIO::ImageOutput*>(out);
 	ProgressCallback cbk = NULL;
-	if (cbk_data != NULL) {
-		cbk = &image_progress_callback;
+	if (cbk_handle != 0) {
+		cbk = &progress_callback;
 	}
-	return static_cast<OIIO::ImageBuf*>(buf)->write(out_ptr, cbk, cbk_data);
+	return static_cast<OIIO::ImageBuf*>(buf)->write(out_ptr, cbk, (void*) cbk_handle);
 }
 
-void ImageBuf_set_write_format(ImageBuf* buf, TypeDesc format) {
+void ImageBuf_set_write_format(ImageBuf* buf, FullTypeDesc format) {
 	static_cast<OIIO::ImageBuf*>(buf)->set_write_format(fromTypeDesc(format));
 }
 
@@ -201,7 +211,7 @@ bool ImageBuf_get_pixel_channels(ImageBuf* buf,
 								 int ybegin, int yend, 
 								 int zbegin, int zend, 
 								 int chbegin, int chend, 
-								 TypeDesc format, 
+								 FullTypeDesc format, 
 								 void *result)
 {
 	return static_cast<OIIO::ImageBuf*>(buf)->get_pixel_channels(xbegin, xend,
@@ -212,6 +222,39 @@ bool ImageBuf_get_pixel_channels(ImageBuf* buf,
 														 		 result );	
 }
 
+bool ImageBuf_set_pixels(ImageBuf* buf, ROI* roi, FullTypeDesc format, const void *data) {
+	return static_cast<OIIO::ImageBuf*>(buf)->set_pixels(*(static_cast<OIIO::ROI*>(roi)),
+														 fromTypeDesc(format), data);
+}
+
+float ImageBuf_getchannel(ImageBuf* buf, int x, int y, int z, int c, WrapMode wrap) {
+	return static_cast<OIIO::ImageBuf*>(buf)->getchannel(x, y, z, c, fromWrapMode(wrap));
+}
+
+void ImageBuf_getpixel_xyz(ImageBuf* buf, int x, int y, int z, float *pixel, int maxchannels, WrapMode wrap) {
+	static_cast<OIIO::ImageBuf*>(buf)->getpixel(x, y, z, pixel, maxchannels, fromWrapMode(wrap));
+}
+
+void ImageBuf_interppixel(ImageBuf* buf, float x, float y, float *pixel, WrapMode wrap) {
+	static_cast<OIIO::ImageBuf*>(buf)->interppixel(x, y, pixel, fromWrapMode(wrap));
+}
+
+void ImageBuf_interppixel_NDC(ImageBuf* buf, float s, float t, float *pixel, WrapMode wrap) {
+	static_cast<OIIO::ImageBuf*>(buf)->interppixel_NDC(s, t, pixel, fromWrapMode(wrap));
+}
+
+void ImageBuf_interppixel_NDC_full(ImageBuf* buf, float s, float t, float *pixel, WrapMode wrap) {
+	static_cast<OIIO::ImageBuf*>(buf)->interppixel_NDC_full(s, t, pixel, fromWrapMode(wrap));
+}
+
+void ImageBuf_setpixel_xyz(ImageBuf* buf, int x, int y, int z, const float *pixel, int maxchannels) {
+	static_cast<OIIO::ImageBuf*>(buf)->setpixel(x, y, z, pixel, maxchannels);
+}
+
+void ImageBuf_setpixel_index(ImageBuf* buf, int i, const float *pixel, int maxchannels) {
+	static_cast<OIIO::ImageBuf*>(buf)->setpixel(i, pixel, maxchannels);
+}
+
 int ImageBuf_orientation(ImageBuf* buf) {
 	return static_cast<OIIO::ImageBuf*>(buf)->orientation();
 }
@@ -322,11 +365,13 @@ bool ImageBuf_pixels_valid(ImageBuf* buf) {
 	return static_cast<OIIO::ImageBuf*>(buf)->pixels_valid();
 }
 
-TypeDesc ImageBuf_pixeltype(ImageBuf* buf) {
+FullTypeDesc ImageBuf_pixeltype(ImageBuf* buf) {
 	return toTypeDesc(static_cast<OIIO::ImageBuf*>(buf)->pixeltype());
 }
 
-// void* ImageBuf_localpixels(ImageBuf* buf);
+void* ImageBuf_localpixels(ImageBuf* buf) {
+	return static_cast<OIIO::ImageBuf*>(buf)->localpixels();
+}
 
 // const void* ImageBuf_localpixels(ImageBuf* buf);
 
@@ -347,13 +392,61 @@ bool ImageBuf_deep(ImageBuf* buf) {
 	return static_cast<OIIO::ImageBuf*>(buf)->deep();
 }
 
-// int ImageBuf_deep_samples(ImageBuf* buf, int x, int y, int z);
+// Deep pixel access needs OIIO 1.7 (see DeepData_supported)
+#if OIIO_VERSION >= 10700
+
+int ImageBuf_deep_samples(ImageBuf* buf, int x, int y, int z) {
+	return static_cast<OIIO::ImageBuf*>(buf)->deep_samples(x, y, z);
+}
+
+void ImageBuf_set_deep_samples(ImageBuf* buf, int x, int y, int z, int nsamples) {
+	static_cast<OIIO::ImageBuf*>(buf)->set_deep_samples(x, y, z, nsamples);
+}
+
+void ImageBuf_deep_insert_samples(ImageBuf* buf, int x, int y, int z, int samplepos, int nsamples) {
+	static_cast<OIIO::ImageBuf*>(buf)->deep_insert_samples(x, y, z, samplepos, nsamples);
+}
+
+void ImageBuf_deep_erase_samples(ImageBuf* buf, int x, int y, int z, int samplepos, int nsamples) {
+	static_cast<OIIO::ImageBuf*>(buf)->deep_erase_samples(x, y, z, samplepos, nsamples);
+}
 
 // const void* ImageBuf_deep_pixel_ptr(ImageBuf* buf, int x, int y, int z, int c);
 
-// float ImageBuf_deep_value(ImageBuf* buf, int x, int y, int z, int c, int s);
+float ImageBuf_deep_value(ImageBuf* buf, int x, int y, int z, int c, int s) {
+	return static_cast<OIIO::ImageBuf*>(buf)->deep_value(x, y, z, c, s);
+}
+
+uint32_t ImageBuf_deep_value_uint(ImageBuf* buf, int x, int y, int z, int c, int s) {
+	return static_cast<OIIO::ImageBuf*>(buf)->deep_value_uint(x, y, z, c, s);
+}
+
+void ImageBuf_set_deep_value(ImageBuf* buf, int x, int y, int z, int c, int s, float value) {
+	static_cast<OIIO::ImageBuf*>(buf)->set_deep_value(x, y, z, c, s, value);
+}
+
+void ImageBuf_set_deep_value_uint(ImageBuf* buf, int x, int y, int z, int c, int s, uint32_t value) {
+	static_cast<OIIO::ImageBuf*>(buf)->set_deep_value(x, y, z, c, s, value);
+}
+
+DeepData* ImageBuf_deepdata(ImageBuf* buf) {
+	OIIO::DeepData *dd = static_cast<OIIO::ImageBuf*>(buf)->deepdata();
+	return static_cast<DeepData*>(dd);
+}
+
+#else
+
+int ImageBuf_deep_samples(ImageBuf* buf, int x, int y, int z) { return 0; }
+void ImageBuf_set_deep_samples(ImageBuf* buf, int x, int y, int z, int nsamples) {}
+void ImageBuf_deep_insert_samples(ImageBuf* buf, int x, int y, int z, int samplepos, int nsamples) {}
+void ImageBuf_deep_erase_samples(ImageBuf* buf, int x, int y, int z, int samplepos, int nsamples) {}
+float ImageBuf_deep_value(ImageBuf* buf, int x, int y, int z, int c, int s) { return 0; }
+uint32_t ImageBuf_deep_value_uint(ImageBuf* buf, int x, int y, int z, int c, int s) { return 0; }
+void ImageBuf_set_deep_value(ImageBuf* buf, int x, int y, int z, int c, int s, float value) {}
+void ImageBuf_set_deep_value_uint(ImageBuf* buf, int x, int y, int z, int c, int s, uint32_t value) {}
+DeepData* ImageBuf_deepdata(ImageBuf* buf) { return NULL; }
 
-// DeepData* ImageBuf_deepdata(ImageBuf* buf);
+#endif
 
 } // extern "C"
 
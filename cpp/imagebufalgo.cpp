@@ -70,6 +70,64 @@ bool flatten(ImageBuf *dst, const ImageBuf *src, ROI* roi, int nthreads) {
 			nthreads);	
 }
 
+// deepen and deep_merge need OIIO 1.7 (see DeepData_supported)
+#if OIIO_VERSION >= 10700
+
+bool deepen(ImageBuf *dst, const ImageBuf *src, float zvalue, ROI* roi, int nthreads) {
+	return OIIO::ImageBufAlgo::deepen(
+			*(static_cast<OIIO::ImageBuf*>(dst)),
+			*(static_cast<const OIIO::ImageBuf*>(src)),
+			zvalue,
+			*(static_cast<OIIO::ROI*>(roi)),
+			nthreads);
+}
+
+bool deep_merge(ImageBuf *dst, const ImageBuf *A, const ImageBuf *B, bool occlusion_cull, ROI* roi, int nthreads) {
+	return OIIO::ImageBufAlgo::deep_merge(
+			*(static_cast<OIIO::ImageBuf*>(dst)),
+			*(static_cast<const OIIO::ImageBuf*>(A)),
+			*(static_cast<const OIIO::ImageBuf*>(B)),
+			occlusion_cull,
+			*(static_cast<OIIO::ROI*>(roi)),
+			nthreads);
+}
+
+#else
+
+bool deepen(ImageBuf *dst, const ImageBuf *src, float zvalue, ROI* roi, int nthreads) { return false; }
+bool deep_merge(ImageBuf *dst, const ImageBuf *A, const ImageBuf *B, bool occlusion_cull, ROI* roi, int nthreads) {
+	return false;
+}
+
+#endif
+
+bool deep_holdout_supported() {
+#if OIIO_VERSION >= 10800
+	return true;
+#else
+	return false;
+#endif
+}
+
+#if OIIO_VERSION >= 10800
+
+bool deep_holdout(ImageBuf *dst, const ImageBuf *src, const ImageBuf *holdout, ROI* roi, int nthreads) {
+	return OIIO::ImageBufAlgo::deep_holdout(
+			*(static_cast<OIIO::ImageBuf*>(dst)),
+			*(static_cast<const OIIO::ImageBuf*>(src)),
+			*(static_cast<const OIIO::ImageBuf*>(holdout)),
+			*(static_cast<OIIO::ROI*>(roi)),
+			nthreads);
+}
+
+#else
+
+bool deep_holdout(ImageBuf *dst, const ImageBuf *src, const ImageBuf *holdout, ROI* roi, int nthreads) {
+	return false;
+}
+
+#endif
+
 bool crop(ImageBuf *dst, const ImageBuf *src, ROI* roi, int nthreads) {
 	return OIIO::ImageBufAlgo::crop(
 			*(static_cast<OIIO::ImageBuf*>(dst)),
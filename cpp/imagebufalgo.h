@@ -25,6 +25,14 @@ bool channel_append(ImageBuf *dst, const ImageBuf *A, const ImageBuf *B, ROI* ro
 
 bool flatten(ImageBuf *dst, const ImageBuf *src, ROI* roi, int nthreads);
 
+bool deepen(ImageBuf *dst, const ImageBuf *src, float zvalue, ROI* roi, int nthreads);
+
+bool deep_merge(ImageBuf *dst, const ImageBuf *A, const ImageBuf *B, bool occlusion_cull, ROI* roi, int nthreads);
+
+bool deep_holdout_supported();
+
+bool deep_holdout(ImageBuf *dst, const ImageBuf *src, const ImageBuf *holdout, ROI* roi, int nthreads);
+
 bool cut (ImageBuf *dst, const ImageBuf *src, ROI* roi, int nthreads);
 
 bool crop(ImageBuf *dst, const ImageBuf *src, ROI* roi, int nthreads);
@@ -1,7 +1,64 @@
 #include <OpenImageIO/imagebuf.h>
+#include <OpenImageIO/imageio.h>
+
+#include <string.h>
+#include <string>
+#include <vector>
 
 #include "oiio.h"
 
+
+extern OIIO::TypeDesc fromTypeDesc(FullTypeDesc fmt);
+extern FullTypeDesc toTypeDesc(OIIO::TypeDesc fmt);
+
+// Reads a procedural image that has no file on disk. The cache opens it
+// with the spec given to add_file as its config, and its pixels are black
+// until tiles are added with add_tile.
+class SyntheticInput : public OIIO::ImageInput {
+public:
+	virtual const char* format_name() const { return "synthetic"; }
+
+	// A synthetic image is described entirely by its config spec
+	virtual bool open(const std::string &name, OIIO::ImageSpec &newspec) {
+		return false;
+	}
+
+	virtual bool open(const std::string &name, OIIO::ImageSpec &newspec,
+					  const OIIO::ImageSpec &config) {
+		m_spec = config;
+		newspec = m_spec;
+		return true;
+	}
+
+	virtual bool close() { return true; }
+
+#if OIIO_VERSION >= 20000
+	virtual bool read_native_scanline(int subimage, int miplevel, int y, int z, void *data) {
+		memset(data, 0, m_spec.scanline_bytes(true));
+		return true;
+	}
+
+	virtual bool read_native_tile(int subimage, int miplevel, int x, int y, int z, void *data) {
+		memset(data, 0, m_spec.tile_bytes(true));
+		return true;
+	}
+#else
+	virtual bool read_native_scanline(int y, int z, void *data) {
+		memset(data, 0, m_spec.scanline_bytes(true));
+		return true;
+	}
+
+	virtual bool read_native_tile(int x, int y, int z, void *data) {
+		memset(data, 0, m_spec.tile_bytes(true));
+		return true;
+	}
+#endif
+};
+
+static OIIO::ImageInput* create_synthetic_input() {
+	return new SyntheticInput();
+}
+
 extern "C" {
 
 ImageCache* ImageCache_Create(bool shared) {
@@ -16,6 +73,87 @@ void ImageCache_clear(ImageCache *x) {
 	static_cast<OIIO::ImageCache*>(x)->clear();
 }
 
+bool ImageCache_attribute(ImageCache *x, const char *name, FullTypeDesc type, const void *val) {
+	return static_cast<OIIO::ImageCache*>(x)->attribute(name, fromTypeDesc(type), val);
+}
+
+bool ImageCache_getattribute(ImageCache *x, const char *name, FullTypeDesc type, void *val) {
+	return static_cast<OIIO::ImageCache*>(x)->getattribute(name, fromTypeDesc(type), val);
+}
+
+bool ImageCache_getattribute_strings(ImageCache *x, const char *name, FullTypeDesc type, const char **out) {
+	OIIO::TypeDesc t = fromTypeDesc(type);
+	std::vector<OIIO::ustring> vals(t.numelements());
+	if (!static_cast<OIIO::ImageCache*>(x)->getattribute(name, t, &vals[0])) {
+		return false;
+	}
+	// ustrings are never freed, so the pointers remain valid
+	for (size_t i = 0; i < vals.size(); ++i) {
+		out[i] = vals[i].c_str();
+	}
+	return true;
+}
+
+bool ImageCache_get_image_info(ImageCache *x, const char *filename, int subimage, int miplevel,
+								const char *dataname, FullTypeDesc datatype, void *data) {
+	return static_cast<OIIO::ImageCache*>(x)->get_image_info(
+		OIIO::ustring(filename), subimage, miplevel,
+		OIIO::ustring(dataname), fromTypeDesc(datatype), data);
+}
+
+bool ImageCache_get_imagespec(ImageCache *x, const char *filename, ImageSpec *spec,
+								int subimage, int miplevel, bool native) {
+	return static_cast<OIIO::ImageCache*>(x)->get_imagespec(
+		OIIO::ustring(filename), *(static_cast<OIIO::ImageSpec*>(spec)),
+		subimage, miplevel, native);
+}
+
+bool ImageCache_get_pixels(ImageCache *x, const char *filename, int subimage, int miplevel,
+							int xbegin, int xend, int ybegin, int yend, int zbegin, int zend,
+							int chbegin, int chend, FullTypeDesc format, void *result) {
+	return static_cast<OIIO::ImageCache*>(x)->get_pixels(
+		OIIO::ustring(filename), subimage, miplevel,
+		xbegin, xend, ybegin, yend, zbegin, zend,
+		chbegin, chend, fromTypeDesc(format), result);
+}
+
+Tile* ImageCache_get_tile(ImageCache *x, const char *filename, int subimage, int miplevel,
+							int xx, int y, int z) {
+	return (Tile*) static_cast<OIIO::ImageCache*>(x)->get_tile(
+		OIIO::ustring(filename), subimage, miplevel, xx, y, z);
+}
+
+void ImageCache_release_tile(ImageCache *x, Tile *tile) {
+	static_cast<OIIO::ImageCache*>(x)->release_tile(static_cast<OIIO::ImageCache::Tile*>(tile));
+}
+
+const void* ImageCache_tile_pixels(ImageCache *x, Tile *tile, FullTypeDesc *format) {
+	OIIO::TypeDesc fmt;
+	const void *pixels = static_cast<OIIO::ImageCache*>(x)->tile_pixels(
+		static_cast<OIIO::ImageCache::Tile*>(tile), fmt);
+	*format = toTypeDesc(fmt);
+	return pixels;
+}
+
+bool ImageCache_add_file(ImageCache *x, const char *filename, ImageSpec *config) {
+	return static_cast<OIIO::ImageCache*>(x)->add_file(
+		OIIO::ustring(filename), create_synthetic_input,
+		static_cast<OIIO::ImageSpec*>(config));
+}
+
+bool ImageCache_add_tile(ImageCache *x, const char *filename, int subimage, int miplevel,
+							int xx, int y, int z, FullTypeDesc format, const void *buffer) {
+#if OIIO_VERSION >= 20000
+	return static_cast<OIIO::ImageCache*>(x)->add_tile(
+		OIIO::ustring(filename), subimage, miplevel, xx, y, z,
+		0, -1, fromTypeDesc(format), buffer);
+#else
+	return static_cast<OIIO::ImageCache*>(x)->add_tile(
+		OIIO::ustring(filename), subimage, miplevel, xx, y, z,
+		fromTypeDesc(format), buffer);
+#endif
+}
+
 const char* ImageCache_geterror(ImageCache* x) {
 	std::string sstring = static_cast<OIIO::ImageCache*>(x)->geterror();
 	if (sstring.empty()) {
@@ -1,12 +1,16 @@
 #include <OpenImageIO/imageio.h>
+#if OIIO_VERSION >= 20000
+#include <OpenImageIO/filesystem.h>
+#endif
 
+#include <memory>
 #include <string>
 
 #include "oiio.h"
 
 
-extern OIIO::TypeDesc fromTypeDesc(TypeDesc fmt);
-extern TypeDesc toTypeDesc(OIIO::TypeDesc fmt);
+extern OIIO::TypeDesc fromTypeDesc(FullTypeDesc fmt);
+extern FullTypeDesc toTypeDesc(OIIO::TypeDesc fmt);
 
 
 
@@ -16,16 +20,46 @@ void deleteImageInput(ImageInput *in) {
 	delete static_cast<OIIO::ImageInput*>(in);
 }
 
+// OIIO 2.x returns a unique_ptr from open and create, whose
+// ImageInput is released to be owned by the Go ImageInput.
+
 ImageInput* ImageInput_Open(const char* filename, const ImageSpec *config) {
 	std::string s_filename(filename);
+#if OIIO_VERSION >= 20000
+	return (ImageInput*) OIIO::ImageInput::open(s_filename, static_cast<const OIIO::ImageSpec*>(config)).release();
+#else
 	return (ImageInput*) OIIO::ImageInput::open(s_filename, static_cast<const OIIO::ImageSpec*>(config));
+#endif
 }
 
 ImageInput* ImageInput_Create(const char* filename, const char* plugin_searchpath) {
 	std::string s_filename(filename);
 	std::string s_path(plugin_searchpath);
+#if OIIO_VERSION >= 20000
+	return (ImageInput*) OIIO::ImageInput::create(s_filename, s_path).release();
+#else
 	return (ImageInput*) OIIO::ImageInput::create(s_filename, s_path);
+#endif
+}
+
+bool ImageInput_format_supports(const char* filename, const char* feature) {
+#if OIIO_VERSION >= 20000
+	std::unique_ptr<OIIO::ImageInput> in = OIIO::ImageInput::create(std::string(filename));
+	return in && in->supports(std::string(feature));
+#else
+	return false;
+#endif
+}
 
+ImageInput* ImageInput_Open_IOProxy(const char* filename, IOProxy *proxy) {
+#if OIIO_VERSION >= 20000
+	OIIO::ImageSpec config;
+	OIIO::Filesystem::IOProxy *p = static_cast<OIIO::Filesystem::IOProxy*>(proxy);
+	config.attribute("oiio:ioproxy", OIIO::TypeDesc::PTR, &p);
+	return (ImageInput*) OIIO::ImageInput::open(std::string(filename), &config).release();
+#else
+	return NULL;
+#endif
 }
 
 const char* ImageInput_geterror(ImageInput *in) {
@@ -89,11 +123,11 @@ bool ImageInput_read_image_floats(ImageInput *in, float* data) {
 	return static_cast<OIIO::ImageInput*>(in)->read_image(data);	
 }
 
-bool ImageInput_read_image_format(ImageInput *in, TypeDesc format, void* data, void* cbk_data)
+bool ImageInput_read_image_format(ImageInput *in, FullTypeDesc format, void* data, uintptr_t cbk_handle)
 {	
 	ProgressCallback cbk = NULL;
-	if (cbk_data != NULL) {
-		cbk = &image_progress_callback;
+	if (cbk_handle != 0) {
+		cbk = &progress_callback;
 	}
 
 	return static_cast<OIIO::ImageInput*>(in)->read_image(
@@ -103,17 +137,99 @@ bool ImageInput_read_image_format(ImageInput *in, TypeDesc format, void* data, v
 												OIIO::AutoStride,
 												OIIO::AutoStride,
 												cbk,
-												cbk_data);
+												(void*) cbk_handle);
 }
 
 bool ImageInput_read_scanline_floats(ImageInput *in, int y, int z, float* data) {
 	return static_cast<OIIO::ImageInput*>(in)->read_scanline(y, z, data);	
 }
 
+bool ImageInput_read_scanlines_format(ImageInput *in, int ybegin, int yend, int z, int chbegin, int chend,
+									FullTypeDesc format, void* data, stride_t xstride, stride_t ystride)
+{
+	return static_cast<OIIO::ImageInput*>(in)->read_scanlines(
+												ybegin, yend, z,
+												chbegin, chend,
+												fromTypeDesc(format),
+												data,
+												xstride,
+												ystride);
+}
+
 bool ImageInput_read_tile_floats(ImageInput *in, int x, int y, int z, float* data) {
 	return static_cast<OIIO::ImageInput*>(in)->read_tile(x, y, z, data);	
 }
 
+bool ImageInput_read_tiles_format(ImageInput *in, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend,
+								int chbegin, int chend, FullTypeDesc format, void* data,
+								stride_t xstride, stride_t ystride, stride_t zstride)
+{
+	return static_cast<OIIO::ImageInput*>(in)->read_tiles(
+												xbegin, xend,
+												ybegin, yend,
+												zbegin, zend,
+												chbegin, chend,
+												fromTypeDesc(format),
+												data,
+												xstride,
+												ystride,
+												zstride);
+}
+
+bool ImageInput_read_native_scanline(ImageInput *in, int y, int z, void *data) {
+	return static_cast<OIIO::ImageInput*>(in)->read_native_scanline(y, z, data);
+}
+
+bool ImageInput_read_native_tile(ImageInput *in, int x, int y, int z, void *data) {
+	return static_cast<OIIO::ImageInput*>(in)->read_native_tile(x, y, z, data);
+}
+
+bool ImageInput_read_native_tiles(ImageInput *in, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend, void *data) {
+	return static_cast<OIIO::ImageInput*>(in)->read_native_tiles(
+												xbegin, xend,
+												ybegin, yend,
+												zbegin, zend,
+												data);
+}
+
+// Deep reads need OIIO 1.7 (see DeepData_supported)
+#if OIIO_VERSION >= 10700
+
+bool ImageInput_read_native_deep_scanlines(ImageInput *in, int ybegin, int yend, int z, int chbegin, int chend,
+											DeepData* deepdata)
+{
+	return static_cast<OIIO::ImageInput*>(in)->read_native_deep_scanlines(
+													ybegin, yend, z,
+													chbegin, chend,
+													*(static_cast<OIIO::DeepData*>(deepdata)));
+}
+
+bool ImageInput_read_native_deep_tiles(ImageInput *in, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend,
+										int chbegin, int chend, DeepData* deepdata)
+{
+	return static_cast<OIIO::ImageInput*>(in)->read_native_deep_tiles(
+													xbegin, xend,
+													ybegin, yend,
+													zbegin, zend,
+													chbegin, chend,
+													*(static_cast<OIIO::DeepData*>(deepdata)));
+}
+
+bool ImageInput_read_native_deep_image(ImageInput *in, DeepData* deepdata) {
+	return static_cast<OIIO::ImageInput*>(in)->read_native_deep_image(
+													*(static_cast<OIIO::DeepData*>(deepdata)));
+}
+
+#else
+
+bool ImageInput_read_native_deep_scanlines(ImageInput *in, int ybegin, int yend, int z, int chbegin, int chend,
+											DeepData* deepdata) { return false; }
+bool ImageInput_read_native_deep_tiles(ImageInput *in, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend,
+										int chbegin, int chend, DeepData* deepdata) { return false; }
+bool ImageInput_read_native_deep_image(ImageInput *in, DeepData* deepdata) { return false; }
+
+#endif
+
 
 } // extern "C"
 
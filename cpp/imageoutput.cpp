@@ -1,9 +1,26 @@
 #include <OpenImageIO/imageio.h>
+#if OIIO_VERSION >= 20000
+#include <OpenImageIO/filesystem.h>
+#endif
 
 #include <string>
+#include <vector>
 
 #include "oiio.h"
 
+
+extern OIIO::TypeDesc fromTypeDesc(FullTypeDesc fmt);
+
+OIIO::ImageOutput::OpenMode fromOpenMode(OpenMode m) {
+	switch (m) {
+	case OPENMODE_CREATE: 			return OIIO::ImageOutput::Create;
+	case OPENMODE_APPENDSUBIMAGE: 	return OIIO::ImageOutput::AppendSubimage;
+	case OPENMODE_APPENDMIPLEVEL: 	return OIIO::ImageOutput::AppendMIPLevel;
+	}
+	return OIIO::ImageOutput::Create;
+}
+
+
 extern "C" {
 
 void deleteImageOutput(ImageOutput *out) {
@@ -13,7 +30,11 @@ void deleteImageOutput(ImageOutput *out) {
 ImageOutput* ImageOutput_Create(const char* filename, const char* plugin_searchpath) {
 	std::string s_filename(filename);
 	std::string s_path(plugin_searchpath);
+#if OIIO_VERSION >= 20000
+	return (ImageOutput*) OIIO::ImageOutput::create(s_filename, s_path).release();
+#else
 	return (ImageOutput*) OIIO::ImageOutput::create(s_filename, s_path);
+#endif
 }
 
 const char* ImageOutput_geterror(ImageOutput *out) {
@@ -38,5 +59,130 @@ bool ImageOutput_supports(ImageOutput *out, const char* feature){
 	return static_cast<OIIO::ImageOutput*>(out)->supports(s_feature);
 }
 
+bool ImageOutput_open(ImageOutput *out, const char* name, const ImageSpec *spec, OpenMode mode) {
+	std::string s_name(name);
+	return static_cast<OIIO::ImageOutput*>(out)->open(s_name,
+													  *(static_cast<const OIIO::ImageSpec*>(spec)),
+													  fromOpenMode(mode));
+}
+
+bool ImageOutput_open_multi(ImageOutput *out, const char* name, int subimages, const ImageSpec **specs) {
+	std::string s_name(name);
+	std::vector<OIIO::ImageSpec> vec;
+	for (int i = 0; i < subimages; i++) {
+		vec.push_back(*(static_cast<const OIIO::ImageSpec*>(specs[i])));
+	}
+	return static_cast<OIIO::ImageOutput*>(out)->open(s_name, subimages, &vec[0]);
+}
+
+bool ImageOutput_open_ioproxy(ImageOutput *out, const char* name, const ImageSpec *spec, IOProxy *proxy) {
+#if OIIO_VERSION >= 20000
+	OIIO::ImageSpec newspec = *(static_cast<const OIIO::ImageSpec*>(spec));
+	OIIO::Filesystem::IOProxy *p = static_cast<OIIO::Filesystem::IOProxy*>(proxy);
+	newspec.attribute("oiio:ioproxy", OIIO::TypeDesc::PTR, &p);
+	return static_cast<OIIO::ImageOutput*>(out)->open(std::string(name), newspec, OIIO::ImageOutput::Create);
+#else
+	return false;
+#endif
+}
+
+bool ImageOutput_close(ImageOutput *out) {
+	return static_cast<OIIO::ImageOutput*>(out)->close();
+}
+
+bool ImageOutput_write_scanline(ImageOutput *out, int y, int z, FullTypeDesc format, const void *data, stride_t xstride) {
+	return static_cast<OIIO::ImageOutput*>(out)->write_scanline(y, z, fromTypeDesc(format), data, xstride);
+}
+
+bool ImageOutput_write_scanlines(ImageOutput *out, int ybegin, int yend, int z, FullTypeDesc format, const void *data,
+									stride_t xstride, stride_t ystride)
+{
+	return static_cast<OIIO::ImageOutput*>(out)->write_scanlines(ybegin, yend, z,
+																 fromTypeDesc(format),
+																 data,
+																 xstride, ystride);
+}
+
+bool ImageOutput_write_tile(ImageOutput *out, int x, int y, int z, FullTypeDesc format, const void *data,
+							stride_t xstride, stride_t ystride, stride_t zstride)
+{
+	return static_cast<OIIO::ImageOutput*>(out)->write_tile(x, y, z,
+															fromTypeDesc(format),
+															data,
+															xstride, ystride, zstride);
+}
+
+bool ImageOutput_write_tiles(ImageOutput *out, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend,
+							FullTypeDesc format, const void *data, stride_t xstride, stride_t ystride, stride_t zstride)
+{
+	return static_cast<OIIO::ImageOutput*>(out)->write_tiles(xbegin, xend,
+															 ybegin, yend,
+															 zbegin, zend,
+															 fromTypeDesc(format),
+															 data,
+															 xstride, ystride, zstride);
+}
+
+bool ImageOutput_write_rectangle(ImageOutput *out, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend,
+								FullTypeDesc format, const void *data, stride_t xstride, stride_t ystride, stride_t zstride)
+{
+	return static_cast<OIIO::ImageOutput*>(out)->write_rectangle(xbegin, xend,
+																 ybegin, yend,
+																 zbegin, zend,
+																 fromTypeDesc(format),
+																 data,
+																 xstride, ystride, zstride);
+}
+
+bool ImageOutput_write_image(ImageOutput *out, FullTypeDesc format, const void *data,
+							stride_t xstride, stride_t ystride, stride_t zstride, uintptr_t cbk_handle)
+{
+	ProgressCallback cbk = NULL;
+	if (cbk_handle != 0) {
+		cbk = &progress_callback;
+	}
+
+	return static_cast<OIIO::ImageOutput*>(out)->write_image(fromTypeDesc(format),
+															 data,
+															 xstride, ystride, zstride,
+															 cbk,
+															 (void*) cbk_handle);
+}
+
+
+// Deep writes need OIIO 1.7 (see DeepData_supported)
+#if OIIO_VERSION >= 10700
+
+bool ImageOutput_write_deep_scanlines(ImageOutput *out, int ybegin, int yend, int z, const DeepData *deepdata) {
+	return static_cast<OIIO::ImageOutput*>(out)->write_deep_scanlines(
+													ybegin, yend, z,
+													*(static_cast<const OIIO::DeepData*>(deepdata)));
+}
+
+bool ImageOutput_write_deep_tiles(ImageOutput *out, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend,
+									const DeepData *deepdata)
+{
+	return static_cast<OIIO::ImageOutput*>(out)->write_deep_tiles(
+													xbegin, xend,
+													ybegin, yend,
+													zbegin, zend,
+													*(static_cast<const OIIO::DeepData*>(deepdata)));
+}
+
+bool ImageOutput_write_deep_image(ImageOutput *out, const DeepData *deepdata) {
+	return static_cast<OIIO::ImageOutput*>(out)->write_deep_image(
+													*(static_cast<const OIIO::DeepData*>(deepdata)));
+}
+
+#else
+
+bool ImageOutput_write_deep_scanlines(ImageOutput *out, int ybegin, int yend, int z,
+										const DeepData *deepdata) { return false; }
+bool ImageOutput_write_deep_tiles(ImageOutput *out, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend,
+									const DeepData *deepdata) { return false; }
+bool ImageOutput_write_deep_image(ImageOutput *out, const DeepData *deepdata) { return false; }
+
+#endif
+
 
 } // extern "C"
@@ -1,42 +1,15 @@
 #include <OpenImageIO/imageio.h>
 
+#include <string.h>
+#include <stdlib.h>
 #include <string>
+#include <vector>
 
 #include "oiio.h"
 
 
-OIIO::TypeDesc fromTypeDesc(TypeDesc fmt) {
-	switch (fmt) {
-	case TYPE_UINT8: 	return OIIO::TypeDesc::UINT8;
-	case TYPE_INT8: 	return OIIO::TypeDesc::INT8;
-	case TYPE_UINT16: 	return OIIO::TypeDesc::UINT16;
-	case TYPE_INT16: 	return OIIO::TypeDesc::INT16;
-	case TYPE_UINT: 	return OIIO::TypeDesc::UINT;
-	case TYPE_INT: 		return OIIO::TypeDesc::INT;
-	case TYPE_UINT64: 	return OIIO::TypeDesc::UINT64;
-	case TYPE_INT64: 	return OIIO::TypeDesc::INT64;
-	case TYPE_HALF: 	return OIIO::TypeDesc::HALF;
-	case TYPE_FLOAT: 	return OIIO::TypeDesc::FLOAT;
-	case TYPE_DOUBLE: 	return OIIO::TypeDesc::DOUBLE;
-	case TYPE_UNKNOWN: 	return OIIO::TypeDesc::UNKNOWN;
-	}
-	return OIIO::TypeDesc::UNKNOWN;
-}
-
-TypeDesc toTypeDesc(OIIO::TypeDesc fmt) {
-	if (fmt == OIIO::TypeDesc::UINT8) 	return TYPE_UINT8;
-	if (fmt == OIIO::TypeDesc::INT8) 	return TYPE_INT8;
-	if (fmt == OIIO::TypeDesc::UINT16) 	return TYPE_UINT16;
-	if (fmt == OIIO::TypeDesc::INT16) 	return TYPE_INT16;
-	if (fmt == OIIO::TypeDesc::UINT)	return TYPE_UINT;
-	if (fmt == OIIO::TypeDesc::INT)		return TYPE_INT;
-	if (fmt == OIIO::TypeDesc::UINT64) 	return TYPE_UINT64;
-	if (fmt == OIIO::TypeDesc::INT64) 	return TYPE_INT64;
-	if (fmt == OIIO::TypeDesc::HALF) 	return TYPE_HALF;
-	if (fmt == OIIO::TypeDesc::FLOAT) 	return TYPE_FLOAT;
-	if (fmt == OIIO::TypeDesc::DOUBLE) 	return TYPE_DOUBLE;
-	return TYPE_UNKNOWN;
-}
+extern OIIO::TypeDesc fromTypeDesc(FullTypeDesc fmt);
+extern FullTypeDesc toTypeDesc(OIIO::TypeDesc fmt);
 
 extern "C" {
 
@@ -44,14 +17,18 @@ void deleteImageSpec(ImageSpec *spec) {
 	delete static_cast<OIIO::ImageSpec*>(spec);
 }
 
-ImageSpec* ImageSpec_New(TypeDesc fmt) {
+ImageSpec* ImageSpec_New(FullTypeDesc fmt) {
 	return (ImageSpec*) new OIIO::ImageSpec(fromTypeDesc(fmt));
 }
 
-ImageSpec* ImageSpec_New_Size(int xres, int yres, int nchans, TypeDesc fmt) {
+ImageSpec* ImageSpec_New_Size(int xres, int yres, int nchans, FullTypeDesc fmt) {
 	return (ImageSpec*) new OIIO::ImageSpec(xres, yres, nchans, fromTypeDesc(fmt));
 }
 
+void ImageSpec_copy(ImageSpec *dst, const ImageSpec *src) {
+	*(static_cast<OIIO::ImageSpec*>(dst)) = *(static_cast<const OIIO::ImageSpec*>(src));
+}
+
 void ImageSpec_default_channel_names(ImageSpec *spec) {
 	static_cast<OIIO::ImageSpec*>(spec)->default_channel_names();
 }
@@ -96,7 +73,7 @@ bool ImageSpec_size_safe (ImageSpec *spec) {
 	return static_cast<OIIO::ImageSpec*>(spec)->size_t_safe();
 }
 
-TypeDesc ImageSpec_channelformat(ImageSpec *spec, int chan) {
+FullTypeDesc ImageSpec_channelformat(ImageSpec *spec, int chan) {
 	OIIO::TypeDesc c_spec = static_cast<OIIO::ImageSpec*>(spec)->channelformat(chan);
 	return toTypeDesc(c_spec);
 }
@@ -230,26 +207,27 @@ void ImageSpec_set_nchannels(ImageSpec *spec, int val) {
 	static_cast<OIIO::ImageSpec*>(spec)->nchannels = val;
 }
 
-TypeDesc ImageSpec_format(ImageSpec *spec){
+FullTypeDesc ImageSpec_format(ImageSpec *spec){
 	OIIO::TypeDesc c_typ = static_cast<OIIO::ImageSpec*>(spec)->format;
 	return toTypeDesc(c_typ);
 }
 
-void ImageSpec_set_format(ImageSpec *spec, TypeDesc fmt) {
+void ImageSpec_set_format(ImageSpec *spec, FullTypeDesc fmt) {
 	static_cast<OIIO::ImageSpec*>(spec)->set_format(fromTypeDesc(fmt));
 }
 
-void ImageSpec_channelformats(ImageSpec *spec, TypeDesc* out) {
+void ImageSpec_channelformats(ImageSpec *spec, FullTypeDesc* out) {
 	std::vector<OIIO::TypeDesc> vec = static_cast<OIIO::ImageSpec*>(spec)->channelformats;
 	for (std::vector<OIIO::TypeDesc>::size_type i = 0; i != vec.size(); i++) {
 		out[i] = toTypeDesc(vec[i]);
 	}
 }
 
-void ImageSpec_set_channelformats(ImageSpec *spec, TypeDesc* formats){
+void ImageSpec_set_channelformats(ImageSpec *spec, FullTypeDesc* formats){
 	OIIO::ImageSpec *ptr = static_cast<OIIO::ImageSpec*>(spec);
 	std::vector<OIIO::TypeDesc> vec = ptr->channelformats;
-	for (std::vector<std::string>::size_type i = 0; i != vec.size(); i++) {
+	vec.resize(ptr->nchannels);
+	for (std::vector<OIIO::TypeDesc>::size_type i = 0; i != vec.size(); i++) {
 		vec[i] = fromTypeDesc(formats[i]);
 	}
 	ptr->channelformats = vec;
@@ -265,6 +243,7 @@ void ImageSpec_channelnames(ImageSpec *spec, char** out) {
 void ImageSpec_set_channelnames(ImageSpec *spec, char** names) {
 	OIIO::ImageSpec *ptr = static_cast<OIIO::ImageSpec*>(spec);
 	std::vector<std::string> vec = ptr->channelnames;
+	vec.resize(ptr->nchannels);
 	for (std::vector<std::string>::size_type i = 0; i != vec.size(); i++) {
 		vec[i] = std::string(names[i]);
 	}
@@ -295,15 +274,27 @@ void ImageSpec_set_deep(ImageSpec *spec, bool val) {
 	static_cast<OIIO::ImageSpec*>(spec)->deep = val;
 }
 
-const char* ImageSpec_to_xml(ImageSpec *spec) {
-	return static_cast<OIIO::ImageSpec*>(spec)->to_xml().c_str();
+char* ImageSpec_to_xml(ImageSpec *spec) {
+	return strdup(static_cast<OIIO::ImageSpec*>(spec)->to_xml().c_str());
+}
+
+char* ImageSpec_metadata_val(ImageSpec *spec, const char* name, bool human) {
+	OIIO::ParamValue *p = static_cast<OIIO::ImageSpec*>(spec)->find_attribute(name);
+	if (p == NULL) {
+		return NULL;
+	}
+	return strdup(OIIO::ImageSpec::metadata_val(*p, human).c_str());
 }
 
-void ImageSpec_attribute_type_data(ImageSpec *spec, const char* name, TypeDesc type, const void *value) {
+void ImageSpec_from_xml(ImageSpec *spec, const char *xml) {
+	static_cast<OIIO::ImageSpec*>(spec)->from_xml(xml);
+}
+
+void ImageSpec_attribute_type_data(ImageSpec *spec, const char* name, FullTypeDesc type, const void *value) {
 	static_cast<OIIO::ImageSpec*>(spec)->attribute(name, fromTypeDesc(type), value);
 }
 
-void ImageSpec_attribute_type_char(ImageSpec *spec, const char* name, TypeDesc type, const char* value) {
+void ImageSpec_attribute_type_char(ImageSpec *spec, const char* name, FullTypeDesc type, const char* value) {
 	static_cast<OIIO::ImageSpec*>(spec)->attribute(name, fromTypeDesc(type), value);
 }
 
@@ -323,7 +314,7 @@ void ImageSpec_attribute_char(ImageSpec *spec, const char* name, const char* val
 	static_cast<OIIO::ImageSpec*>(spec)->attribute(name, value);
 }
 
-void ImageSpec_erase_attribute(ImageSpec *spec, const char* name, TypeDesc type, bool caseSensitive) {
+void ImageSpec_erase_attribute(ImageSpec *spec, const char* name, FullTypeDesc type, bool caseSensitive) {
 	static_cast<OIIO::ImageSpec*>(spec)->erase_attribute(name, fromTypeDesc(type), caseSensitive);
 }
 
@@ -339,6 +330,78 @@ const char* ImageSpec_get_string_attribute(ImageSpec *spec, const char* name, co
 	return static_cast<OIIO::ImageSpec*>(spec)->get_string_attribute(name, defaultval).c_str();
 }
 
-} // extern "C"
+int ImageSpec_num_attributes(ImageSpec *spec) {
+	return int(static_cast<OIIO::ImageSpec*>(spec)->extra_attribs.size());
+}
+
+int ImageSpec_find_attribute(ImageSpec *spec, const char* name) {
+	OIIO::ImageSpec *ptr = static_cast<OIIO::ImageSpec*>(spec);
+	OIIO::ParamValue *p = ptr->find_attribute(name);
+	if (p == NULL) {
+		return -1;
+	}
+	return int(p - &ptr->extra_attribs[0]);
+}
+
+const char* ImageSpec_attribute_name(ImageSpec *spec, int index) {
+	return static_cast<OIIO::ImageSpec*>(spec)->extra_attribs[index].name().c_str();
+}
 
+FullTypeDesc ImageSpec_attribute_type(ImageSpec *spec, int index) {
+	return toTypeDesc(static_cast<OIIO::ImageSpec*>(spec)->extra_attribs[index].type());
+}
+
+const void* ImageSpec_attribute_data(ImageSpec *spec, int index) {
+	return static_cast<OIIO::ImageSpec*>(spec)->extra_attribs[index].data();
+}
+
+// Copies an encoded metadata block to memory owned by the caller
+static void* copy_blob(const std::vector<char> &blob, size_t *length) {
+	*length = blob.size();
+	if (blob.empty()) {
+		return NULL;
+	}
+	void *out = malloc(blob.size());
+	memcpy(out, &blob[0], blob.size());
+	return out;
+}
 
+// The attribute name of the EXIF ISO tag (0x8827), which encode_exif
+// knows by its EXIF 2.2 name before OIIO 2.0.
+const char* ImageSpec_exif_iso_name() {
+#if OIIO_VERSION >= 20000
+	return "Exif:PhotographicSensitivity";
+#else
+	return "Exif:ISOSpeedRatings";
+#endif
+}
+
+bool ImageSpec_decode_exif(ImageSpec *spec, const void *data, int length) {
+	return OIIO::decode_exif(data, length, *(static_cast<OIIO::ImageSpec*>(spec)));
+}
+
+void* ImageSpec_encode_exif(ImageSpec *spec, size_t *length) {
+	std::vector<char> blob;
+	OIIO::encode_exif(*(static_cast<OIIO::ImageSpec*>(spec)), blob);
+	return copy_blob(blob, length);
+}
+
+bool ImageSpec_decode_iptc(ImageSpec *spec, const void *data, int length) {
+	return OIIO::decode_iptc_iim(data, length, *(static_cast<OIIO::ImageSpec*>(spec)));
+}
+
+void* ImageSpec_encode_iptc(ImageSpec *spec, size_t *length) {
+	std::vector<char> blob;
+	OIIO::encode_iptc_iim(*(static_cast<OIIO::ImageSpec*>(spec)), blob);
+	return copy_blob(blob, length);
+}
+
+bool ImageSpec_decode_xmp(ImageSpec *spec, const char *xml) {
+	return OIIO::decode_xmp(std::string(xml), *(static_cast<OIIO::ImageSpec*>(spec)));
+}
+
+char* ImageSpec_encode_xmp(ImageSpec *spec, bool minimal) {
+	return strdup(OIIO::encode_xmp(*(static_cast<OIIO::ImageSpec*>(spec)), minimal).c_str());
+}
+
+} // extern "C"
@@ -0,0 +1,141 @@
+#include <OpenImageIO/imageio.h>
+
+#include <string.h>
+#include <string>
+#include <vector>
+
+#include "oiio.h"
+
+#if OIIO_VERSION >= 20000
+#include <OpenImageIO/filesystem.h>
+
+// Reads through a Go io.ReaderAt, which is passed to C as a cgo.Handle,
+// since Go pointers may not be retained by C.
+class GoReaderProxy : public OIIO::Filesystem::IOProxy {
+public:
+	GoReaderProxy(const std::string &filename, uintptr_t handle, int64_t size)
+		: IOProxy(filename, Read), m_handle(handle), m_size(size) {}
+
+	virtual const char* proxytype() const { return "goreader"; }
+
+	virtual size_t read(void *buf, size_t size) {
+		size_t n = pread(buf, size, m_pos);
+		m_pos += n;
+		return n;
+	}
+
+	virtual size_t pread(void *buf, size_t size, int64_t offset) {
+		if (offset < 0 || offset >= m_size) {
+			return 0;
+		}
+		if (int64_t(size) > m_size - offset) {
+			size = size_t(m_size - offset);
+		}
+		return ioproxy_read_at(m_handle, buf, size, offset);
+	}
+
+	virtual size_t size() const { return size_t(m_size); }
+
+private:
+	uintptr_t m_handle;
+	int64_t m_size;
+};
+
+// Collects the written file in memory. Formats such as OpenEXR seek
+// back to patch offset tables, which a Go io.Writer can not do, so the
+// data is only handed to the io.Writer once the ImageOutput is closed.
+class GoWriterProxy : public OIIO::Filesystem::IOProxy {
+public:
+	GoWriterProxy(const std::string &filename)
+		: IOProxy(filename, Write) {}
+
+	virtual const char* proxytype() const { return "gowriter"; }
+
+	virtual size_t write(const void *buf, size_t size) {
+		size_t n = pwrite(buf, size, m_pos);
+		m_pos += n;
+		return n;
+	}
+
+	virtual size_t pwrite(const void *buf, size_t size, int64_t offset) {
+		if (offset < 0) {
+			return 0;
+		}
+		if (size_t(offset) + size > m_data.size()) {
+			m_data.resize(size_t(offset) + size);
+		}
+		memcpy(&m_data[offset], buf, size);
+		return size;
+	}
+
+	virtual size_t size() const { return m_data.size(); }
+
+	const std::vector<unsigned char>& data() const { return m_data; }
+
+private:
+	std::vector<unsigned char> m_data;
+};
+
+// The C IOProxy handle always points at the IOProxy base class,
+// so that it can be deleted and passed to OIIO as one.
+static GoWriterProxy* writerProxy(IOProxy *proxy) {
+	return static_cast<GoWriterProxy*>(static_cast<OIIO::Filesystem::IOProxy*>(proxy));
+}
+#endif
+
+
+extern "C" {
+
+bool ioproxy_supported() {
+#if OIIO_VERSION >= 20000
+	return true;
+#else
+	return false;
+#endif
+}
+
+void deleteIOProxy(IOProxy *proxy) {
+#if OIIO_VERSION >= 20000
+	delete static_cast<OIIO::Filesystem::IOProxy*>(proxy);
+#endif
+}
+
+IOProxy* IOProxy_NewReader(const char* filename, uintptr_t handle, int64_t size) {
+#if OIIO_VERSION >= 20000
+	OIIO::Filesystem::IOProxy *proxy = new GoReaderProxy(std::string(filename), handle, size);
+	return (IOProxy*) proxy;
+#else
+	return NULL;
+#endif
+}
+
+IOProxy* IOProxy_NewWriter(const char* filename) {
+#if OIIO_VERSION >= 20000
+	OIIO::Filesystem::IOProxy *proxy = new GoWriterProxy(std::string(filename));
+	return (IOProxy*) proxy;
+#else
+	return NULL;
+#endif
+}
+
+size_t IOProxy_writer_size(IOProxy *proxy) {
+#if OIIO_VERSION >= 20000
+	return writerProxy(proxy)->data().size();
+#else
+	return 0;
+#endif
+}
+
+const void* IOProxy_writer_data(IOProxy *proxy) {
+#if OIIO_VERSION >= 20000
+	const std::vector<unsigned char> &data = writerProxy(proxy)->data();
+	if (data.empty()) {
+		return NULL;
+	}
+	return &data[0];
+#else
+	return NULL;
+#endif
+}
+
+}
@@ -1,3 +1,30 @@
+#include <OpenImageIO/imageio.h>
+
+#include <string.h>
+
+#include "oiio.h"
+
 extern "C" {
 	#include "_cgo_export.h"
-}
\ No newline at end of file
+
+char* oiio_geterror() {
+	std::string err = OIIO::geterror();
+	if (err.empty()) {
+		return NULL;
+	}
+	return strdup(err.c_str());
+}
+
+char* oiio_extension_list() {
+	std::string list;
+	if (!OIIO::getattribute("extension_list", list) || list.empty()) {
+		return NULL;
+	}
+	return strdup(list.c_str());
+}
+
+bool progress_callback(void *opaque_data, float portion_done) {
+	return image_progress_callback((uintptr_t) opaque_data, portion_done);
+}
+
+}
@@ -3,6 +3,7 @@
 
 #include <stdbool.h>
 #include <stddef.h>
+#include <stdint.h>
 
 #ifdef __cplusplus
 extern "C" {
@@ -28,6 +29,10 @@ static void freeCharArray(char **a, int size) {
 typedef ptrdiff_t stride_t;
 typedef unsigned long long imagesize_t;
 
+// Matches OIIO::AutoStride, which asks OIIO to compute
+// contiguous strides from the data format and image dimensions.
+#define AUTOSTRIDE PTRDIFF_MIN
+
 typedef void ImageSpec;
 typedef void ImageInput;
 typedef void ImageOutput;
@@ -36,6 +41,7 @@ typedef void DeepData;
 typedef void ImageBuf;
 typedef void ROI;
 typedef void Tile;
+typedef void IOProxy;
 
 typedef bool(* ProgressCallback)(void *opaque_data, float portion_done);
 
@@ -56,9 +62,22 @@ typedef enum TypeDesc {
 	TYPE_INT64 		= 7,
 	TYPE_HALF 		= 8,
 	TYPE_FLOAT 		= 9,
-	TYPE_DOUBLE 	= 10
+	TYPE_DOUBLE 	= 10,
+	TYPE_STRING 	= 11,
+	TYPE_NONE 		= 12,
+	TYPE_PTR 		= 13
 } TypeDesc;
 
+// The full description of a data type: a base type, aggregated into
+// vectors or matrices, with the vector semantics and array length of
+// OIIO::TypeDesc.
+typedef struct FullTypeDesc {
+	TypeDesc basetype;
+	int aggregate;
+	int vecsemantics;
+	int arraylen;
+} FullTypeDesc;
+
 
 typedef enum IBStorage {
 	IBSTORAGE_UNINITIALIZED,
@@ -78,6 +97,48 @@ typedef enum WrapMode {
 } WrapMode;
 
 
+typedef enum OpenMode {
+	OPENMODE_CREATE,
+	OPENMODE_APPENDSUBIMAGE,
+	OPENMODE_APPENDMIPLEVEL,
+} OpenMode;
+
+
+// Global
+//
+
+// Returns a copy of the global error message, which must be freed,
+// or NULL if there is no error.
+char* oiio_geterror();
+
+// Returns a copy of the "extension_list" attribute, which must be freed,
+// or NULL if it is not available. ie. "tiff:tif,tiff;openexr:exr;..."
+char* oiio_extension_list();
+
+// Forwards progress to the Go callback registered under the handle
+// that is passed as the opaque data.
+bool progress_callback(void *opaque_data, float portion_done);
+
+
+// TypeDesc
+//
+
+const char* TypeDesc_c_str(FullTypeDesc type);
+bool TypeDesc_fromstring(const char* typestring, FullTypeDesc *out);
+size_t TypeDesc_size(FullTypeDesc type);
+
+
+// IOProxy
+//
+
+bool ioproxy_supported();
+void deleteIOProxy(IOProxy *proxy);
+IOProxy* IOProxy_NewReader(const char* filename, uintptr_t handle, int64_t size);
+IOProxy* IOProxy_NewWriter(const char* filename);
+size_t IOProxy_writer_size(IOProxy *proxy);
+const void* IOProxy_writer_data(IOProxy *proxy);
+
+
 // ImageInput
 //
 
@@ -85,6 +146,8 @@ void deleteImageInput(ImageInput *in);
 
 ImageInput* ImageInput_Open(const char* filename, const ImageSpec *config);
 ImageInput* ImageInput_Create(const char* filename, const char* plugin_searchpath);
+bool ImageInput_format_supports(const char* filename, const char* feature);
+ImageInput* ImageInput_Open_IOProxy(const char* filename, IOProxy *proxy);
 
 const char* ImageInput_format_name(ImageInput *in);
 bool ImageInput_valid_file(ImageInput *in, const char* filename);
@@ -98,20 +161,22 @@ int ImageInput_current_miplevel(ImageInput *in);
 bool ImageInput_seek_subimage(ImageInput *in, int subimage, ImageSpec* newspec);
 bool ImageInput_seek_subimage_miplevel(ImageInput *in, int subimage, int miplevel, ImageSpec* newspec);
 bool ImageInput_read_scanline_floats(ImageInput *in, int y, int z, float* data);
-// bool ImageInput_read_scanline_format(ImageInput *in, int y, int z, TypeDesc format, void* data, stride_t xstride);
+bool ImageInput_read_scanlines_format(ImageInput *in, int ybegin, int yend, int z, int chbegin, int chend,
+									FullTypeDesc format, void* data, stride_t xstride, stride_t ystride);
 bool ImageInput_read_tile_floats(ImageInput *in, int x, int y, int z, float* data);
-// bool ImageInput_read_tile_format(ImageInput *in, int x, int y, int z, TypeDesc format, void* data,
-// 									stride_t xstride, stride_t ystride, stride_t zstride);
+bool ImageInput_read_tiles_format(ImageInput *in, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend,
+								int chbegin, int chend, FullTypeDesc format, void* data,
+								stride_t xstride, stride_t ystride, stride_t zstride);
 bool ImageInput_read_image_floats(ImageInput *in, float* data);
-bool ImageInput_read_image_format(ImageInput *in, TypeDesc format, void* data, void* cbk_data);
-
-// bool ImageInput_read_native_scanline(ImageInput *in, int y, int z, void *data);
-// bool ImageInput_read_native_tile(ImageInput *in, int x, int y, int z, void *data);
-// bool ImageInput_read_native_tiles(ImageInput *in, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend, void *data);
-// bool ImageInput_read_native_deep_scanlines(ImageInput *in, int ybegin, int yend, int z, int chbegin, int chend, DeepData* deepdata);
-// bool ImageInput_read_native_deep_tiles(ImageInput *in, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend,
-// 											int chbegin, int chend, DeepData &deepdata);
-// bool ImageInput_read_native_deep_image(ImageInput *in, DeepData* deepdata);
+bool ImageInput_read_image_format(ImageInput *in, FullTypeDesc format, void* data, uintptr_t cbk_handle);
+
+bool ImageInput_read_native_scanline(ImageInput *in, int y, int z, void *data);
+bool ImageInput_read_native_tile(ImageInput *in, int x, int y, int z, void *data);
+bool ImageInput_read_native_tiles(ImageInput *in, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend, void *data);
+bool ImageInput_read_native_deep_scanlines(ImageInput *in, int ybegin, int yend, int z, int chbegin, int chend, DeepData* deepdata);
+bool ImageInput_read_native_deep_tiles(ImageInput *in, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend,
+											int chbegin, int chend, DeepData* deepdata);
+bool ImageInput_read_native_deep_image(ImageInput *in, DeepData* deepdata);
 // int ImageInput_send_to_input(ImageInput *in, const char *format,...);
 // int ImageInput_send_to_client(ImageInput *in, const char *format,...);
 
@@ -126,6 +191,25 @@ ImageOutput* ImageOutput_Create(const char* filename, const char* plugin_searchp
 const char* ImageOutput_format_name(ImageOutput *out);
 const ImageSpec* ImageOutput_spec(ImageOutput *out);
 bool ImageOutput_supports(ImageOutput *out, const char* feature);
+bool ImageOutput_open(ImageOutput *out, const char* name, const ImageSpec *spec, OpenMode mode);
+bool ImageOutput_open_multi(ImageOutput *out, const char* name, int subimages, const ImageSpec **specs);
+bool ImageOutput_open_ioproxy(ImageOutput *out, const char* name, const ImageSpec *spec, IOProxy *proxy);
+bool ImageOutput_close(ImageOutput *out);
+bool ImageOutput_write_scanline(ImageOutput *out, int y, int z, FullTypeDesc format, const void *data, stride_t xstride);
+bool ImageOutput_write_scanlines(ImageOutput *out, int ybegin, int yend, int z, FullTypeDesc format, const void *data,
+									stride_t xstride, stride_t ystride);
+bool ImageOutput_write_tile(ImageOutput *out, int x, int y, int z, FullTypeDesc format, const void *data,
+							stride_t xstride, stride_t ystride, stride_t zstride);
+bool ImageOutput_write_tiles(ImageOutput *out, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend,
+							FullTypeDesc format, const void *data, stride_t xstride, stride_t ystride, stride_t zstride);
+bool ImageOutput_write_rectangle(ImageOutput *out, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend,
+								FullTypeDesc format, const void *data, stride_t xstride, stride_t ystride, stride_t zstride);
+bool ImageOutput_write_image(ImageOutput *out, FullTypeDesc format, const void *data,
+							stride_t xstride, stride_t ystride, stride_t zstride, uintptr_t cbk_handle);
+bool ImageOutput_write_deep_scanlines(ImageOutput *out, int ybegin, int yend, int z, const DeepData *deepdata);
+bool ImageOutput_write_deep_tiles(ImageOutput *out, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend,
+									const DeepData *deepdata);
+bool ImageOutput_write_deep_image(ImageOutput *out, const DeepData *deepdata);
 
 const char* ImageOutput_geterror(ImageOutput *out);
 
@@ -134,10 +218,11 @@ const char* ImageOutput_geterror(ImageOutput *out);
 
 void deleteImageSpec(ImageSpec *spec);
 
-ImageSpec* ImageSpec_New(TypeDesc fmt);
-ImageSpec* ImageSpec_New_Size(int xres, int yres, int nchans, TypeDesc fmt);
+ImageSpec* ImageSpec_New(FullTypeDesc fmt);
+ImageSpec* ImageSpec_New_Size(int xres, int yres, int nchans, FullTypeDesc fmt);
+void ImageSpec_copy(ImageSpec *dst, const ImageSpec *src);
 
-void ImageSpec_set_format(ImageSpec *spec, TypeDesc fmt);
+void ImageSpec_set_format(ImageSpec *spec, FullTypeDesc fmt);
 void ImageSpec_default_channel_names(ImageSpec *spec);
 size_t ImageSpec_channel_bytes(ImageSpec *spec);
 size_t ImageSpec_channel_bytes_chan(ImageSpec *spec, int chan, bool native);
@@ -150,12 +235,12 @@ imagesize_t	ImageSpec_image_pixels(ImageSpec *spec);
 imagesize_t	ImageSpec_image_bytes(ImageSpec *spec, bool native);
 bool ImageSpec_size_safe(ImageSpec *spec);
 
-// std::string metadata_val(const ImageIOParameter &p, bool human=false);
-const char* ImageSpec_to_xml(ImageSpec *spec);
-// void from_xml(const char *xml)
+char* ImageSpec_metadata_val(ImageSpec *spec, const char* name, bool human);
+char* ImageSpec_to_xml(ImageSpec *spec);
+void ImageSpec_from_xml(ImageSpec *spec, const char *xml);
 // bool valid_tile_range(int xbegin, int xend, int ybegin, int yend, int zbegin, int zend)
 
-TypeDesc ImageSpec_channelformat(ImageSpec *spec, int chan);
+FullTypeDesc ImageSpec_channelformat(ImageSpec *spec, int chan);
 // void ImageSpec_get_channelformats(ImageSpec *spec, std::vector< TypeDesc > &formats);
 
 // Properties
@@ -191,10 +276,10 @@ int ImageSpec_tile_depth(ImageSpec *spec);
 void ImageSpec_set_tile_depth(ImageSpec *spec, int val);
 int ImageSpec_nchannels(ImageSpec *spec);
 void ImageSpec_set_nchannels(ImageSpec *spec, int val);
-TypeDesc ImageSpec_format(ImageSpec *spec);
-void ImageSpec_set_format(ImageSpec *spec, TypeDesc format);
-void ImageSpec_channelformats(ImageSpec *spec, TypeDesc *out);
-void ImageSpec_set_channelformats(ImageSpec *spec, TypeDesc *formats);
+FullTypeDesc ImageSpec_format(ImageSpec *spec);
+void ImageSpec_set_format(ImageSpec *spec, FullTypeDesc format);
+void ImageSpec_channelformats(ImageSpec *spec, FullTypeDesc *out);
+void ImageSpec_set_channelformats(ImageSpec *spec, FullTypeDesc *formats);
 void ImageSpec_channelnames(ImageSpec *spec, char** out);
 void ImageSpec_set_channelnames(ImageSpec *spec, char** names);
 int ImageSpec_alpha_channel(ImageSpec *spec);
@@ -204,8 +289,8 @@ void ImageSpec_set_z_channel(ImageSpec *spec, int val);
 bool ImageSpec_deep(ImageSpec *spec);
 void ImageSpec_set_deep(ImageSpec *spec, bool val);
 
-void ImageSpec_attribute_type_data(ImageSpec *spec, const char* name, TypeDesc type, const void *value);
-void ImageSpec_attribute_type_char(ImageSpec *spec, const char* name, TypeDesc type, const char* value);
+void ImageSpec_attribute_type_data(ImageSpec *spec, const char* name, FullTypeDesc type, const void *value);
+void ImageSpec_attribute_type_char(ImageSpec *spec, const char* name, FullTypeDesc type, const char* value);
 void ImageSpec_attribute_uint(ImageSpec *spec, const char* name, unsigned int value);
 void ImageSpec_attribute_int(ImageSpec *spec, const char* name, int value);
 void ImageSpec_attribute_float(ImageSpec *spec, const char* name, float value);
@@ -213,9 +298,19 @@ void ImageSpec_attribute_char(ImageSpec *spec, const char* name, const char* val
 int ImageSpec_get_int_attribute(ImageSpec *spec, const char* name, int defaultval);
 float ImageSpec_get_float_attribute(ImageSpec *spec, const char* name, float defaultval);
 const char* ImageSpec_get_string_attribute(ImageSpec *spec, const char* name, const char* defaultval);
-void ImageSpec_erase_attribute(ImageSpec *spec, const char* name, TypeDesc searchtype, bool caseSensitive);
-// ImageIOParameter * find_attribute(const char* name, TypeDesc searchtype=TypeDesc::UNKNOWN, bool casesensitive=false)
-// const ImageIOParameter * find_attribute(const char* name, TypeDesc searchtype=TypeDesc::UNKNOWN, bool casesensitive=false);
+void ImageSpec_erase_attribute(ImageSpec *spec, const char* name, FullTypeDesc searchtype, bool caseSensitive);
+int ImageSpec_num_attributes(ImageSpec *spec);
+int ImageSpec_find_attribute(ImageSpec *spec, const char* name);
+const char* ImageSpec_attribute_name(ImageSpec *spec, int index);
+FullTypeDesc ImageSpec_attribute_type(ImageSpec *spec, int index);
+const void* ImageSpec_attribute_data(ImageSpec *spec, int index);
+const char* ImageSpec_exif_iso_name();
+bool ImageSpec_decode_exif(ImageSpec *spec, const void *data, int length);
+void* ImageSpec_encode_exif(ImageSpec *spec, size_t *length);
+bool ImageSpec_decode_iptc(ImageSpec *spec, const void *data, int length);
+void* ImageSpec_encode_iptc(ImageSpec *spec, size_t *length);
+bool ImageSpec_decode_xmp(ImageSpec *spec, const char *xml);
+char* ImageSpec_encode_xmp(ImageSpec *spec, bool minimal);
 
 
 
@@ -236,11 +331,11 @@ void ImageBuf_reset_name_spec(ImageBuf* buf, const char* name, const ImageSpec*
 
 IBStorage ImageBuf_storage(ImageBuf* buf);
 bool ImageBuf_initialized(ImageBuf* buf);
-bool ImageBuf_read(ImageBuf* buf, int subimage, int miplevel, bool force, TypeDesc convert, void *cbk_data);
+bool ImageBuf_read(ImageBuf* buf, int subimage, int miplevel, bool force, FullTypeDesc convert, uintptr_t cbk_handle);
 bool ImageBuf_init_spec(ImageBuf* buf, const char* filename, int subimage, int miplevel);
-bool ImageBuf_write_file(ImageBuf* buf, const char* filename, const char* fileformat, void *cbk_data);
-bool ImageBuf_write_output(ImageBuf* buf, ImageOutput *out, void *cbk_data);
-void ImageBuf_set_write_format(ImageBuf* buf, TypeDesc format);
+bool ImageBuf_write_file(ImageBuf* buf, const char* filename, const char* fileformat, uintptr_t cbk_handle);
+bool ImageBuf_write_output(ImageBuf* buf, ImageOutput *out, uintptr_t cbk_handle);
+void ImageBuf_set_write_format(ImageBuf* buf, FullTypeDesc format);
 void ImageBuf_set_write_tiles(ImageBuf* buf, int width, int height, int depth);
 void ImageBuf_copy_metadata(ImageBuf* dst, const ImageBuf* src);
 bool ImageBuf_copy_pixels(ImageBuf* dst, const ImageBuf* src);
@@ -257,17 +352,18 @@ int ImageBuf_nsubimages(ImageBuf* buf);
 int ImageBuf_miplevel(ImageBuf* buf);
 int ImageBuf_nmiplevels(ImageBuf* buf);
 int ImageBuf_nchannels(ImageBuf* buf);
-// float ImageBuf_getchannel(ImageBuf* buf, int x, int y, int z, int c, WrapMode wrap);
+float ImageBuf_getchannel(ImageBuf* buf, int x, int y, int z, int c, WrapMode wrap);
 // void ImageBuf_getpixel(ImageBuf* buf, int x, int y, float *pixel, int maxchannels);
-// void ImageBuf_getpixel_xyz(ImageBuf* buf, int x, int y, int z, float *pixel, int maxchannels, WrapMode wrap);
-// void ImageBuf_interppixel(ImageBuf* buf, float x, float y, float *pixel, WrapMode wrap);
-// void ImageBuf_interppixel_NDC(ImageBuf* buf, float s, float t, float *pixel, WrapMode wrap);
-// void ImageBuf_interppixel_NDC_full(ImageBuf* buf, float s, float t, float *pixel, WrapMode wrap);
+void ImageBuf_getpixel_xyz(ImageBuf* buf, int x, int y, int z, float *pixel, int maxchannels, WrapMode wrap);
+void ImageBuf_interppixel(ImageBuf* buf, float x, float y, float *pixel, WrapMode wrap);
+void ImageBuf_interppixel_NDC(ImageBuf* buf, float s, float t, float *pixel, WrapMode wrap);
+void ImageBuf_interppixel_NDC_full(ImageBuf* buf, float s, float t, float *pixel, WrapMode wrap);
 // void ImageBuf_setpixel(ImageBuf* buf, int x, int y, const float *pixel, int maxchannels);
-// void ImageBuf_setpixel_xyz(ImageBuf* buf, int x, int y, int z, const float *pixel, int maxchannels);
-// void ImageBuf_setpixel_index(ImageBuf* buf, int i, const float *pixel, int maxchannels);
-bool ImageBuf_get_pixel_channels(ImageBuf* buf, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend, int chbegin, int chend, TypeDesc format, void *result);
+void ImageBuf_setpixel_xyz(ImageBuf* buf, int x, int y, int z, const float *pixel, int maxchannels);
+void ImageBuf_setpixel_index(ImageBuf* buf, int i, const float *pixel, int maxchannels);
+bool ImageBuf_get_pixel_channels(ImageBuf* buf, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend, int chbegin, int chend, FullTypeDesc format, void *result);
 // bool ImageBuf_get_pixels(ImageBuf* buf, int xbegin, int xend, int ybegin, int yend, int zbegin, int zend, TypeDesc format, void *result);
+bool ImageBuf_set_pixels(ImageBuf* buf, ROI* roi, FullTypeDesc format, const void *data);
 
 int ImageBuf_orientation(ImageBuf* buf);
 int ImageBuf_oriented_width(ImageBuf* buf);
@@ -300,18 +396,55 @@ ROI* ImageBuf_roi_full(ImageBuf* buf);
 void ImageBuf_set_roi_full(ImageBuf* buf, ROI* newroi);
 
 bool ImageBuf_pixels_valid(ImageBuf* buf);
-TypeDesc ImageBuf_pixeltype(ImageBuf* buf);
-// void* ImageBuf_localpixels(ImageBuf* buf);
+FullTypeDesc ImageBuf_pixeltype(ImageBuf* buf);
+void* ImageBuf_localpixels(ImageBuf* buf);
 // const void* ImageBuf_localpixels(ImageBuf* buf);
 bool ImageBuf_cachedpixels(ImageBuf* buf);
 ImageCache* ImageBuf_imagecache(ImageBuf* buf);
 // void* ImageBuf_pixeladdr(ImageBuf* buf, int x, int y);
 // void* ImageBuf_pixeladdr_z(ImageBuf* buf, int x, int y, int z);
 bool ImageBuf_deep(ImageBuf* buf);
-// int ImageBuf_deep_samples(ImageBuf* buf, int x, int y, int z);
+int ImageBuf_deep_samples(ImageBuf* buf, int x, int y, int z);
+void ImageBuf_set_deep_samples(ImageBuf* buf, int x, int y, int z, int nsamples);
+void ImageBuf_deep_insert_samples(ImageBuf* buf, int x, int y, int z, int samplepos, int nsamples);
+void ImageBuf_deep_erase_samples(ImageBuf* buf, int x, int y, int z, int samplepos, int nsamples);
 // const void* ImageBuf_deep_pixel_ptr(ImageBuf* buf, int x, int y, int z, int c);
-// float ImageBuf_deep_value(ImageBuf* buf, int x, int y, int z, int c, int s);
-// DeepData* ImageBuf_deepdata(ImageBuf* buf);
+float ImageBuf_deep_value(ImageBuf* buf, int x, int y, int z, int c, int s);
+uint32_t ImageBuf_deep_value_uint(ImageBuf* buf, int x, int y, int z, int c, int s);
+void ImageBuf_set_deep_value(ImageBuf* buf, int x, int y, int z, int c, int s, float value);
+void ImageBuf_set_deep_value_uint(ImageBuf* buf, int x, int y, int z, int c, int s, uint32_t value);
+DeepData* ImageBuf_deepdata(ImageBuf* buf);
+
+// DeepData
+//
+bool DeepData_supported();
+
+void deleteDeepData(DeepData *dd);
+
+DeepData* DeepData_New();
+
+void DeepData_init(DeepData *dd, int64_t npix, int nchans, const FullTypeDesc *channeltypes, char **channelnames);
+void DeepData_init_spec(DeepData *dd, const ImageSpec *spec);
+void DeepData_clear(DeepData *dd);
+void DeepData_free(DeepData *dd);
+
+int64_t DeepData_pixels(DeepData *dd);
+int DeepData_channels(DeepData *dd);
+FullTypeDesc DeepData_channeltype(DeepData *dd, int c);
+size_t DeepData_channelsize(DeepData *dd, int c);
+size_t DeepData_samplesize(DeepData *dd);
+
+int DeepData_samples(DeepData *dd, int64_t pixel);
+void DeepData_set_samples(DeepData *dd, int64_t pixel, int samps);
+void DeepData_all_samples(DeepData *dd, uint32_t *out);
+void DeepData_set_all_samples(DeepData *dd, const uint32_t *samples, int64_t npix);
+void DeepData_insert_samples(DeepData *dd, int64_t pixel, int samplepos, int n);
+void DeepData_erase_samples(DeepData *dd, int64_t pixel, int samplepos, int n);
+
+float DeepData_deep_value(DeepData *dd, int64_t pixel, int channel, int sample);
+uint32_t DeepData_deep_value_uint(DeepData *dd, int64_t pixel, int channel, int sample);
+void DeepData_set_deep_value(DeepData *dd, int64_t pixel, int channel, int sample, float value);
+void DeepData_set_deep_value_uint(DeepData *dd, int64_t pixel, int channel, int sample, uint32_t value);
 
 // ROI
 //
@@ -354,52 +487,28 @@ void ImageCache_Destroy(ImageCache *x, bool teardown);
 
 void ImageCache_clear(ImageCache *x);
 
-// bool ImageCache_attribute(ImageCache *x, const char *name, TypeDesc type, const void *val);
-
-// bool ImageCache_attribute_int(ImageCache *x, const char *name, int val);
-// bool ImageCache_attribute_float(ImageCache *x, const char *name, float val);
-// bool ImageCache_attribute_double(ImageCache *x, const char *name, double val);
-// bool ImageCache_attribute_char(ImageCache *x, const char *name, const char **val);
-
-// bool ImageCache_getattribute(ImageCache *x, const char *name, TypeDesc type, void *val);
-// bool ImageCache_getattribute_int(ImageCache *x, const char *name, int *val);
-// bool ImageCache_getattribute_float(ImageCache *x, const char *name, float *val);
-// bool ImageCache_getattribute_double(ImageCache *x, const char *name, double *val);
-// bool ImageCache_getattribute_char(ImageCache *x, const char *name, char **val);
+bool ImageCache_attribute(ImageCache *x, const char *name, FullTypeDesc type, const void *val);
+bool ImageCache_getattribute(ImageCache *x, const char *name, FullTypeDesc type, void *val);
+bool ImageCache_getattribute_strings(ImageCache *x, const char *name, FullTypeDesc type, const char **out);
 
 // char* ImageCache_resolve_filename(ImageCache *x, const char *filename);
 
-// bool ImageCache_get_image_info(ImageCache *x, char *filename, int subimage, int miplevel,
-//                      			char *dataname, TypeDesc datatype, void *data);
-
-// bool ImageCache_get_imagespec(ImageCache *x, char *filename, ImageSpec &spec,
-//                             int subimage=0, int miplevel=0,
-//                             bool native=false);
-
-// const ImageSpec* ImageCache_imagespec(ImageCache *x, char *filename, int subimage, int miplevel, bool native);
-
-// bool get_pixels(ImageCache *x, char *filename, int subimage, int miplevel,
-//                          int xbegin, int xend, int ybegin, int yend,
-//                          int zbegin, int zend,
-//                          TypeDesc format, void *result);
-
-// bool get_pixels(ImageCache *x, char *filename,
-//                 int subimage, int miplevel, int xbegin, int xend,
-//                 int ybegin, int yend, int zbegin, int zend,
-//                 int chbegin, int chend, TypeDesc format, void *result,
-//                 stride_t xstride=AutoStride, stride_t ystride=AutoStride,
-//                 stride_t zstride=AutoStride);
-
-// Tile* ImageCache_get_tile(ImageCache *x, char *filename, int subimage, int miplevel,
-//                             int x, int y, int z);
-
-// void ImageCache_release_tile(ImageCache *x, Tile *tile);
-// const void* ImageCache_tile_pixels(ImageCache *x, Tile *tile, TypeDesc *format);
-// bool ImageCache_add_file(ImageCache *x, char *filename, ImageInput::Creator creator);
-// bool ImageCache_add_tile(ImageCache *x, char *filename, int subimage, int miplevel,
-// 		                 int x, int y, int z, TypeDesc format, const void *buffer,
-// 		                 stride_t xstride=AutoStride, stride_t ystride=AutoStride,
-// 		                 stride_t zstride=AutoStride);
+bool ImageCache_get_image_info(ImageCache *x, const char *filename, int subimage, int miplevel,
+								const char *dataname, FullTypeDesc datatype, void *data);
+bool ImageCache_get_imagespec(ImageCache *x, const char *filename, ImageSpec *spec,
+								int subimage, int miplevel, bool native);
+bool ImageCache_get_pixels(ImageCache *x, const char *filename, int subimage, int miplevel,
+							int xbegin, int xend, int ybegin, int yend, int zbegin, int zend,
+							int chbegin, int chend, FullTypeDesc format, void *result);
+
+Tile* ImageCache_get_tile(ImageCache *x, const char *filename, int subimage, int miplevel,
+							int xx, int y, int z);
+void ImageCache_release_tile(ImageCache *x, Tile *tile);
+const void* ImageCache_tile_pixels(ImageCache *x, Tile *tile, FullTypeDesc *format);
+// Adds a synthetic image, described by config, that has no file on disk
+bool ImageCache_add_file(ImageCache *x, const char *filename, ImageSpec *config);
+bool ImageCache_add_tile(ImageCache *x, const char *filename, int subimage, int miplevel,
+							int xx, int y, int z, FullTypeDesc format, const void *buffer);
 const char* ImageCache_geterror(ImageCache *x);
 const char* ImageCache_getstats(ImageCache *x, int level);
 void ImageCache_reset_stats(ImageCache *x);
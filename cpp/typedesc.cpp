@@ -0,0 +1,87 @@
+#include <OpenImageIO/typedesc.h>
+
+#include <string>
+
+#include "oiio.h"
+
+
+// Translate between the values of the C TypeDesc enum, and
+// OIIO::TypeDesc::BASETYPE
+OIIO::TypeDesc::BASETYPE fromBaseType(TypeDesc base) {
+	switch (base) {
+	case TYPE_UINT8: 	return OIIO::TypeDesc::UINT8;
+	case TYPE_INT8: 	return OIIO::TypeDesc::INT8;
+	case TYPE_UINT16: 	return OIIO::TypeDesc::UINT16;
+	case TYPE_INT16: 	return OIIO::TypeDesc::INT16;
+	case TYPE_UINT: 	return OIIO::TypeDesc::UINT;
+	case TYPE_INT: 		return OIIO::TypeDesc::INT;
+	case TYPE_UINT64: 	return OIIO::TypeDesc::UINT64;
+	case TYPE_INT64: 	return OIIO::TypeDesc::INT64;
+	case TYPE_HALF: 	return OIIO::TypeDesc::HALF;
+	case TYPE_FLOAT: 	return OIIO::TypeDesc::FLOAT;
+	case TYPE_DOUBLE: 	return OIIO::TypeDesc::DOUBLE;
+	case TYPE_STRING: 	return OIIO::TypeDesc::STRING;
+	case TYPE_NONE: 	return OIIO::TypeDesc::NONE;
+	case TYPE_PTR: 		return OIIO::TypeDesc::PTR;
+	case TYPE_UNKNOWN: 	return OIIO::TypeDesc::UNKNOWN;
+	}
+	return OIIO::TypeDesc::UNKNOWN;
+}
+
+TypeDesc toBaseType(unsigned char base) {
+	switch (base) {
+	case OIIO::TypeDesc::UINT8: 	return TYPE_UINT8;
+	case OIIO::TypeDesc::INT8: 		return TYPE_INT8;
+	case OIIO::TypeDesc::UINT16: 	return TYPE_UINT16;
+	case OIIO::TypeDesc::INT16: 	return TYPE_INT16;
+	case OIIO::TypeDesc::UINT: 		return TYPE_UINT;
+	case OIIO::TypeDesc::INT: 		return TYPE_INT;
+	case OIIO::TypeDesc::UINT64: 	return TYPE_UINT64;
+	case OIIO::TypeDesc::INT64: 	return TYPE_INT64;
+	case OIIO::TypeDesc::HALF: 		return TYPE_HALF;
+	case OIIO::TypeDesc::FLOAT: 	return TYPE_FLOAT;
+	case OIIO::TypeDesc::DOUBLE: 	return TYPE_DOUBLE;
+	case OIIO::TypeDesc::STRING: 	return TYPE_STRING;
+	case OIIO::TypeDesc::NONE: 		return TYPE_NONE;
+	case OIIO::TypeDesc::PTR: 		return TYPE_PTR;
+	}
+	return TYPE_UNKNOWN;
+}
+
+OIIO::TypeDesc fromTypeDesc(FullTypeDesc fmt) {
+	return OIIO::TypeDesc(fromBaseType(fmt.basetype),
+						  (OIIO::TypeDesc::AGGREGATE) fmt.aggregate,
+						  (OIIO::TypeDesc::VECSEMANTICS) fmt.vecsemantics,
+						  fmt.arraylen);
+}
+
+FullTypeDesc toTypeDesc(OIIO::TypeDesc fmt) {
+	FullTypeDesc out;
+	out.basetype = toBaseType(fmt.basetype);
+	out.aggregate = fmt.aggregate;
+	out.vecsemantics = fmt.vecsemantics;
+	out.arraylen = fmt.arraylen;
+	return out;
+}
+
+extern "C" {
+
+const char* TypeDesc_c_str(FullTypeDesc type) {
+	return fromTypeDesc(type).c_str();
+}
+
+bool TypeDesc_fromstring(const char* typestring, FullTypeDesc *out) {
+	std::string s_type(typestring);
+	OIIO::TypeDesc t;
+	if (t.fromstring(s_type) != s_type.size()) {
+		return false;
+	}
+	*out = toTypeDesc(t);
+	return true;
+}
+
+size_t TypeDesc_size(FullTypeDesc type) {
+	return fromTypeDesc(type).size();
+}
+
+} // extern "C"
@@ -0,0 +1,214 @@
+package oiio
+
+/*
+#include "stdlib.h"
+
+#include "cpp/oiio.h"
+
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+// DeepData holds the contents of an image of "deep" pixels, in which
+// each pixel may hold any number of samples, and each channel may have
+// its own data type. Pixels are addressed by their index within the
+// image or region that the DeepData was read from.
+//
+// Deep data requires OpenImageIO 1.7 or newer. When built against an older
+// release, the functions that create or read a DeepData return an error.
+type DeepData struct {
+	ptr unsafe.Pointer
+	// The ImageBuf that owns the memory of a DeepData, if any
+	owner *ImageBuf
+}
+
+func newDeepData(i unsafe.Pointer) *DeepData {
+	dd := &DeepData{ptr: i}
+	runtime.SetFinalizer(dd, deleteDeepData)
+	return dd
+}
+
+// Return an error if the linked OpenImageIO does not support deep data.
+func checkDeepData() error {
+	if !bool(C.DeepData_supported()) {
+		return errors.New("Deep data requires OpenImageIO 1.7 or newer")
+	}
+	return nil
+}
+
+func deleteDeepData(i *DeepData) {
+	if i.ptr != nil {
+		C.deleteDeepData(i.ptr)
+		i.ptr = nil
+	}
+}
+
+// Create a DeepData for npixels pixels, with one channel for each of the
+// given channel types and names. All pixels start out with 0 samples.
+// Channel names such as "A" and "Z" are used to identify the alpha and
+// depth channels by the deep algorithms.
+func NewDeepData(npixels int, channelTypes []TypeDesc, channelNames []string) (*DeepData, error) {
+	if err := checkDeepData(); err != nil {
+		return nil, err
+	}
+
+	nchans := len(channelTypes)
+	if nchans == 0 {
+		return nil, errors.New("DeepData requires at least 1 channel")
+	}
+	if len(channelNames) != nchans {
+		return nil, fmt.Errorf("Number of channel names %d does not match number of channel types %d",
+			len(channelNames), nchans)
+	}
+
+	c_types := make([]C.FullTypeDesc, nchans)
+	for i, t := range channelTypes {
+		c_types[i] = t.c()
+	}
+
+	c_names := C.makeCharArray(C.int(nchans))
+	defer C.freeCharArray(c_names, C.int(nchans))
+	for i, n := range channelNames {
+		C.setArrayString(c_names, C.CString(n), C.int(i))
+	}
+
+	dd := newDeepData(C.DeepData_New())
+	C.DeepData_init(dd.ptr, C.int64_t(npixels), C.int(nchans), &c_types[0], c_names)
+	return dd, nil
+}
+
+// Create a DeepData that is sized to hold all of the pixels and channels
+// (with their per-channel formats) described by a deep ImageSpec.
+func NewDeepDataSpec(spec *ImageSpec) (*DeepData, error) {
+	if err := checkDeepData(); err != nil {
+		return nil, err
+	}
+
+	dd := newDeepData(C.DeepData_New())
+	C.DeepData_init_spec(dd.ptr, spec.ptr)
+	return dd, nil
+}
+
+// Reset the DeepData to be equivalent to its empty initial state.
+func (d *DeepData) Clear() {
+	C.DeepData_clear(d.ptr)
+}
+
+// Release all of the memory held by the DeepData, in addition to
+// resetting it as Clear() does.
+func (d *DeepData) Free() {
+	C.DeepData_free(d.ptr)
+}
+
+// Return the total number of pixels.
+func (d *DeepData) NumPixels() int {
+	return int(C.DeepData_pixels(d.ptr))
+}
+
+// Return the number of channels.
+func (d *DeepData) NumChannels() int {
+	return int(C.DeepData_channels(d.ptr))
+}
+
+// Return the data type of the given channel.
+func (d *DeepData) ChannelType(channel int) TypeDesc {
+	return newTypeDesc(C.DeepData_channeltype(d.ptr, C.int(channel)))
+}
+
+// Return the size in bytes of one sample of the given channel.
+func (d *DeepData) ChannelSize(channel int) int {
+	return int(C.DeepData_channelsize(d.ptr, C.int(channel)))
+}
+
+// Return the size in bytes of one sample of all channels.
+func (d *DeepData) SampleSize() int {
+	return int(C.DeepData_samplesize(d.ptr))
+}
+
+// Return the number of samples for the given pixel index.
+func (d *DeepData) Samples(pixel int) int {
+	return int(C.DeepData_samples(d.ptr, C.int64_t(pixel)))
+}
+
+// Set the number of samples for the given pixel index. Existing sample
+// values are preserved, up to the new number of samples.
+func (d *DeepData) SetSamples(pixel, samples int) {
+	C.DeepData_set_samples(d.ptr, C.int64_t(pixel), C.int(samples))
+}
+
+// Return the number of samples of every pixel.
+func (d *DeepData) AllSamples() []uint32 {
+	npix := d.NumPixels()
+	if npix <= 0 {
+		return []uint32{}
+	}
+	samples := make([]uint32, npix)
+	C.DeepData_all_samples(d.ptr, (*C.uint32_t)(unsafe.Pointer(&samples[0])))
+	return samples
+}
+
+// Set the number of samples of every pixel at once. The samples
+// slice must have one entry for each pixel.
+func (d *DeepData) SetAllSamples(samples []uint32) error {
+	npix := d.NumPixels()
+	if len(samples) != npix {
+		return fmt.Errorf("Expected %d sample counts; got %d", npix, len(samples))
+	}
+	if npix == 0 {
+		return nil
+	}
+	C.DeepData_set_all_samples(d.ptr, (*C.uint32_t)(unsafe.Pointer(&samples[0])), C.int64_t(npix))
+	return nil
+}
+
+// Insert n new samples into the given pixel, starting at sample position
+// samplePos. The values of the new samples are uninitialized.
+func (d *DeepData) InsertSamples(pixel, samplePos, n int) {
+	C.DeepData_insert_samples(d.ptr, C.int64_t(pixel), C.int(samplePos), C.int(n))
+}
+
+// Erase n samples from the given pixel, starting at sample position samplePos.
+func (d *DeepData) EraseSamples(pixel, samplePos, n int) {
+	C.DeepData_erase_samples(d.ptr, C.int64_t(pixel), C.int(samplePos), C.int(n))
+}
+
+// Return the value of a sample of a pixel channel, converted to float32.
+// Returns 0 if the pixel, channel, or sample are out of range.
+func (d *DeepData) Value(pixel, channel, sample int) float32 {
+	return float32(C.DeepData_deep_value(d.ptr, C.int64_t(pixel), C.int(channel), C.int(sample)))
+}
+
+// Return the value of a sample of a pixel channel, converted to uint32.
+// This is intended for channels of integer types, such as object ids.
+// Returns 0 if the pixel, channel, or sample are out of range.
+func (d *DeepData) ValueUint(pixel, channel, sample int) uint32 {
+	return uint32(C.DeepData_deep_value_uint(d.ptr, C.int64_t(pixel), C.int(channel), C.int(sample)))
+}
+
+// Set the value of a sample of a pixel channel, converting from float32
+// to the data type of the channel.
+func (d *DeepData) SetValue(pixel, channel, sample int, value float32) {
+	C.DeepData_set_deep_value(d.ptr, C.int64_t(pixel), C.int(channel), C.int(sample), C.float(value))
+}
+
+// Set the value of a sample of a pixel channel, converting from uint32
+// to the data type of the channel.
+func (d *DeepData) SetValueUint(pixel, channel, sample int, value uint32) {
+	C.DeepData_set_deep_value_uint(d.ptr, C.int64_t(pixel), C.int(channel), C.int(sample), C.uint32_t(value))
+}
+
+// Return the float32 values of all samples of a pixel channel.
+func (d *DeepData) ChannelValues(pixel, channel int) []float32 {
+	nsamples := d.Samples(pixel)
+	values := make([]float32, nsamples)
+	for s := 0; s < nsamples; s++ {
+		values[s] = d.Value(pixel, channel, s)
+	}
+	return values
+}
@@ -0,0 +1,196 @@
+package oiio
+
+import (
+	"os"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func newDeepTestSpec() *ImageSpec {
+	spec := NewImageSpecSize(4, 2, 5, TypeFloat())
+	spec.SetChannelNames([]string{"R", "G", "B", "A", "Z"})
+	spec.SetAlphaChannel(3)
+	spec.SetZChannel(4)
+	spec.SetDeep(true)
+	return spec
+}
+
+func TestNewDeepData(t *testing.T) {
+	if _, err := NewDeepData(4, nil, nil); err == nil {
+		t.Error("Expected an error when creating DeepData with no channels")
+	}
+
+	types := []TypeDesc{TypeFloat(), TypeUint()}
+	if _, err := NewDeepData(4, types, []string{"Z"}); err == nil {
+		t.Error("Expected an error when channel names do not match channel types")
+	}
+
+	dd, err := NewDeepData(4, types, []string{"Z", "id"})
+	checkFatalError(t, err)
+
+	if dd.NumPixels() != 4 {
+		t.Errorf("Expected 4 pixels; got %d", dd.NumPixels())
+	}
+	if dd.NumChannels() != 2 {
+		t.Errorf("Expected 2 channels; got %d", dd.NumChannels())
+	}
+	if dd.ChannelType(1) != TypeUint() {
+		t.Errorf("Expected channel 1 to be TypeUint(); got %v", dd.ChannelType(1))
+	}
+	if dd.SampleSize() != dd.ChannelSize(0)+dd.ChannelSize(1) {
+		t.Errorf("Expected sample size %d; got %d", dd.ChannelSize(0)+dd.ChannelSize(1), dd.SampleSize())
+	}
+
+	checkFatalError(t, dd.SetAllSamples([]uint32{1, 0, 2, 3}))
+	if err = dd.SetAllSamples([]uint32{1}); err == nil {
+		t.Error("Expected an error when setting too few sample counts")
+	}
+
+	dd.SetValue(2, 0, 1, 10.5)
+	dd.SetValueUint(2, 1, 1, 42)
+	if dd.Value(2, 0, 1) != 10.5 {
+		t.Errorf("Expected value 10.5; got %v", dd.Value(2, 0, 1))
+	}
+	if dd.ValueUint(2, 1, 1) != 42 {
+		t.Errorf("Expected id 42; got %v", dd.ValueUint(2, 1, 1))
+	}
+
+	// Inserting before the sample shifts it along
+	dd.InsertSamples(2, 0, 2)
+	if dd.Samples(2) != 4 {
+		t.Fatalf("Expected 4 samples after insert; got %d", dd.Samples(2))
+	}
+	if dd.Value(2, 0, 3) != 10.5 {
+		t.Errorf("Expected inserted samples to shift value 10.5 to sample 3; got %v", dd.ChannelValues(2, 0))
+	}
+
+	dd.EraseSamples(2, 0, 3)
+	if dd.Samples(2) != 1 {
+		t.Fatalf("Expected 1 sample after erase; got %d", dd.Samples(2))
+	}
+	if dd.Value(2, 0, 0) != 10.5 {
+		t.Errorf("Expected value 10.5 to remain after erase; got %v", dd.ChannelValues(2, 0))
+	}
+
+	dd.Clear()
+	if dd.NumPixels() != 0 {
+		t.Errorf("Expected 0 pixels after Clear; got %d", dd.NumPixels())
+	}
+}
+
+func TestDeepDataReadWrite(t *testing.T) {
+	spec := newDeepTestSpec()
+
+	dd, err := NewDeepDataSpec(spec)
+	checkFatalError(t, err)
+	npix := dd.NumPixels()
+	if npix != 8 {
+		t.Fatalf("Expected 8 pixels; got %d", npix)
+	}
+	if dd.NumChannels() != 5 {
+		t.Fatalf("Expected 5 channels; got %d", dd.NumChannels())
+	}
+
+	for p := 0; p < npix; p++ {
+		dd.SetSamples(p, p%3)
+		for s := 0; s < dd.Samples(p); s++ {
+			for c := 0; c < 4; c++ {
+				dd.SetValue(p, c, s, 0.5)
+			}
+			dd.SetValue(p, 4, s, float32(p+s))
+		}
+	}
+
+	outfile := createOutputFileExt("exr")
+	defer os.Remove(outfile)
+
+	out, err := OpenImageOutput(outfile)
+	checkFatalError(t, err)
+
+	if !out.Supports("deepdata") {
+		t.Fatalf("Expected format %q to support deepdata", out.FormatName())
+	}
+
+	checkFatalError(t, out.Open(outfile, spec, OpenModeCreate))
+	checkFatalError(t, out.WriteDeepImage(dd))
+	checkFatalError(t, out.Close())
+
+	// ImageInput
+	//
+	in, err := OpenImageInput(outfile)
+	checkFatalError(t, err)
+
+	if !in.Spec().Deep() {
+		t.Fatal("Expected ImageInput spec to be deep")
+	}
+
+	actual, err := in.ReadNativeDeepImage()
+	checkFatalError(t, err)
+
+	if !reflect.DeepEqual(dd.AllSamples(), actual.AllSamples()) {
+		t.Fatalf("Expected samples %v; got %v", dd.AllSamples(), actual.AllSamples())
+	}
+
+	for p := 0; p < npix; p++ {
+		if !reflect.DeepEqual(dd.ChannelValues(p, 4), actual.ChannelValues(p, 4)) {
+			t.Errorf("Pixel %d: Expected Z values %v; got %v",
+				p, dd.ChannelValues(p, 4), actual.ChannelValues(p, 4))
+		}
+	}
+
+	scanline, err := in.ReadNativeDeepScanlines(1, 2, 0, 4, 5)
+	checkFatalError(t, err)
+
+	if scanline.NumPixels() != 4 || scanline.NumChannels() != 1 {
+		t.Fatalf("Expected 4 pixels and 1 channel; got %d, %d", scanline.NumPixels(), scanline.NumChannels())
+	}
+	if scanline.Samples(1) != dd.Samples(5) {
+		t.Errorf("Expected %d samples; got %d", dd.Samples(5), scanline.Samples(1))
+	}
+
+	// ImageBuf
+	//
+	buf, err := NewImageBufPath(outfile)
+	checkFatalError(t, err)
+	checkFatalError(t, buf.Read(true))
+
+	if !buf.Deep() {
+		t.Fatal("Expected ImageBuf to be deep")
+	}
+
+	if n := buf.DeepSamples(1, 1, 0); n != dd.Samples(5) {
+		t.Errorf("Expected %d samples at (1,1); got %d", dd.Samples(5), n)
+	}
+	if v := buf.DeepValue(1, 1, 0, 4, 1); v != 6 {
+		t.Errorf("Expected Z value 6 at (1,1) sample 1; got %v", v)
+	}
+
+	buf.SetDeepValue(1, 1, 0, 4, 1, 20)
+	if v := buf.DeepValue(1, 1, 0, 4, 1); v != 20 {
+		t.Errorf("Expected Z value 20 at (1,1) sample 1; got %v", v)
+	}
+
+	buf.DeepInsertSamples(1, 1, 0, 0, 1)
+	buf.DeepEraseSamples(1, 1, 0, 1, 1)
+	buf.SetDeepSamples(0, 0, 0, 3)
+	if n := buf.DeepSamples(0, 0, 0); n != 3 {
+		t.Errorf("Expected 3 samples at (0,0); got %d", n)
+	}
+
+	bufdd := buf.DeepData()
+	if bufdd == nil {
+		t.Fatal("Expected ImageBuf.DeepData() to be non-nil")
+	}
+	if bufdd.NumPixels() != npix {
+		t.Errorf("Expected %d pixels; got %d", npix, bufdd.NumPixels())
+	}
+
+	// The DeepData keeps the ImageBuf that owns it alive
+	buf = nil
+	runtime.GC()
+	runtime.GC()
+	if n := bufdd.Samples(0); n != 3 {
+		t.Errorf("Expected 3 samples at (0,0); got %d", n)
+	}
+}
@@ -0,0 +1,406 @@
+package oiio
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// ToImage copies the pixels of the data window of the ImageBuf (the first
+// z slice, for volume images) into a new image.Image from the standard
+// library. The concrete type of the result depends on the channels:
+//
+//     1 channel                          => *image.Gray16
+//     unassociated alpha, 8-bit pixels   => *image.NRGBA
+//     unassociated alpha, deeper pixels  => *image.NRGBA64
+//     anything else                      => *image.RGBA64
+//
+// ImageBuf pixels are normally associated (premultiplied) by alpha, which
+// matches image.RGBA64. The non-premultiplied types are only used when the
+// "oiio:UnassociatedAlpha" attribute of the spec is set. Values outside of
+// [0,1] are clamped. Images with 2 channels are treated as gray and alpha.
+func (i *ImageBuf) ToImage() (image.Image, error) {
+	if i.Deep() {
+		return nil, errors.New("Cannot convert a deep ImageBuf to an image.Image")
+	}
+
+	spec := i.Spec()
+	nchans := spec.NumChannels()
+	if nchans < 1 {
+		return nil, errors.New("ImageBuf has no channels")
+	}
+
+	roi := i.ROI()
+	region := NewROIRegion3D(roi.XBegin(), roi.XEnd(), roi.YBegin(), roi.YEnd(),
+		roi.ZBegin(), roi.ZBegin()+1, 0, nchans)
+
+	format := TypeUint16()
+	if spec.ColorModel() == color.NRGBAModel {
+		format = TypeUint8()
+	}
+	pixels, err := i.GetPixelRegion(region, format)
+	if err != nil {
+		return nil, err
+	}
+	return NewImageFromPixels(spec, pixels)
+}
+
+// NewImageFromPixels creates an image.Image, as described by ToImage, from
+// the pixels of the data window of an image with the given spec, such as
+// read by ImageInput.ReadImageFormat. The pixels must be a []uint8 if the
+// ColorModel() of the spec is color.NRGBAModel, and a []uint16 otherwise,
+// holding at least all of the channels of the first z slice of the image.
+// The pixels are copied once, into the new image.
+func NewImageFromPixels(spec *ImageSpec, pixels interface{}) (image.Image, error) {
+	nchans := spec.NumChannels()
+	if nchans < 1 {
+		return nil, errors.New("ImageSpec has no channels")
+	}
+
+	rect := image.Rect(spec.X(), spec.Y(), spec.X()+spec.Width(), spec.Y()+spec.Height())
+	npix := rect.Dx() * rect.Dy()
+
+	rc, gc, bc, ac := imageChannelMap(nchans, spec.AlphaChannel())
+	model := spec.ColorModel()
+
+	if model == color.NRGBAModel {
+		u8, ok := pixels.([]uint8)
+		if !ok {
+			return nil, fmt.Errorf("Expected []uint8 pixels; got %T", pixels)
+		}
+		if err := checkPixelBufferSize(len(u8), npix*nchans); err != nil {
+			return nil, err
+		}
+
+		img := image.NewNRGBA(rect)
+		for p := 0; p < npix; p++ {
+			src, dst := u8[p*nchans:], img.Pix[p*4:]
+			dst[0], dst[1], dst[2], dst[3] = src[rc], src[gc], src[bc], src[ac]
+		}
+		return img, nil
+	}
+
+	u16, ok := pixels.([]uint16)
+	if !ok {
+		return nil, fmt.Errorf("Expected []uint16 pixels; got %T", pixels)
+	}
+	if err := checkPixelBufferSize(len(u16), npix*nchans); err != nil {
+		return nil, err
+	}
+
+	if model == color.Gray16Model {
+		img := image.NewGray16(rect)
+		for p, v := range u16[:npix] {
+			putUint16(img.Pix[p*2:], v)
+		}
+		return img, nil
+	}
+
+	if model == color.NRGBA64Model {
+		img := image.NewNRGBA64(rect)
+		for p := 0; p < npix; p++ {
+			src, dst := u16[p*nchans:], img.Pix[p*8:]
+			putUint16(dst[0:], src[rc])
+			putUint16(dst[2:], src[gc])
+			putUint16(dst[4:], src[bc])
+			putUint16(dst[6:], src[ac])
+		}
+		return img, nil
+	}
+
+	img := image.NewRGBA64(rect)
+	for p := 0; p < npix; p++ {
+		src, dst := u16[p*nchans:], img.Pix[p*8:]
+		a := uint16(0xffff)
+		if ac >= 0 {
+			a = src[ac]
+		}
+		// Premultiplied color values can never be greater than alpha
+		putUint16(dst[0:], minUint16(src[rc], a))
+		putUint16(dst[2:], minUint16(src[gc], a))
+		putUint16(dst[4:], minUint16(src[bc], a))
+		putUint16(dst[6:], a)
+	}
+	return img, nil
+}
+
+// ColorModel returns the color.Model of the image.Image that ToImage
+// produces for an ImageBuf with this spec.
+func (s *ImageSpec) ColorModel() color.Model {
+	nchans := s.NumChannels()
+	_, _, _, a := imageChannelMap(nchans, s.AlphaChannel())
+
+	switch {
+	case nchans == 1:
+		return color.Gray16Model
+	case a >= 0 && s.AttributeInt("oiio:UnassociatedAlpha") != 0:
+		if s.Format() == TypeUint8() {
+			return color.NRGBAModel
+		}
+		return color.NRGBA64Model
+	default:
+		return color.RGBA64Model
+	}
+}
+
+// NewImageBufFromImage creates an ImageBuf holding a copy of the pixels of
+// an image.Image from the standard library. The data window of the ImageBuf
+// matches the bounds of the image.
+//
+// *image.Gray and *image.Gray16 images produce a single channel ImageBuf of
+// 8-bit and 16-bit pixels. *image.RGBA images produce 8-bit RGBA pixels.
+// All other images are converted to 16-bit pixels, with associated
+// (premultiplied) alpha, and have no alpha channel if the image is opaque.
+func NewImageBufFromImage(img image.Image) (*ImageBuf, error) {
+	b := img.Bounds()
+	if b.Empty() {
+		return nil, errors.New("Image is empty")
+	}
+	w, h := b.Dx(), b.Dy()
+
+	var (
+		spec   *ImageSpec
+		format TypeDesc
+		pixels interface{}
+	)
+
+	switch src := img.(type) {
+
+	case *image.Gray:
+		spec, format = NewImageSpecSize(w, h, 1, TypeUint8()), TypeUint8()
+		data := make([]uint8, 0, w*h)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			off := src.PixOffset(b.Min.X, y)
+			data = append(data, src.Pix[off:off+w]...)
+		}
+		pixels = data
+
+	case *image.Gray16:
+		spec, format = NewImageSpecSize(w, h, 1, TypeUint16()), TypeUint16()
+		data := make([]uint16, 0, w*h)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			off := src.PixOffset(b.Min.X, y)
+			for x := 0; x < w; x++ {
+				data = append(data, getUint16(src.Pix[off+x*2:]))
+			}
+		}
+		pixels = data
+
+	case *image.RGBA:
+		spec, format = NewImageSpecSize(w, h, 4, TypeUint8()), TypeUint8()
+		data := make([]uint8, 0, w*h*4)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			off := src.PixOffset(b.Min.X, y)
+			data = append(data, src.Pix[off:off+w*4]...)
+		}
+		pixels = data
+
+	default:
+		nchans := 4
+		if o, ok := img.(interface {
+			Opaque() bool
+		}); ok && o.Opaque() {
+			nchans = 3
+		}
+
+		spec, format = NewImageSpecSize(w, h, nchans, TypeUint16()), TypeUint16()
+		data := make([]uint16, 0, w*h*nchans)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				r, g, b, a := img.At(x, y).RGBA()
+				data = append(data, uint16(r), uint16(g), uint16(b))
+				if nchans == 4 {
+					data = append(data, uint16(a))
+				}
+			}
+		}
+		pixels = data
+
+	}
+
+	spec.SetX(b.Min.X)
+	spec.SetY(b.Min.Y)
+	spec.SetFullX(b.Min.X)
+	spec.SetFullY(b.Min.Y)
+
+	buf, err := NewImageBufSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	if err = buf.SetPixels(nil, format, pixels); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// ImageAdapter implements the draw.Image interface directly on top of an
+// ImageBuf, without copying its pixels. Each call to At or Set reads or
+// writes a single pixel of the ImageBuf (the first z slice, for volume
+// images), so it is best suited to sparse access and to small images.
+//
+// Colors are exchanged as color.Gray16 for single channel images, and
+// as premultiplied color.RGBA64 otherwise. Values outside of [0,1] are
+// clamped when read.
+type ImageAdapter struct {
+	buf    *ImageBuf
+	rect   image.Rectangle
+	z      int
+	nchans int
+	extra  bool
+
+	r, g, b, a int
+}
+
+var _ draw.Image = (*ImageAdapter)(nil)
+
+// NewImageAdapter returns a draw.Image that reads and writes the pixels
+// of the data window of the ImageBuf.
+func NewImageAdapter(buf *ImageBuf) *ImageAdapter {
+	spec := buf.Spec()
+	roi := buf.ROI()
+	nchans := spec.NumChannels()
+	r, g, b, a := imageChannelMap(nchans, spec.AlphaChannel())
+
+	return &ImageAdapter{
+		buf:    buf,
+		rect:   image.Rect(roi.XBegin(), roi.YBegin(), roi.XEnd(), roi.YEnd()),
+		z:      roi.ZBegin(),
+		nchans: nchans,
+		extra:  nchans > 3 && (nchans > 4 || a < 0),
+		r:      r,
+		g:      g,
+		b:      b,
+		a:      a,
+	}
+}
+
+// Return the ImageBuf that is being adapted.
+func (m *ImageAdapter) ImageBuf() *ImageBuf {
+	return m.buf
+}
+
+// Return color.Gray16Model for single channel images, and
+// color.RGBA64Model otherwise.
+func (m *ImageAdapter) ColorModel() color.Model {
+	if m.nchans == 1 {
+		return color.Gray16Model
+	}
+	return color.RGBA64Model
+}
+
+// Return the data window of the ImageBuf.
+func (m *ImageAdapter) Bounds() image.Rectangle {
+	return m.rect
+}
+
+// Return the color of the pixel at (x,y).
+// Pixels outside of the bounds are transparent black.
+func (m *ImageAdapter) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(m.rect)) {
+		if m.nchans == 1 {
+			return color.Gray16{}
+		}
+		return color.RGBA64{}
+	}
+
+	pixel := m.buf.GetPixelWrap(x, y, m.z, WrapBlack)
+
+	if m.nchans == 1 {
+		return color.Gray16{floatToUint16(pixel[0])}
+	}
+
+	a := uint16(0xffff)
+	if m.a >= 0 {
+		a = floatToUint16(pixel[m.a])
+	}
+	return color.RGBA64{
+		R: minUint16(floatToUint16(pixel[m.r]), a),
+		G: minUint16(floatToUint16(pixel[m.g]), a),
+		B: minUint16(floatToUint16(pixel[m.b]), a),
+		A: a,
+	}
+}
+
+// Set the pixel at (x,y) to the given color. Pixels outside of the
+// bounds are ignored. If the ImageBuf has no alpha channel, the color
+// is composited over black. Gray images store the luminance of the color.
+//
+// Set satisfies draw.Image, which has no way to report an error, so any
+// error from ImageBuf.SetPixel is dropped. Use ImageBuf.SetPixel directly
+// where errors must be handled.
+func (m *ImageAdapter) Set(x, y int, c color.Color) {
+	if !(image.Point{x, y}.In(m.rect)) {
+		return
+	}
+
+	pixel := make([]float32, m.nchans)
+
+	// Keep the values of any channels that are not color or alpha
+	if m.extra {
+		pixel = m.buf.GetPixelWrap(x, y, m.z, WrapBlack)
+	}
+
+	r, g, b, a := c.RGBA()
+	if m.r == m.g {
+		pixel[m.r] = float32(color.Gray16Model.Convert(c).(color.Gray16).Y) / 0xffff
+	} else {
+		pixel[m.r] = float32(r) / 0xffff
+		pixel[m.g] = float32(g) / 0xffff
+		pixel[m.b] = float32(b) / 0xffff
+	}
+	if m.a >= 0 {
+		pixel[m.a] = float32(a) / 0xffff
+	}
+
+	m.buf.SetPixel(x, y, m.z, pixel)
+}
+
+// Given the number of channels and the alpha channel of a spec, return
+// the channel indices to use for red, green, blue, and alpha (-1 if
+// there is no alpha). 2 channel images are treated as gray and alpha.
+func imageChannelMap(nchans, alpha int) (r, g, b, a int) {
+	if alpha >= nchans {
+		alpha = -1
+	}
+	switch {
+	case nchans >= 3:
+		return 0, 1, 2, alpha
+	case nchans == 2:
+		if alpha < 0 {
+			alpha = 1
+		}
+		return 0, 0, 0, alpha
+	default:
+		return 0, 0, 0, -1
+	}
+}
+
+func floatToUint16(v float32) uint16 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 1:
+		return 0xffff
+	default:
+		return uint16(v*0xffff + 0.5)
+	}
+}
+
+func minUint16(a, b uint16) uint16 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Image Pix data stores 16-bit values in big-endian order
+func putUint16(b []uint8, v uint16) {
+	b[0] = uint8(v >> 8)
+	b[1] = uint8(v)
+}
+
+func getUint16(b []uint8) uint16 {
+	return uint16(b[0])<<8 | uint16(b[1])
+}
@@ -0,0 +1,217 @@
+package oiio
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"reflect"
+	"testing"
+)
+
+func TestImageBufToImage(t *testing.T) {
+	// Gray
+	buf, err := NewImageBufSpec(NewImageSpecSize(2, 2, 1, TypeFloat()))
+	checkFatalError(t, err)
+	checkFatalError(t, Zero(buf))
+	checkFatalError(t, buf.SetPixel(1, 0, 0, []float32{0.5}))
+	checkFatalError(t, buf.SetPixel(0, 1, 0, []float32{2}))
+
+	img, err := buf.ToImage()
+	checkFatalError(t, err)
+
+	gray, ok := img.(*image.Gray16)
+	if !ok {
+		t.Fatalf("Expected *image.Gray16; got %T", img)
+	}
+	if c := gray.Gray16At(1, 0); c.Y != 0x8000 {
+		t.Errorf("Expected gray value 0x8000; got %#x", c.Y)
+	}
+	if c := gray.Gray16At(0, 1); c.Y != 0xffff {
+		t.Errorf("Expected gray value to be clamped to 0xffff; got %#x", c.Y)
+	}
+
+	// Premultiplied RGBA, with a data window origin
+	spec := NewImageSpecSize(3, 2, 4, TypeUint16())
+	spec.SetX(10)
+	spec.SetY(20)
+	buf, err = NewImageBufSpec(spec)
+	checkFatalError(t, err)
+	checkFatalError(t, Zero(buf))
+	checkFatalError(t, buf.SetPixel(11, 21, 0, []float32{0.25, 0, 0.5, 0.5}))
+
+	img, err = buf.ToImage()
+	checkFatalError(t, err)
+
+	rgba, ok := img.(*image.RGBA64)
+	if !ok {
+		t.Fatalf("Expected *image.RGBA64; got %T", img)
+	}
+	if expected := image.Rect(10, 20, 13, 22); rgba.Bounds() != expected {
+		t.Errorf("Expected bounds %v; got %v", expected, rgba.Bounds())
+	}
+	expected := color.RGBA64{0x4000, 0, 0x8000, 0x8000}
+	if c := rgba.RGBA64At(11, 21); c != expected {
+		t.Errorf("Expected color %v; got %v", expected, c)
+	}
+
+	// Unassociated 8-bit alpha
+	spec = NewImageSpecSize(1, 1, 4, TypeUint8())
+	spec.SetAttribute("oiio:UnassociatedAlpha", 1)
+	buf, err = NewImageBufSpec(spec)
+	checkFatalError(t, err)
+	checkFatalError(t, buf.SetPixels(nil, TypeUint8(), []uint8{255, 128, 0, 128}))
+
+	img, err = buf.ToImage()
+	checkFatalError(t, err)
+
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok {
+		t.Fatalf("Expected *image.NRGBA; got %T", img)
+	}
+	if c := nrgba.NRGBAAt(0, 0); c != (color.NRGBA{255, 128, 0, 128}) {
+		t.Errorf("Expected color {255 128 0 128}; got %v", c)
+	}
+}
+
+func TestNewImageBufFromImage(t *testing.T) {
+	// 8-bit premultiplied, sub-image with an offset
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	src.SetRGBA(2, 3, color.RGBA{64, 0, 128, 128})
+	sub := src.SubImage(image.Rect(1, 2, 4, 4))
+
+	buf, err := NewImageBufFromImage(sub)
+	checkFatalError(t, err)
+
+	roi := buf.ROI()
+	if roi.XBegin() != 1 || roi.YBegin() != 2 || roi.Width() != 3 || roi.Height() != 2 {
+		t.Errorf("Expected data window of (1,2) 3x2; got %v", roi)
+	}
+	if buf.PixelType() != TypeUint8() || buf.NumChannels() != 4 {
+		t.Errorf("Expected 4 channels of TypeUint8; got %d of %v", buf.NumChannels(), buf.PixelType())
+	}
+
+	actual, err := buf.GetPixelRegion(NewROIRegion3D(2, 3, 3, 4, 0, 1, 0, 4), TypeUint8())
+	checkFatalError(t, err)
+	if !reflect.DeepEqual(actual, []uint8{64, 0, 128, 128}) {
+		t.Errorf("Expected pixel [64 0 128 128]; got %v", actual)
+	}
+
+	// Non-premultiplied source is premultiplied
+	nsrc := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	nsrc.SetNRGBA(0, 0, color.NRGBA{255, 0, 0, 0x80})
+
+	buf, err = NewImageBufFromImage(nsrc)
+	checkFatalError(t, err)
+
+	if buf.PixelType() != TypeUint16() {
+		t.Errorf("Expected TypeUint16(); got %v", buf.PixelType())
+	}
+	img, err := buf.ToImage()
+	checkFatalError(t, err)
+
+	r, _, _, a := img.At(0, 0).RGBA()
+	er, _, _, ea := nsrc.At(0, 0).RGBA()
+	if r != er || a != ea {
+		t.Errorf("Expected premultiplied red %#x, alpha %#x; got %#x, %#x", er, ea, r, a)
+	}
+
+	// Opaque images have no alpha channel
+	ycc := image.NewYCbCr(image.Rect(0, 0, 2, 2), image.YCbCrSubsampleRatio444)
+	buf, err = NewImageBufFromImage(ycc)
+	checkFatalError(t, err)
+	if buf.NumChannels() != 3 {
+		t.Errorf("Expected 3 channels for an opaque image; got %d", buf.NumChannels())
+	}
+
+	// Gray16 round trip
+	gray := image.NewGray16(image.Rect(0, 0, 2, 1))
+	gray.SetGray16(1, 0, color.Gray16{0x1234})
+
+	buf, err = NewImageBufFromImage(gray)
+	checkFatalError(t, err)
+	img, err = buf.ToImage()
+	checkFatalError(t, err)
+	if !reflect.DeepEqual(gray, img) {
+		t.Errorf("Expected Gray16 image to round trip; got %v", img)
+	}
+
+	if _, err = NewImageBufFromImage(image.NewRGBA(image.Rectangle{})); err == nil {
+		t.Error("Expected an error when converting an empty image")
+	}
+}
+
+func TestNewImageFromPixels(t *testing.T) {
+	spec := NewImageSpecSize(2, 1, 3, TypeFloat())
+	spec.SetX(5)
+
+	img, err := NewImageFromPixels(spec, []uint16{0xffff, 0, 0, 0, 0x8000, 0})
+	checkFatalError(t, err)
+
+	rgba, ok := img.(*image.RGBA64)
+	if !ok {
+		t.Fatalf("Expected *image.RGBA64; got %T", img)
+	}
+	if expected := image.Rect(5, 0, 7, 1); rgba.Bounds() != expected {
+		t.Errorf("Expected bounds %v; got %v", expected, rgba.Bounds())
+	}
+	if c := rgba.RGBA64At(6, 0); c != (color.RGBA64{0, 0x8000, 0, 0xffff}) {
+		t.Errorf("Expected opaque green; got %v", c)
+	}
+
+	if _, err = NewImageFromPixels(spec, []float32{1, 0, 0, 0, 1, 0}); err == nil {
+		t.Error("Expected an error for pixels of the wrong type")
+	}
+	if _, err = NewImageFromPixels(spec, []uint16{0xffff, 0, 0}); err == nil {
+		t.Error("Expected an error when the pixels are too short")
+	}
+}
+
+func TestImageAdapter(t *testing.T) {
+	buf, err := NewImageBufSpec(NewImageSpecSize(4, 4, 4, TypeFloat()))
+	checkFatalError(t, err)
+	checkFatalError(t, Zero(buf))
+
+	adapter := NewImageAdapter(buf)
+	if adapter.Bounds() != image.Rect(0, 0, 4, 4) {
+		t.Errorf("Expected bounds (0,0)-(4,4); got %v", adapter.Bounds())
+	}
+	if adapter.ColorModel() != color.RGBA64Model {
+		t.Error("Expected color.RGBA64Model")
+	}
+
+	// Draw a half transparent red square over the top left corner
+	red := image.NewUniform(color.NRGBA{255, 0, 0, 128})
+	draw.Draw(adapter, image.Rect(0, 0, 2, 2), red, image.Point{}, draw.Src)
+
+	pixel := buf.GetPixel(1, 1, 0)
+	if pixel[0] != pixel[3] || pixel[1] != 0 || pixel[2] != 0 {
+		t.Errorf("Expected premultiplied red pixel; got %v", pixel)
+	}
+	if pixel := buf.GetPixel(2, 2, 0); !reflect.DeepEqual(pixel, []float32{0, 0, 0, 0}) {
+		t.Errorf("Expected pixel (2,2) to be untouched; got %v", pixel)
+	}
+
+	r, g, b, a := adapter.At(1, 1).RGBA()
+	er, eg, eb, ea := red.At(0, 0).RGBA()
+	if r != er || g != eg || b != eb || a != ea {
+		t.Errorf("Expected color %v; got %v", red.At(0, 0), adapter.At(1, 1))
+	}
+
+	if c := adapter.At(10, 10); c != (color.RGBA64{}) {
+		t.Errorf("Expected transparent black outside of the bounds; got %v", c)
+	}
+
+	// Gray images store luminance
+	gbuf, err := NewImageBufSpec(NewImageSpecSize(1, 1, 1, TypeFloat()))
+	checkFatalError(t, err)
+	checkFatalError(t, Zero(gbuf))
+
+	gadapter := NewImageAdapter(gbuf)
+	gadapter.Set(0, 0, color.White)
+	if v := gbuf.GetChannel(0, 0, 0, 0, WrapBlack); v != 1 {
+		t.Errorf("Expected white to be stored as 1; got %v", v)
+	}
+	if c := gadapter.At(0, 0); c != (color.Gray16{0xffff}) {
+		t.Errorf("Expected color.Gray16{0xffff}; got %v", c)
+	}
+}
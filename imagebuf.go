@@ -9,7 +9,11 @@ package oiio
 import "C"
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"reflect"
 	"runtime"
 	"unsafe"
 )
@@ -27,12 +31,36 @@ const (
 	IBStorageUninitialized IBStorage = C.IBSTORAGE_UNINITIALIZED
 )
 
+// Describes what happens when reading pixels that are outside
+// of the pixel data window of an ImageBuf
+type WrapMode int
+
+const (
+	// Use the default wrap mode of the operation (usually WrapBlack)
+	WrapDefault WrapMode = C.WrapDefault
+	// Pixels outside the data window are black (0)
+	WrapBlack WrapMode = C.WrapBlack
+	// Clamp to the nearest pixel inside the data window
+	WrapClamp WrapMode = C.WrapClamp
+	// Wrap around to the opposite side of the data window
+	WrapPeriodic WrapMode = C.WrapPeriodic
+	// Reflect back into the data window
+	WrapMirror WrapMode = C.WrapMirror
+)
+
 // An ImageBuf is a simple in-memory representation of a 2D image.
 // It uses ImageInput and ImageOutput underneath for its file I/O, and has simple
 // routines for setting and getting individual pixels, that hides most of the details
 // of memory layout and data representation (translating to/from float automatically).
 type ImageBuf struct {
 	ptr unsafe.Pointer
+
+	// Memory wrapped with NewImageBufWrap, kept alive with the ImageBuf
+	appBuffer *PixelBuffer
+
+	// Handle to the ImageCache of the ImageBuf, which keeps the
+	// cache from being destroyed while the ImageBuf uses it
+	cache *ImageCache
 }
 
 func newImageBuf(i unsafe.Pointer) *ImageBuf {
@@ -48,6 +76,10 @@ func deleteImageBuf(i *ImageBuf) {
 		C.free(i.ptr)
 		i.ptr = nil
 	}
+	if i.cache != nil {
+		i.cache.Destroy(false)
+		i.cache = nil
+	}
 }
 
 // Return the last error generated by API calls.
@@ -88,12 +120,35 @@ func NewImageBufPathCache(path string, cache *ImageCache) (*ImageBuf, error) {
 	c_str := C.CString(path)
 	defer C.free(unsafe.Pointer(c_str))
 
-	var ptr unsafe.Pointer = nil
-	if cache != nil {
-		ptr = cache.ptr
+	handle := imageBufCache(cache)
+	buf := newImageBuf(C.ImageBuf_New_WithCache(c_str, handle.ptr))
+	buf.cache = handle
+	err := buf.LastError()
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Return a new handle to the cache for an ImageBuf to hold, or to the
+// shared cache if cache is nil, which OIIO would otherwise use implicitly.
+func imageBufCache(cache *ImageCache) *ImageCache {
+	if cache == nil {
+		return CreateImageCache(true)
 	}
+	return cache.acquire()
+}
+
+// Construct an ImageBuf to read the named image at the given subimage
+// and MIP level – but don't actually read it yet!
+// If cache is nil, the global/shared ImageCache is used.
+func NewImageBufSubImage(path string, subimage, miplevel int, cache *ImageCache) (*ImageBuf, error) {
+	c_str := C.CString(path)
+	defer C.free(unsafe.Pointer(c_str))
 
-	buf := newImageBuf(C.ImageBuf_New_WithCache(c_str, ptr))
+	handle := imageBufCache(cache)
+	buf := newImageBuf(C.ImageBuf_New_SubImage(c_str, C.int(subimage), C.int(miplevel), handle.ptr))
+	buf.cache = handle
 	err := buf.LastError()
 	if err != nil {
 		return nil, err
@@ -112,6 +167,40 @@ func NewImageBufSpec(spec *ImageSpec) (*ImageBuf, error) {
 	return buf, nil
 }
 
+// NewImageBufWrap constructs an ImageBuf that uses the memory of a
+// PixelBuffer for its pixels, rather than allocating and copying its own
+// (IBStorageAppBuffer). The PixelBuffer must have the format of the spec, and
+// hold at least all of the pixels of the spec.
+//
+// Writes to the ImageBuf are visible through pixels.Pixels(), and vice versa.
+// The ImageBuf keeps the PixelBuffer alive for as long as it is in use.
+func NewImageBufWrap(spec *ImageSpec, pixels *PixelBuffer) (*ImageBuf, error) {
+	if pixels == nil || pixels.ptr == nil {
+		return nil, errors.New("PixelBuffer is nil or has been freed")
+	}
+	if pixels.format != spec.Format() {
+		return nil, fmt.Errorf("PixelBuffer format %v does not match ImageSpec format %v",
+			pixels.format, spec.Format())
+	}
+
+	expected := spec.Width() * spec.Height() * spec.Depth() * spec.NumChannels()
+	if err := checkPixelBufferSize(pixels.size, expected); err != nil {
+		return nil, err
+	}
+
+	c_str := C.CString("")
+	defer C.free(unsafe.Pointer(c_str))
+
+	buf := newImageBuf(C.ImageBuf_New_WithBuffer(c_str, spec.ptr, pixels.ptr))
+	buf.appBuffer = pixels
+
+	err := buf.LastError()
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
 // Is this ImageBuf object initialized?
 func (i *ImageBuf) Initialized() bool {
 	return bool(C.ImageBuf_initialized(i.ptr))
@@ -145,7 +234,7 @@ func (i *ImageBuf) InitSpec(filename string, subimage, miplevel int) error {
 // This uses ImageInput underneath, so will read any file format for which an appropriate
 // imageio plugin can be found.
 func (i *ImageBuf) Read(force bool) error {
-	return i.ReadFormatCallback(force, TypeUnknown, nil)
+	return i.ReadFormatCallback(force, TypeUnknown(), nil)
 }
 
 // Read the file from disk. Generally will skip the read if we've already got a current
@@ -159,7 +248,7 @@ func (i *ImageBuf) Read(force bool) error {
 // return true if the process should abort, and false if it should continue.
 //
 func (i *ImageBuf) ReadCallback(force bool, progress *ProgressCallback) error {
-	return i.ReadFormatCallback(force, TypeUnknown, progress)
+	return i.ReadFormatCallback(force, TypeUnknown(), progress)
 }
 
 // Read the file from disk. Generally will skip the read if we've already got a current
@@ -167,7 +256,7 @@ func (i *ImageBuf) ReadCallback(force bool, progress *ProgressCallback) error {
 // This uses ImageInput underneath, so will read any file format for which an appropriate
 // imageio plugin can be found.
 //
-// Specify a specific conversion format or TypeUnknown for automatic handling.
+// Specify a specific conversion format or TypeUnknown() for automatic handling.
 //
 // This call optionally supports passing a callback pointer to both track the progress,
 // and to optionally abort the processing. The callback function will receive
@@ -175,12 +264,14 @@ func (i *ImageBuf) ReadCallback(force bool, progress *ProgressCallback) error {
 // return true if the process should abort, and false if it should continue.
 //
 func (i *ImageBuf) ReadFormatCallback(force bool, convert TypeDesc, progress *ProgressCallback) error {
-	var cbk unsafe.Pointer
-	if progress != nil {
-		cbk = unsafe.Pointer(progress)
-	}
+	cbk := registerProgress(progress)
 
-	ok := C.ImageBuf_read(i.ptr, 0, 0, C.bool(force), C.TypeDesc(convert), cbk)
+	ok := C.ImageBuf_read(i.ptr, 0, 0, C.bool(force), convert.c(), C.uintptr_t(cbk))
+	if err := releaseProgress(cbk); err != nil {
+		// Discard the error of the aborted operation
+		i.LastError()
+		return err
+	}
 	if !bool(ok) {
 		return i.LastError()
 	}
@@ -188,6 +279,36 @@ func (i *ImageBuf) ReadFormatCallback(force bool, convert TypeDesc, progress *Pr
 	return nil
 }
 
+// ReadFormatCallbackContext is ReadFormatCallback, but aborts the read and
+// returns the error of ctx once ctx is done. The optional progress callback
+// is still called while the read is in progress. If the read is aborted,
+// any pixels that were already read are dropped, as if Read was never called.
+func (i *ImageBuf) ReadFormatCallbackContext(ctx context.Context, force bool, convert TypeDesc,
+	progress *ProgressCallback) error {
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := i.ReadFormatCallback(force, convert, contextProgress(ctx, progress))
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		i.resetUnread()
+		return ctxErr
+	}
+	return err
+}
+
+// Reset the ImageBuf to its file, without reading any pixels.
+func (i *ImageBuf) resetUnread() {
+	c_str := C.CString(i.Name())
+	defer C.free(unsafe.Pointer(c_str))
+
+	C.ImageBuf_reset_name_cache(i.ptr, c_str, C.ImageBuf_imagecache(i.ptr))
+	i.appBuffer = nil
+	// Discard the error of the aborted read
+	i.LastError()
+}
+
 // Write the image to the named file and file format
 // (fileformat=="" means to infer the type from the filename extension).
 func (i *ImageBuf) WriteFile(filepath, fileformat string) error {
@@ -203,18 +324,20 @@ func (i *ImageBuf) WriteFile(filepath, fileformat string) error {
 // return true if the process should abort, and false if it should continue.
 //
 func (i *ImageBuf) WriteFileProgress(filepath, fileformat string, progress *ProgressCallback) error {
-	var cbk unsafe.Pointer
-	if progress != nil {
-		cbk = unsafe.Pointer(progress)
-	}
-
 	c_path := C.CString(filepath)
 	defer C.free(unsafe.Pointer(c_path))
 
 	c_fmt := C.CString(fileformat)
 	defer C.free(unsafe.Pointer(c_fmt))
 
-	ok := C.ImageBuf_write_file(i.ptr, c_path, c_fmt, cbk)
+	cbk := registerProgress(progress)
+
+	ok := C.ImageBuf_write_file(i.ptr, c_path, c_fmt, C.uintptr_t(cbk))
+	if err := releaseProgress(cbk); err != nil {
+		// Discard the error of the aborted operation
+		i.LastError()
+		return err
+	}
 	if !bool(ok) {
 		return i.LastError()
 	}
@@ -222,8 +345,42 @@ func (i *ImageBuf) WriteFileProgress(filepath, fileformat string, progress *Prog
 	return nil
 }
 
+// WriteFileProgressContext is WriteFileProgress, but aborts the write and
+// returns the error of ctx once ctx is done. The file may be left partially
+// written. The optional progress callback is still called while the write
+// is in progress.
+func (i *ImageBuf) WriteFileProgressContext(ctx context.Context, filepath, fileformat string,
+	progress *ProgressCallback) error {
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := i.WriteFileProgress(filepath, fileformat, contextProgress(ctx, progress))
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
 // Write the image to the open ImageOutput 'out'. Return true if all went ok, false if there were errors writing.
 // It does NOT close the file when it's done (and so may be called in a loop to write a multi-image file).
+//
+// Example:
+//
+//     // Write each subimage of a source file into a new multi-part file
+//     out, _ := OpenImageOutput("out.exr")
+//     for s := 0; s < n; s++ {
+//         buf, _ := NewImageBufSubImage("in.exr", s, 0, nil)
+//         mode := OpenModeAppendSubimage
+//         if s == 0 {
+//             mode = OpenModeCreate
+//         }
+//         out.Open("out.exr", buf.Spec(), mode)
+//         buf.WriteImageOutput(out)
+//     }
+//     out.Close()
+//
 func (i *ImageBuf) WriteImageOutput(output *ImageOutput) error {
 	return i.WriteImageOutputProgress(output, nil)
 }
@@ -237,12 +394,14 @@ func (i *ImageBuf) WriteImageOutput(output *ImageOutput) error {
 // return true if the process should abort, and false if it should continue.
 //
 func (i *ImageBuf) WriteImageOutputProgress(output *ImageOutput, progress *ProgressCallback) error {
-	var cbk unsafe.Pointer
-	if progress != nil {
-		cbk = unsafe.Pointer(progress)
-	}
+	cbk := registerProgress(progress)
 
-	ok := C.ImageBuf_write_output(i.ptr, output.ptr, cbk)
+	ok := C.ImageBuf_write_output(i.ptr, output.ptr, C.uintptr_t(cbk))
+	if err := releaseProgress(cbk); err != nil {
+		// Discard the error of the aborted operation
+		i.LastError()
+		return err
+	}
 	if !bool(ok) {
 		return i.LastError()
 	}
@@ -250,9 +409,120 @@ func (i *ImageBuf) WriteImageOutputProgress(output *ImageOutput, progress *Progr
 	return nil
 }
 
+// WriteImageOutputProgressContext is WriteImageOutputProgress, but aborts
+// the write and returns the error of ctx once ctx is done. The optional
+// progress callback is still called while the write is in progress.
+func (i *ImageBuf) WriteImageOutputProgressContext(ctx context.Context, output *ImageOutput,
+	progress *ProgressCallback) error {
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := i.WriteImageOutputProgress(output, contextProgress(ctx, progress))
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// WriteTo writes the image to w, in the file format that the ImageBuf was
+// read from (see FileFormatName). It returns the number of bytes written.
+// WriteTo implements the io.WriterTo interface.
+func (i *ImageBuf) WriteTo(w io.Writer) (int64, error) {
+	return i.WriteToFormat(w, "")
+}
+
+// WriteToFormat writes the image to w, in the given file format, which may
+// be a format name or extension (ie. "png", "exr"). An empty fileformat
+// means to use the file format that the ImageBuf was read from.
+// It returns the number of bytes written. As with ImageOutput.OpenWriter,
+// this requires OpenImageIO 2.0 or newer.
+func (i *ImageBuf) WriteToFormat(w io.Writer, fileformat string) (int64, error) {
+	if fileformat == "" {
+		fileformat = i.FileFormatName()
+	}
+	if fileformat == "" {
+		return 0, errors.New("No file format was given, and the ImageBuf was not read from a file")
+	}
+
+	out, err := OpenImageOutput(fileformat)
+	if err != nil {
+		return 0, err
+	}
+
+	cw := &countingWriter{w: w}
+	if err = out.OpenWriter(cw, fileformat, i.Spec()); err != nil {
+		return 0, err
+	}
+
+	if err = i.WriteImageOutput(out); err != nil {
+		out.Close()
+		return cw.n, err
+	}
+
+	err = out.Close()
+	return cw.n, err
+}
+
+// ReadFrom replaces the contents of the ImageBuf with the first subimage
+// of an image read from r, trying every file format. It returns the number
+// of bytes read. ReadFrom implements the io.ReaderFrom interface.
+func (i *ImageBuf) ReadFrom(r io.Reader) (int64, error) {
+	return i.ReadFromFormat(r, "")
+}
+
+// ReadFromFormat replaces the contents of the ImageBuf with the first
+// subimage of an image read from r, in the given file format, which may be
+// a format name or extension (ie. "png", "exr"). All pixels are read into
+// local memory (IBStorageLocalBuffer). It returns the number of bytes read.
+//
+// If r can seek and read at an offset (ie. *os.File or *bytes.Reader), the
+// image is read from it as needed. Otherwise r is read into memory first.
+// As with OpenImageInputReader, this requires OpenImageIO 2.0 or newer.
+func (i *ImageBuf) ReadFromFormat(r io.Reader, fileformat string) (int64, error) {
+	if err := checkIOProxy(); err != nil {
+		return 0, err
+	}
+
+	ra, n, err := readerAtFor(r)
+	if err != nil {
+		return n, err
+	}
+
+	in, err := OpenImageInputReader(fileformat, ra, n)
+	if err != nil {
+		return n, err
+	}
+	defer in.Close()
+
+	spec := in.Spec()
+	if spec.Deep() {
+		return n, errors.New("Reading deep images from memory is not supported")
+	}
+
+	C.ImageBuf_reset_spec(i.ptr, spec.ptr)
+	i.appBuffer = nil
+	if err = i.LastError(); err != nil {
+		return n, err
+	}
+
+	ptr := C.ImageBuf_localpixels(i.ptr)
+	if ptr == nil {
+		return n, errors.New("ImageBuf did not allocate local pixel memory")
+	}
+
+	ok := C.ImageInput_read_image_format(in.ptr, i.PixelType().c(), ptr, 0)
+	if !bool(ok) {
+		return n, in.LastError()
+	}
+
+	return n, nil
+}
+
 // Inform the ImageBuf what data format you'd like for any subsequent write().
 func (i *ImageBuf) SetWriteFormat(format TypeDesc) {
-	C.ImageBuf_set_write_format(i.ptr, C.TypeDesc(format))
+	C.ImageBuf_set_write_format(i.ptr, format.c())
 }
 
 // Inform the ImageBuf what tile size (or no tiling, for 0) for any subsequent Write*()
@@ -376,7 +646,7 @@ func (i *ImageBuf) GetFloatPixels() ([]float32, error) {
 		C.int(roi.YBegin()), C.int(roi.YEnd()),
 		C.int(roi.ZBegin()), C.int(roi.ZEnd()),
 		C.int(roi.ChannelsBegin()), C.int(roi.ChannelsEnd()),
-		(C.TypeDesc)(TypeFloat), ptr),
+		TypeFloat().c(), ptr),
 	)
 
 	if !ok {
@@ -393,21 +663,21 @@ func (i *ImageBuf) GetFloatPixels() ([]float32, error) {
 //
 // The underlying type of data is determined by the given TypeDesc.
 // Returned interface{} will be:
-//     TypeUint8   => []uint8
-//     TypeInt8    => []int8
-//     TypeUint16  => []uint16
-//     TypeInt16   => []int16
-//     TypeUint    => []uint
-//     TypeInt     => []int
-//     TypeUint64  => []uint64
-//     TypeInt64   => []int64
-//     TypeHalf    => []float32
-//     TypeFloat   => []float32
-//     TypeDouble  => []float64
+//     TypeUint8()   => []uint8
+//     TypeInt8()    => []int8
+//     TypeUint16()  => []uint16
+//     TypeInt16()   => []int16
+//     TypeUint()    => []uint
+//     TypeInt()     => []int
+//     TypeUint64()  => []uint64
+//     TypeInt64()   => []int64
+//     TypeHalf()    => []float32
+//     TypeFloat()   => []float32
+//     TypeDouble()  => []float64
 //
 // Example:
 //
-//     val, err := buf.GetPixels(TypeFloat)
+//     val, err := buf.GetPixels(TypeFloat())
 //     if err != nil {
 //         panic(err.Error())
 //     }
@@ -427,7 +697,7 @@ func (i *ImageBuf) GetPixels(format TypeDesc) (interface{}, error) {
 		C.int(roi.YBegin()), C.int(roi.YEnd()),
 		C.int(roi.ZBegin()), C.int(roi.ZEnd()),
 		C.int(roi.ChannelsBegin()), C.int(roi.ChannelsEnd()),
-		(C.TypeDesc)(format), ptr),
+		format.c(), ptr),
 	)
 
 	if !ok {
@@ -444,21 +714,21 @@ func (i *ImageBuf) GetPixels(format TypeDesc) (interface{}, error) {
 //
 // The underlying type of data is determined by the given TypeDesc.
 // Returned interface{} will be:
-//     TypeUint8   => []uint8
-//     TypeInt8    => []int8
-//     TypeUint16  => []uint16
-//     TypeInt16   => []int16
-//     TypeUint    => []uint
-//     TypeInt     => []int
-//     TypeUint64  => []uint64
-//     TypeInt64   => []int64
-//     TypeHalf    => []float32
-//     TypeFloat   => []float32
-//     TypeDouble  => []float64
+//     TypeUint8()   => []uint8
+//     TypeInt8()    => []int8
+//     TypeUint16()  => []uint16
+//     TypeInt16()   => []int16
+//     TypeUint()    => []uint
+//     TypeInt()     => []int
+//     TypeUint64()  => []uint64
+//     TypeInt64()   => []int64
+//     TypeHalf()    => []float32
+//     TypeFloat()   => []float32
+//     TypeDouble()  => []float64
 //
 // Example:
 //
-//     val, err := buf.GetPixelRegion(roi, TypeFloat)
+//     val, err := buf.GetPixelRegion(roi, TypeFloat())
 //     if err != nil {
 //         panic(err.Error())
 //     }
@@ -476,7 +746,7 @@ func (i *ImageBuf) GetPixelRegion(roi *ROI, format TypeDesc) (interface{}, error
 		C.int(roi.YBegin()), C.int(roi.YEnd()),
 		C.int(roi.ZBegin()), C.int(roi.ZEnd()),
 		C.int(roi.ChannelsBegin()), C.int(roi.ChannelsEnd()),
-		(C.TypeDesc)(format), ptr),
+		format.c(), ptr),
 	)
 
 	if !ok {
@@ -486,6 +756,161 @@ func (i *ImageBuf) GetPixelRegion(roi *ROI, format TypeDesc) (interface{}, error
 	return pixel_iface, nil
 }
 
+// SetPixels copies a rectangle of pixel values, defined by an ROI, from a
+// slice into the ImageBuf at the current subimage and MIP-map level. The
+// values are converted from the pixel format type described by 'format'
+// to the pixel type of the ImageBuf. If roi is nil or undefined, the entire
+// image is set. The channels of the ROI are clamped to the channels of the
+// ImageBuf, as OIIO does when it copies the values.
+//
+// The slice must hold at least roi.NumPixels() * roi.NumChannels() values
+// for the clamped ROI,
+// and its type must be the one that GetPixels and GetPixelRegion return for
+// the given format:
+//     TypeUint8()   => []uint8
+//     TypeInt8()    => []int8
+//     TypeUint16()  => []uint16
+//     TypeInt16()   => []int16
+//     TypeUint()    => []uint
+//     TypeInt()     => []int
+//     TypeUint64()  => []uint64
+//     TypeInt64()   => []int64
+//     TypeHalf()    => []float32
+//     TypeFloat()   => []float32
+//     TypeDouble()  => []float64
+//
+// Example:
+//
+//     pixels := make([]float32, roi.NumPixels()*roi.NumChannels())
+//     // ... generate pixel values
+//     err := buf.SetPixels(roi, TypeFloat(), pixels)
+//     if err != nil {
+//         panic(err.Error())
+//     }
+//
+func (i *ImageBuf) SetPixels(roi *ROI, format TypeDesc, data interface{}) error {
+	if !pixelBufferMatchesFormat(data, format) {
+		return fmt.Errorf("Pixel type %T does not match the TypeDesc %v", data, format)
+	}
+
+	ptr, size, dataFormat, err := pixelBufferInfo(data)
+	if err != nil {
+		return err
+	}
+
+	if roi == nil || !roi.Defined() {
+		roi = i.ROI()
+	} else if roi.ChannelsEnd() > i.NumChannels() {
+		roi = roi.Copy()
+		roi.SetChannelsEnd(i.NumChannels())
+	}
+	if roi.NumPixels() <= 0 || roi.NumChannels() <= 0 {
+		return errors.New("ROI does not contain any pixels or channels of the ImageBuf")
+	}
+
+	if err = checkPixelBufferSize(size, roi.NumPixels()*roi.NumChannels()); err != nil {
+		return err
+	}
+
+	// The values are read using the in-memory layout of the slice, which
+	// may differ from 'format' (ie. []float32 for TypeHalf())
+	ok := bool(C.ImageBuf_set_pixels(i.ptr, roi.ptr, dataFormat.c(), ptr))
+	runtime.KeepAlive(data)
+
+	if !ok {
+		return i.LastError()
+	}
+	return nil
+}
+
+// GetChannel retrieves the value of channel c of pixel (x,y,z), converted to
+// float32. Pixels outside the data window are handled according to wrap.
+func (i *ImageBuf) GetChannel(x, y, z, c int, wrap WrapMode) float32 {
+	return float32(C.ImageBuf_getchannel(i.ptr, C.int(x), C.int(y), C.int(z), C.int(c), C.WrapMode(wrap)))
+}
+
+// GetPixel retrieves the float32 values of all channels of pixel (x,y,z).
+// Pixels outside the data window are black.
+func (i *ImageBuf) GetPixel(x, y, z int) []float32 {
+	return i.GetPixelWrap(x, y, z, WrapBlack)
+}
+
+// GetPixelWrap retrieves the float32 values of all channels of pixel (x,y,z).
+// Pixels outside the data window are handled according to wrap.
+func (i *ImageBuf) GetPixelWrap(x, y, z int, wrap WrapMode) []float32 {
+	nchans := i.NumChannels()
+	if nchans <= 0 {
+		return []float32{}
+	}
+	pixel := make([]float32, nchans)
+	C.ImageBuf_getpixel_xyz(i.ptr, C.int(x), C.int(y), C.int(z),
+		(*C.float)(unsafe.Pointer(&pixel[0])), C.int(nchans), C.WrapMode(wrap))
+	return pixel
+}
+
+// InterpPixel samples the image plane at pixel coordinates (x,y), using
+// bilinear interpolation, and returns the float32 values of all channels.
+// Pixel centers are at (x+0.5, y+0.5), so InterpPixel(0.5, 0.5) returns
+// exactly the value of pixel (0,0). Pixels outside the data window are
+// handled according to wrap.
+func (i *ImageBuf) InterpPixel(x, y float32, wrap WrapMode) []float32 {
+	nchans := i.NumChannels()
+	if nchans <= 0 {
+		return []float32{}
+	}
+	pixel := make([]float32, nchans)
+	C.ImageBuf_interppixel(i.ptr, C.float(x), C.float(y), (*C.float)(unsafe.Pointer(&pixel[0])), C.WrapMode(wrap))
+	return pixel
+}
+
+// InterpPixelNDC is like InterpPixel, but the (s,t) coordinates are in
+// normalized device coordinates, where (0,0) is the upper left corner
+// of the data window and (1,1) is the lower right corner.
+func (i *ImageBuf) InterpPixelNDC(s, t float32, wrap WrapMode) []float32 {
+	nchans := i.NumChannels()
+	if nchans <= 0 {
+		return []float32{}
+	}
+	pixel := make([]float32, nchans)
+	C.ImageBuf_interppixel_NDC(i.ptr, C.float(s), C.float(t), (*C.float)(unsafe.Pointer(&pixel[0])), C.WrapMode(wrap))
+	return pixel
+}
+
+// InterpPixelNDCFull is like InterpPixelNDC, but the (s,t) coordinates are
+// relative to the full (display) window, rather than the data window.
+func (i *ImageBuf) InterpPixelNDCFull(s, t float32, wrap WrapMode) []float32 {
+	nchans := i.NumChannels()
+	if nchans <= 0 {
+		return []float32{}
+	}
+	pixel := make([]float32, nchans)
+	C.ImageBuf_interppixel_NDC_full(i.ptr, C.float(s), C.float(t), (*C.float)(unsafe.Pointer(&pixel[0])), C.WrapMode(wrap))
+	return pixel
+}
+
+// SetPixel sets the channels of pixel (x,y,z) from the float32 values,
+// converting to the pixel data type of the ImageBuf. Only the first
+// min(len(pixel), channels) channels are set.
+func (i *ImageBuf) SetPixel(x, y, z int, pixel []float32) error {
+	if len(pixel) == 0 {
+		return errors.New("Pixel slice is empty")
+	}
+	C.ImageBuf_setpixel_xyz(i.ptr, C.int(x), C.int(y), C.int(z),
+		(*C.float)(unsafe.Pointer(&pixel[0])), C.int(len(pixel)))
+	return i.LastError()
+}
+
+// SetPixelIndex sets the channels of the pixel with the given index
+// (counting from the first pixel of the data window, in scanline order)
+// from the float32 values, as with SetPixel.
+func (i *ImageBuf) SetPixelIndex(index int, pixel []float32) error {
+	if len(pixel) == 0 {
+		return errors.New("Pixel slice is empty")
+	}
+	C.ImageBuf_setpixel_index(i.ptr, C.int(index), (*C.float)(unsafe.Pointer(&pixel[0])), C.int(len(pixel)))
+	return i.LastError()
+}
+
 // By default, image pixels are ordered from the top of the display to the bottom, and within
 // each scanline, from left to right (i.e., the same ordering as English text and scan progression
 // on a CRT). But the "Orientation" field can suggest that it should be displayed
@@ -625,19 +1050,135 @@ func (i *ImageBuf) PixelsValid() bool {
 }
 
 func (i *ImageBuf) PixelType() TypeDesc {
-	return TypeDesc(C.ImageBuf_pixeltype(i.ptr))
+	return newTypeDesc(C.ImageBuf_pixeltype(i.ptr))
+}
+
+// LocalPixelsView is a view of the pixels of an ImageBuf that holds them in
+// local memory. It holds its ImageBuf, so that the finalizer of the ImageBuf
+// does not free the pixels while the LocalPixelsView is reachable.
+//
+// The garbage collector does not know that Data points into the memory of
+// the ImageBuf, so code that uses Data after its last use of the
+// LocalPixelsView must keep the LocalPixelsView alive with runtime.KeepAlive
+// after its last access to Data.
+//
+// Data becomes invalid as soon as the ImageBuf reallocates or frees its
+// pixels, ie. by Clear, Reset, Read, Copy, Swap, by being the destination of
+// an ImageBufAlgo function that reinitializes it, or by Destroy. If the
+// ImageBuf wraps a PixelBuffer (NewImageBufWrap), Data is the Go slice of
+// the PixelBuffer, and remains valid, but is no longer shared with the
+// ImageBuf after its pixels are reallocated.
+type LocalPixelsView struct {
+	// The pixels of all channels of the image, as described by LocalPixels
+	Data interface{}
+
+	buf *ImageBuf
+}
+
+// LocalPixels returns a view of the pixel memory of an ImageBuf whose
+// pixels are held in memory (IBStorageLocalBuffer or IBStorageAppBuffer),
+// without copying it. Data is a slice that has been casted to an interface,
+// typed according to the PixelType() of the ImageBuf, as described by
+// PixelBuffer.Pixels(). Writes to Data are visible to the ImageBuf, and
+// writes to the ImageBuf are visible in Data.
+//
+// An error is returned if the ImageBuf is backed by an ImageCache.
+func (i *ImageBuf) LocalPixels() (*LocalPixelsView, error) {
+	ptr := C.ImageBuf_localpixels(i.ptr)
+	if ptr == nil {
+		return nil, errors.New("ImageBuf does not hold its pixels in local memory")
+	}
+	spec := i.Spec()
+	size := spec.Width() * spec.Height() * spec.Depth() * spec.NumChannels()
+
+	if i.appBuffer != nil && i.appBuffer.ptr == ptr {
+		data := reflect.ValueOf(i.appBuffer.Pixels()).Slice(0, size).Interface()
+		return &LocalPixelsView{Data: data, buf: i}, nil
+	}
+
+	data, err := pixelSliceView(ptr, size, i.PixelType())
+	if err != nil {
+		return nil, err
+	}
+	return &LocalPixelsView{Data: data, buf: i}, nil
 }
 
 func (i *ImageBuf) CachedPixels() bool {
 	return bool(C.ImageBuf_cachedpixels(i.ptr))
 }
 
-// Return the ImageCache in which backs this ImageBuf
+// Return the ImageCache in which backs this ImageBuf, or nil if the
+// ImageBuf is not backed by a cache. The returned handle holds its own
+// reference to the cache, and should be released with ImageCache.Destroy.
 func (i *ImageBuf) ImageCache() *ImageCache {
-	return &ImageCache{C.ImageBuf_imagecache(i.ptr)}
+	ptr := C.ImageBuf_imagecache(i.ptr)
+	if ptr == nil {
+		return nil
+	}
+	return imageCacheHandle(ptr)
 }
 
 // Does this ImageBuf store deep data?
 func (i *ImageBuf) Deep() bool {
 	return bool(C.ImageBuf_deep(i.ptr))
 }
+
+// Return the number of deep samples for the given pixel, or 0
+// if the ImageBuf is not deep.
+func (i *ImageBuf) DeepSamples(x, y, z int) int {
+	return int(C.ImageBuf_deep_samples(i.ptr, C.int(x), C.int(y), C.int(z)))
+}
+
+// Set the number of deep samples for the given pixel.
+// This has no effect if the ImageBuf is not deep.
+func (i *ImageBuf) SetDeepSamples(x, y, z, nsamples int) {
+	C.ImageBuf_set_deep_samples(i.ptr, C.int(x), C.int(y), C.int(z), C.int(nsamples))
+}
+
+// Insert nsamples new deep samples into the given pixel, starting
+// at sample position samplePos.
+func (i *ImageBuf) DeepInsertSamples(x, y, z, samplePos, nsamples int) {
+	C.ImageBuf_deep_insert_samples(i.ptr, C.int(x), C.int(y), C.int(z), C.int(samplePos), C.int(nsamples))
+}
+
+// Erase nsamples deep samples from the given pixel, starting
+// at sample position samplePos.
+func (i *ImageBuf) DeepEraseSamples(x, y, z, samplePos, nsamples int) {
+	C.ImageBuf_deep_erase_samples(i.ptr, C.int(x), C.int(y), C.int(z), C.int(samplePos), C.int(nsamples))
+}
+
+// Return the value of deep sample s of channel c of the given pixel,
+// converted to float32. Returns 0 if the ImageBuf is not deep, or if the
+// pixel, channel, or sample is out of range.
+func (i *ImageBuf) DeepValue(x, y, z, c, s int) float32 {
+	return float32(C.ImageBuf_deep_value(i.ptr, C.int(x), C.int(y), C.int(z), C.int(c), C.int(s)))
+}
+
+// Return the value of deep sample s of channel c of the given pixel,
+// converted to uint32.
+func (i *ImageBuf) DeepValueUint(x, y, z, c, s int) uint32 {
+	return uint32(C.ImageBuf_deep_value_uint(i.ptr, C.int(x), C.int(y), C.int(z), C.int(c), C.int(s)))
+}
+
+// Set the value of deep sample s of channel c of the given pixel.
+func (i *ImageBuf) SetDeepValue(x, y, z, c, s int, value float32) {
+	C.ImageBuf_set_deep_value(i.ptr, C.int(x), C.int(y), C.int(z), C.int(c), C.int(s), C.float(value))
+}
+
+// Set the value of deep sample s of channel c of the given pixel,
+// from a uint32 value.
+func (i *ImageBuf) SetDeepValueUint(x, y, z, c, s int, value uint32) {
+	C.ImageBuf_set_deep_value_uint(i.ptr, C.int(x), C.int(y), C.int(z), C.int(c), C.int(s), C.uint32_t(value))
+}
+
+// Return a reference to the DeepData that holds the deep pixels of this
+// ImageBuf, or nil if the ImageBuf is not deep, or deep data is not
+// supported by the linked OpenImageIO. The DeepData is owned by the
+// ImageBuf, and keeps the ImageBuf alive for as long as it is in use.
+func (i *ImageBuf) DeepData() *DeepData {
+	ptr := C.ImageBuf_deepdata(i.ptr)
+	if ptr == nil {
+		return nil
+	}
+	return &DeepData{ptr: ptr, owner: i}
+}
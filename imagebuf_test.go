@@ -1,8 +1,12 @@
 package oiio
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"math"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -223,7 +227,7 @@ func TestImageBufWriteFile(t *testing.T) {
 	defer os.Remove(outfile)
 
 	buf.SetWriteTiles(0, 0, 0)
-	buf.SetWriteFormat(TypeUint8)
+	buf.SetWriteFormat(TypeUint8())
 
 	checkFatalError(t, buf.WriteFile(outfile, ""))
 
@@ -242,6 +246,142 @@ func TestImageBufWriteFile(t *testing.T) {
 	checkFatalError(t, buf.WriteFileProgress(outfile, "", &progress))
 }
 
+func TestImageBufContext(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var calls int
+	var progress ProgressCallback = func(done float32) bool {
+		calls++
+		return false
+	}
+
+	buf, err := NewImageBufPath(TEST_IMAGE)
+	checkFatalError(t, err)
+
+	checkFatalError(t, buf.ReadFormatCallbackContext(context.Background(), true, TypeFloat(), &progress))
+	if !buf.PixelsValid() {
+		t.Fatal("Expected pixels to be read")
+	}
+	if calls == 0 {
+		t.Error("Expected the progress callback to be called")
+	}
+
+	if err = buf.ReadFormatCallbackContext(cancelled, true, TypeFloat(), nil); err != context.Canceled {
+		t.Errorf("Expected error %v; got %v", context.Canceled, err)
+	}
+
+	// Writes
+	outfile := createOutputFile()
+	defer os.Remove(outfile)
+
+	checkFatalError(t, buf.WriteFileProgressContext(context.Background(), outfile, "", nil))
+	if err = buf.WriteFileProgressContext(cancelled, outfile, "", nil); err != context.Canceled {
+		t.Errorf("Expected error %v; got %v", context.Canceled, err)
+	}
+
+	out, err := OpenImageOutput(outfile)
+	checkFatalError(t, err)
+	checkFatalError(t, out.Open(outfile, buf.Spec(), OpenModeCreate))
+	defer out.Close()
+
+	if err = buf.WriteImageOutputProgressContext(cancelled, out, nil); err != context.Canceled {
+		t.Errorf("Expected error %v; got %v", context.Canceled, err)
+	}
+	checkFatalError(t, buf.WriteImageOutputProgressContext(context.Background(), out, nil))
+
+	// Cancelled from the progress callback, while the read is in progress
+	partial, err := NewImageBufPath(TEST_IMAGE)
+	checkFatalError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var cancelling ProgressCallback = func(done float32) bool {
+		cancel()
+		return false
+	}
+	if err = partial.ReadFormatCallbackContext(ctx, true, TypeFloat(), &cancelling); err != context.Canceled {
+		t.Errorf("Expected error %v; got %v", context.Canceled, err)
+	}
+	if partial.PixelsValid() {
+		t.Error("Expected the pixels of a cancelled read to be dropped")
+	}
+
+	// The ImageBuf can still be read afterwards
+	checkFatalError(t, partial.ReadFormatCallbackContext(context.Background(), true, TypeFloat(), nil))
+	if !partial.PixelsValid() {
+		t.Error("Expected pixels to be read after a cancelled read")
+	}
+}
+
+func TestImageBufWriteToReadFrom(t *testing.T) {
+	requireIOProxy(t, "png", "exr")
+
+	src, err := NewImageBufPath(TEST_IMAGE)
+	checkFatalError(t, err)
+
+	expected, err := src.GetFloatPixels()
+	checkFatalError(t, err)
+
+	// Defaults to the format the ImageBuf was read from
+	var buf bytes.Buffer
+	n, err := src.WriteTo(&buf)
+	checkFatalError(t, err)
+	if n == 0 || n != int64(buf.Len()) {
+		t.Fatalf("Expected %d bytes written; got %d", buf.Len(), n)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("\x89PNG")) {
+		t.Error("Expected a PNG to be written")
+	}
+
+	for _, format := range []string{"png", "exr"} {
+		buf.Reset()
+		_, err = src.WriteToFormat(&buf, format)
+		checkFatalError(t, err)
+
+		dst := NewImageBuf()
+		size := int64(buf.Len())
+		n, err = dst.ReadFromFormat(&buf, format)
+		checkFatalError(t, err)
+		if n != size {
+			t.Errorf("%s: Expected %d bytes read; got %d", format, size, n)
+		}
+
+		if dst.Storage() != IBStorageLocalBuffer {
+			t.Errorf("%s: Expected IBStorageLocalBuffer; got %v", format, dst.Storage())
+		}
+
+		actual, err := dst.GetFloatPixels()
+		checkFatalError(t, err)
+		if len(expected) != len(actual) {
+			t.Fatalf("%s: Expected %d values; got %d", format, len(expected), len(actual))
+		}
+		// 8-bit values are not exactly representable as half floats in exr
+		for i := range expected {
+			if math.Abs(float64(expected[i]-actual[i])) > 1e-3 {
+				t.Errorf("%s: Pixels do not match after a round trip: expected %v; got %v",
+					format, expected[i], actual[i])
+				break
+			}
+		}
+	}
+
+	// Detect the format
+	buf.Reset()
+	_, err = src.WriteToFormat(&buf, "exr")
+	checkFatalError(t, err)
+
+	dst := NewImageBuf()
+	_, err = dst.ReadFrom(&buf)
+	checkFatalError(t, err)
+	if w, h := dst.Spec().Width(), dst.Spec().Height(); w != 128 || h != 64 {
+		t.Errorf("Expected 128x64; got %dx%d", w, h)
+	}
+
+	if _, err = NewImageBuf().WriteTo(&buf); err == nil {
+		t.Error("Expected an error writing an ImageBuf with no file format")
+	}
+}
+
 func TestImageBufCopySwap(t *testing.T) {
 	src, err := NewImageBufPath(TEST_IMAGE)
 	if err != nil {
@@ -263,7 +403,7 @@ func TestImageBufGetPixels(t *testing.T) {
 
 	var pixel_iface interface{}
 
-	pixel_iface, err = src.GetPixels(TypeFloat)
+	pixel_iface, err = src.GetPixels(TypeFloat())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -320,7 +460,7 @@ func TestImageBufGetPixelRegion(t *testing.T) {
 	roi := src.ROI()
 	roi.SetXEnd(10)
 	roi.SetYEnd(10)
-	pixel_iface, err := src.GetPixelRegion(roi, TypeFloat)
+	pixel_iface, err := src.GetPixelRegion(roi, TypeFloat())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -340,3 +480,204 @@ func TestImageBufGetPixelRegion(t *testing.T) {
 		}
 	}
 }
+
+func TestImageBufSetPixels(t *testing.T) {
+	buf, err := NewImageBufSpec(NewImageSpecSize(4, 4, 3, TypeUint8()))
+	checkFatalError(t, err)
+	// Pixels of a new ImageBuf are uninitialized
+	checkFatalError(t, Zero(buf))
+
+	roi := NewROIRegion3D(1, 3, 1, 2, 0, 1, 0, 3)
+
+	if err = buf.SetPixels(roi, TypeUint8(), []float32{1, 2, 3}); err == nil {
+		t.Error("Expected an error when the slice type does not match the format")
+	}
+	if err = buf.SetPixels(roi, TypeFloat(), []float32{1, 0.5, 0}); err == nil {
+		t.Error("Expected an error when the slice is smaller than the ROI")
+	}
+
+	pixels := []float32{1, 0.5, 0, 0, 0.5, 1}
+	checkFatalError(t, buf.SetPixels(roi, TypeFloat(), pixels))
+
+	actual, err := buf.GetPixelRegion(roi, TypeUint8())
+	checkFatalError(t, err)
+
+	expected := []uint8{255, 128, 0, 0, 128, 255}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected pixels %v; got %v", expected, actual)
+	}
+
+	// Pixels outside of the ROI are untouched
+	if p := buf.GetPixel(0, 1, 0); !reflect.DeepEqual(p, []float32{0, 0, 0}) {
+		t.Errorf("Expected pixel (0,1) to be black; got %v", p)
+	}
+
+	// Whole image
+	all := make([]uint16, 4*4*3)
+	for i := range all {
+		all[i] = 65535
+	}
+	checkFatalError(t, buf.SetPixels(nil, TypeUint16(), all))
+	if p := buf.GetPixel(0, 1, 0); !reflect.DeepEqual(p, []float32{1, 1, 1}) {
+		t.Errorf("Expected pixel (0,1) to be white; got %v", p)
+	}
+
+	// An undefined ROI is the whole image
+	if err = buf.SetPixels(NewROI(), TypeUint16(), all[:3]); err == nil {
+		t.Error("Expected an error when the slice is smaller than the image of an undefined ROI")
+	}
+	checkFatalError(t, buf.SetPixels(NewROI(), TypeUint16(), make([]uint16, 4*4*3)))
+	if p := buf.GetPixel(3, 3, 0); !reflect.DeepEqual(p, []float32{0, 0, 0}) {
+		t.Errorf("Expected pixel (3,3) to be black; got %v", p)
+	}
+
+	// The channels of the ROI are clamped to the channels of the image
+	region := NewROIRegion2D(0, 2, 0, 1)
+	if region.ChannelsEnd() <= buf.NumChannels() {
+		t.Fatalf("Expected a 2D region to cover more than %d channels", buf.NumChannels())
+	}
+	checkFatalError(t, buf.SetPixels(region, TypeFloat(), []float32{1, 1, 1, 0, 1, 0}))
+	if p := buf.GetPixel(1, 0, 0); !reflect.DeepEqual(p, []float32{0, 1, 0}) {
+		t.Errorf("Expected pixel (1,0) to be [0 1 0]; got %v", p)
+	}
+
+	outside := NewROIRegion3D(0, 2, 0, 1, 0, 1, 3, 4)
+	if err = buf.SetPixels(outside, TypeFloat(), []float32{1, 1}); err == nil {
+		t.Error("Expected an error when the ROI has no channels of the image")
+	}
+}
+
+func TestImageBufPixelAccess(t *testing.T) {
+	buf, err := NewImageBufSpec(NewImageSpecSize(4, 4, 3, TypeFloat()))
+	checkFatalError(t, err)
+	// Pixels of a new ImageBuf are uninitialized
+	checkFatalError(t, Zero(buf))
+
+	if err = buf.SetPixel(0, 0, 0, nil); err == nil {
+		t.Error("Expected an error when setting an empty pixel")
+	}
+
+	checkFatalError(t, buf.SetPixel(1, 2, 0, []float32{0.25, 0.5, 1}))
+
+	if actual := buf.GetPixel(1, 2, 0); !reflect.DeepEqual(actual, []float32{0.25, 0.5, 1}) {
+		t.Errorf("Expected pixel (1,2) to be [0.25 0.5 1]; got %v", actual)
+	}
+	if v := buf.GetChannel(1, 2, 0, 1, WrapBlack); v != 0.5 {
+		t.Errorf("Expected channel 1 of pixel (1,2) to be 0.5; got %v", v)
+	}
+
+	// Index 11 is pixel (3,2)
+	checkFatalError(t, buf.SetPixelIndex(11, []float32{1, 1, 1}))
+	if actual := buf.GetPixel(3, 2, 0); !reflect.DeepEqual(actual, []float32{1, 1, 1}) {
+		t.Errorf("Expected pixel (3,2) to be [1 1 1]; got %v", actual)
+	}
+
+	// Outside of the data window
+	if actual := buf.GetPixel(4, 2, 0); !reflect.DeepEqual(actual, []float32{0, 0, 0}) {
+		t.Errorf("Expected pixel (4,2) to be black; got %v", actual)
+	}
+	if actual := buf.GetPixelWrap(4, 2, 0, WrapClamp); !reflect.DeepEqual(actual, []float32{1, 1, 1}) {
+		t.Errorf("Expected clamped pixel (4,2) to be [1 1 1]; got %v", actual)
+	}
+	if actual := buf.GetPixelWrap(5, 2, 0, WrapPeriodic); !reflect.DeepEqual(actual, []float32{0.25, 0.5, 1}) {
+		t.Errorf("Expected periodic pixel (5,2) to be [0.25 0.5 1]; got %v", actual)
+	}
+	if v := buf.GetChannel(-2, 2, 0, 0, WrapMirror); v != 0.25 {
+		t.Errorf("Expected mirrored channel 0 of pixel (-2,2) to be 0.25; got %v", v)
+	}
+
+	// Pixel centers interpolate to exact values
+	if actual := buf.InterpPixel(1.5, 2.5, WrapBlack); !reflect.DeepEqual(actual, []float32{0.25, 0.5, 1}) {
+		t.Errorf("Expected interpolated pixel at (1.5,2.5) to be [0.25 0.5 1]; got %v", actual)
+	}
+	if actual := buf.InterpPixel(1, 2.5, WrapBlack); actual[0] != 0.125 {
+		t.Errorf("Expected interpolated channel 0 at (1,2.5) to be 0.125; got %v", actual)
+	}
+	if actual := buf.InterpPixelNDC(0.375, 0.625, WrapBlack); !reflect.DeepEqual(actual, []float32{0.25, 0.5, 1}) {
+		t.Errorf("Expected NDC pixel at (0.375,0.625) to be [0.25 0.5 1]; got %v", actual)
+	}
+	if actual := buf.InterpPixelNDCFull(0.375, 0.625, WrapBlack); !reflect.DeepEqual(actual, []float32{0.25, 0.5, 1}) {
+		t.Errorf("Expected full NDC pixel at (0.375,0.625) to be [0.25 0.5 1]; got %v", actual)
+	}
+}
+
+func TestImageBufWriteImageOutputSubimages(t *testing.T) {
+	srcfile := `testdata/subimages.exr`
+	src, err := NewImageBufPath(srcfile)
+	checkFatalError(t, err)
+
+	nsubimages := src.NumSubImages()
+	if nsubimages < 2 {
+		t.Fatalf("Expected %q to have multiple subimages; got %d", srcfile, nsubimages)
+	}
+
+	bufs := make([]*ImageBuf, nsubimages)
+	specs := make([]*ImageSpec, nsubimages)
+	for s := 0; s < nsubimages; s++ {
+		bufs[s], err = NewImageBufSubImage(srcfile, s, 0, nil)
+		checkFatalError(t, err)
+		checkFatalError(t, bufs[s].Read(false))
+		specs[s] = bufs[s].Spec()
+	}
+
+	checkSubimages := func(outfile string) {
+		check, err := NewImageBufPath(outfile)
+		checkFatalError(t, err)
+
+		if check.NumSubImages() != nsubimages {
+			t.Fatalf("Expected %d subimages to be written; got %d", nsubimages, check.NumSubImages())
+		}
+
+		for s := 0; s < nsubimages; s++ {
+			sub, err := NewImageBufSubImage(outfile, s, 0, nil)
+			checkFatalError(t, err)
+			if sub.NumChannels() != bufs[s].NumChannels() {
+				t.Errorf("Expected subimage %d to have %d channels; got %d",
+					s, bufs[s].NumChannels(), sub.NumChannels())
+			}
+		}
+	}
+
+	// Appending one subimage at a time
+	//
+	outfile := createOutputFileExt("exr")
+	defer os.Remove(outfile)
+
+	out, err := OpenImageOutput(outfile)
+	checkFatalError(t, err)
+
+	if !out.Supports("appendsubimage") {
+		t.Fatalf("Expected format %q to support appendsubimage", out.FormatName())
+	}
+
+	for s, buf := range bufs {
+		mode := OpenModeAppendSubimage
+		if s == 0 {
+			mode = OpenModeCreate
+		}
+		checkFatalError(t, out.Open(outfile, specs[s], mode))
+		checkFatalError(t, buf.WriteImageOutput(out))
+	}
+	checkFatalError(t, out.Close())
+
+	checkSubimages(outfile)
+
+	// Declaring all subimages up front
+	//
+	multifile := createOutputFileExt("exr")
+	defer os.Remove(multifile)
+
+	out, err = OpenImageOutput(multifile)
+	checkFatalError(t, err)
+
+	checkFatalError(t, out.OpenMulti(multifile, specs))
+	for s, buf := range bufs {
+		if s > 0 {
+			checkFatalError(t, out.Open(multifile, specs[s], OpenModeAppendSubimage))
+		}
+		checkFatalError(t, buf.WriteImageOutput(out))
+	}
+	checkFatalError(t, out.Close())
+
+	checkSubimages(multifile)
+}
@@ -9,6 +9,7 @@ package oiio
 import "C"
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"unsafe"
@@ -58,6 +59,95 @@ func flatAlgoOpts(opts []AlgoOpts) AlgoOpts {
 	return opt
 }
 
+// The number of scanlines of dst that are computed at a time by
+// the context-aware algorithms, between checks for cancellation.
+const contextBand = 64
+
+// Apply fn to the ROI of dst in bands of scanlines, stopping with the
+// error of ctx once it is cancelled. The ROI defaults to all of dst, if
+// dst is initialized, or all of src.
+//
+// The bands are computed into a scratch ImageBuf, which starts out as a
+// copy of dst, or is allocated to the ROI with the data format of src if
+// dst is not initialized. dst only receives the result once every band is
+// done, so that it is left unchanged if ctx is cancelled part way through.
+func runContext(ctx context.Context, dst, src *ImageBuf, opts []AlgoOpts, fn func(*ImageBuf, AlgoOpts) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	opt := flatAlgoOpts(opts)
+
+	roi := opt.ROI
+	if roi == nil || roi.ptr == nil || !roi.Defined() {
+		if dst.Initialized() {
+			roi = dst.ROI()
+		} else {
+			roi = src.ROI()
+		}
+	}
+
+	scratch := NewImageBuf()
+	if dst.Initialized() {
+		if err := scratch.Copy(dst); err != nil {
+			return err
+		}
+	} else if err := allocContextDst(scratch, src, roi); err != nil {
+		return err
+	}
+
+	for y := roi.YBegin(); y < roi.YEnd(); y += contextBand {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		band := roi.Copy()
+		band.SetYBegin(y)
+		band.SetYEnd(minInt(y+contextBand, roi.YEnd()))
+
+		if err := fn(scratch, AlgoOpts{ROI: band, Threads: opt.Threads}); err != nil {
+			return err
+		}
+	}
+
+	// Copy into an initialized dst, which may wrap a PixelBuffer
+	if dst.Initialized() {
+		return dst.Copy(scratch)
+	}
+	return dst.Swap(scratch)
+}
+
+// Allocate dst to the ROI, with the data format and channels of src.
+func allocContextDst(dst, src *ImageBuf, roi *ROI) error {
+	srcSpec := src.Spec()
+
+	spec := NewImageSpecSize(roi.Width(), roi.Height(), roi.NumChannels(), srcSpec.Format())
+	spec.SetX(roi.XBegin())
+	spec.SetY(roi.YBegin())
+	spec.SetZ(roi.ZBegin())
+	spec.SetDepth(roi.Depth())
+	spec.SetFullX(roi.XBegin())
+	spec.SetFullY(roi.YBegin())
+	spec.SetFullZ(roi.ZBegin())
+	spec.SetFullWidth(roi.Width())
+	spec.SetFullHeight(roi.Height())
+	spec.SetFullDepth(roi.Depth())
+
+	if roi.ChannelsBegin() == 0 && roi.NumChannels() == srcSpec.NumChannels() {
+		if err := spec.SetChannelNames(srcSpec.ChannelNames()); err != nil {
+			return err
+		}
+		spec.SetAlphaChannel(srcSpec.AlphaChannel())
+		spec.SetZChannel(srcSpec.ZChannel())
+	}
+
+	buf, err := NewImageBufSpec(spec)
+	if err != nil {
+		return err
+	}
+	return dst.Swap(buf)
+}
+
 // Zero out (set to 0, black) the image region.
 // Only the pixels (and channels) in dst that are specified by roi will be altered;
 // the default roi is to alter all the pixels in dst.
@@ -105,7 +195,7 @@ func Fill(dst *ImageBuf, values []float32, opts ...AlgoOpts) error {
 // Example:
 //     // Create a new 640x480 RGB image, fill it with a two-toned gray
 //     // checkerboard, the checkers being 64x64 pixels each.
-//     spec := NewImageSpecSize(640, 480, 3, TypeFloat)
+//     spec := NewImageSpecSize(640, 480, 3, TypeFloat())
 //     dark := []float32{0.1, 0.1, 0.1}
 //     light := []float32{0.4, 0.4, 0.4}
 //     Checker(spec, 64, 64, dark, light, 0, 0)
@@ -235,21 +325,79 @@ func ChannelAppend(dst, a, b *ImageBuf, opts ...AlgoOpts) error {
 	return nil
 }
 
-// TODO: Flatten does not seem to work as expected
-//
 // Flatten copies pixels from deep image src into non-deep dst, compositing the depth samples
 // within each pixel to yield a single “flat” value per pixel. If src is not deep, it just copies
 // the pixels without alteration.
-// func Flatten(dst, src *ImageBuf, opts ...AlgoOpts) error {
-// 	opt := flatAlgoOpts(opts)
-//
-// 	ok := C.flatten(dst.ptr, src.ptr, opt.ROI.validOrAllPtr(), C.int(opt.Threads))
-// 	if !bool(ok) {
-// 		return dst.LastError()
-// 	}
-//
-// 	return nil
-// }
+func Flatten(dst, src *ImageBuf, opts ...AlgoOpts) error {
+	opt := flatAlgoOpts(opts)
+
+	ok := C.flatten(dst.ptr, src.ptr, opt.ROI.validOrAllPtr(), C.int(opt.Threads))
+	if !bool(ok) {
+		return dst.LastError()
+	}
+
+	return nil
+}
+
+// Deepen copies pixels from non-deep image src into deep dst. Pixels with a nonzero
+// value in any channel become a single deep sample, and pixels that are zero in all
+// channels have no samples. If src has no Z channel, the sample depth is set to zValue.
+// If src is already deep, it just copies the pixels without alteration.
+// Requires OpenImageIO 1.7 or newer.
+func Deepen(dst, src *ImageBuf, zValue float32, opts ...AlgoOpts) error {
+	if err := checkDeepData(); err != nil {
+		return err
+	}
+
+	opt := flatAlgoOpts(opts)
+
+	ok := C.deepen(dst.ptr, src.ptr, C.float(zValue), opt.ROI.validOrAllPtr(), C.int(opt.Threads))
+	if !bool(ok) {
+		return dst.LastError()
+	}
+
+	return nil
+}
+
+// DeepMerge sets dst to the merged samples of deep images A and B, sorted by depth.
+// A and B must have the same channels.
+// If occlusionCull is true, any samples that lie behind a fully opaque sample
+// are discarded.
+// Requires OpenImageIO 1.7 or newer.
+func DeepMerge(dst, a, b *ImageBuf, occlusionCull bool, opts ...AlgoOpts) error {
+	if err := checkDeepData(); err != nil {
+		return err
+	}
+
+	opt := flatAlgoOpts(opts)
+
+	ok := C.deep_merge(dst.ptr, a.ptr, b.ptr, C.bool(occlusionCull),
+		opt.ROI.validOrAllPtr(), C.int(opt.Threads))
+	if !bool(ok) {
+		return dst.LastError()
+	}
+
+	return nil
+}
+
+// DeepHoldout sets dst to the samples of deep image src that are closer
+// than the opaque frontier of deep image holdout, so that only the parts
+// of src that are not occluded by holdout remain.
+// Requires OpenImageIO 1.8 or newer.
+func DeepHoldout(dst, src, holdout *ImageBuf, opts ...AlgoOpts) error {
+	if !bool(C.deep_holdout_supported()) {
+		return errors.New("DeepHoldout requires OpenImageIO 1.8 or newer")
+	}
+
+	opt := flatAlgoOpts(opts)
+
+	ok := C.deep_holdout(dst.ptr, src.ptr, holdout.ptr, opt.ROI.validOrAllPtr(), C.int(opt.Threads))
+	if !bool(ok) {
+		return dst.LastError()
+	}
+
+	return nil
+}
 
 // Crop resets dst to be the specified region of src.
 // Note that the crop operation does not actually move the pixels on the image plane or
@@ -518,6 +666,17 @@ func ColorConvert(dst, src *ImageBuf, from, to string, unpremult bool, opts ...A
 	return nil
 }
 
+// ColorConvertContext is ColorConvert, computing dst in bands of scanlines,
+// and stopping with the error of ctx once it is cancelled. dst is left
+// unchanged if ctx is cancelled.
+func ColorConvertContext(ctx context.Context, dst, src *ImageBuf, from, to string, unpremult bool,
+	opts ...AlgoOpts) error {
+
+	return runContext(ctx, dst, src, opts, func(dst *ImageBuf, opt AlgoOpts) error {
+		return ColorConvert(dst, src, from, to, unpremult, opt)
+	})
+}
+
 // Copy pixels within the ROI from src to dst, applying a color transform.
 // If dst is not yet initialized, it will be allocated to the same size as specified by roi.
 // If roi is not defined it will be all of dst, if dst is defined, or all of src, if dst is not yet defined.
@@ -537,6 +696,16 @@ func ColorConvertProcessor(dst, src *ImageBuf, cp *ColorProcessor, unpremult boo
 	return nil
 }
 
+// ColorConvertProcessorContext is ColorConvertProcessor, computing dst in
+// bands of scanlines, and stopping with the error of ctx once it is cancelled.
+func ColorConvertProcessorContext(ctx context.Context, dst, src *ImageBuf, cp *ColorProcessor,
+	unpremult bool, opts ...AlgoOpts) error {
+
+	return runContext(ctx, dst, src, opts, func(dst *ImageBuf, opt AlgoOpts) error {
+		return ColorConvertProcessor(dst, src, cp, unpremult, opt)
+	})
+}
+
 // Premult copies pixels from src to dst, and in the process multiply all color channels (those not
 // alpha or z) by the alpha value, to “premultiply” them. This presumes that the image starts
 // of as “unassociated alpha” a.k.a. “non-premultipled.” The alterations are restricted to the
@@ -674,6 +843,15 @@ func Resize(dst, src *ImageBuf, opts ...AlgoOpts) error {
 	return nil
 }
 
+// ResizeContext is Resize, computing dst in bands of scanlines, and
+// stopping with the error of ctx once it is cancelled. dst is left
+// unchanged if ctx is cancelled.
+func ResizeContext(ctx context.Context, dst, src *ImageBuf, opts ...AlgoOpts) error {
+	return runContext(ctx, dst, src, opts, func(dst *ImageBuf, opt AlgoOpts) error {
+		return Resize(dst, src, opt)
+	})
+}
+
 // Set dst, over the region of interest, to be a resized version of the
 // corresponding portion of src (mapping such that the "full" image
 // window of each correspond to each other, regardless of resolution).
@@ -703,6 +881,16 @@ func ResizeFilter(dst, src *ImageBuf, filter string, filterWidth float32, opts .
 	return nil
 }
 
+// ResizeFilterContext is ResizeFilter, computing dst in bands of scanlines,
+// and stopping with the error of ctx once it is cancelled.
+func ResizeFilterContext(ctx context.Context, dst, src *ImageBuf, filter string, filterWidth float32,
+	opts ...AlgoOpts) error {
+
+	return runContext(ctx, dst, src, opts, func(dst *ImageBuf, opt AlgoOpts) error {
+		return ResizeFilter(dst, src, filter, filterWidth, opt)
+	})
+}
+
 // Set dst, over the region of interest, to be a resampled version of the corresponding portion of src
 // (mapping such that the "full" image window of each correspond to each other, regardless of resolution).
 // Unlike Resize(), Resample does not take a filter; it just samples either with a bilinear
@@ -720,6 +908,14 @@ func Resample(dst, src *ImageBuf, interpolate bool, opts ...AlgoOpts) error {
 	return nil
 }
 
+// ResampleContext is Resample, computing dst in bands of scanlines,
+// and stopping with the error of ctx once it is cancelled.
+func ResampleContext(ctx context.Context, dst, src *ImageBuf, interpolate bool, opts ...AlgoOpts) error {
+	return runContext(ctx, dst, src, opts, func(dst *ImageBuf, opt AlgoOpts) error {
+		return Resample(dst, src, interpolate, opt)
+	})
+}
+
 // Over sets dst to the composite of A over B using the Porter/Duff definition
 // of "over", returning true upon success and false for any of a
 // variety of failures (as described below).
@@ -1,6 +1,7 @@
 package oiio
 
 import (
+	"context"
 	"reflect"
 	"testing"
 )
@@ -23,7 +24,7 @@ func TestAlgoZero(t *testing.T) {
 }
 
 func TestAlgoFill(t *testing.T) {
-	spec := NewImageSpecSize(32, 32, 3, TypeFloat)
+	spec := NewImageSpecSize(32, 32, 3, TypeFloat())
 	buf, err := NewImageBufSpec(spec)
 	if err != nil {
 		t.Fatal(err.Error())
@@ -34,7 +35,7 @@ func TestAlgoFill(t *testing.T) {
 
 	roi := NewROIRegion2D(0, 1, 0, 1)
 	roi.SetChannelsEnd(3)
-	iface, err := buf.GetPixelRegion(roi, TypeFloat)
+	iface, err := buf.GetPixelRegion(roi, TypeFloat())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -46,7 +47,7 @@ func TestAlgoFill(t *testing.T) {
 }
 
 func TestAlgoChecker(t *testing.T) {
-	spec := NewImageSpecSize(16, 16, 3, TypeFloat)
+	spec := NewImageSpecSize(16, 16, 3, TypeFloat())
 	buf, err := NewImageBufSpec(spec)
 	if err != nil {
 		t.Fatal(err.Error())
@@ -59,7 +60,7 @@ func TestAlgoChecker(t *testing.T) {
 
 	roi := NewROIRegion2D(0, 1, 0, 1)
 	roi.SetChannelsEnd(3)
-	iface, err := buf.GetPixelRegion(roi, TypeFloat)
+	iface, err := buf.GetPixelRegion(roi, TypeFloat())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -71,7 +72,7 @@ func TestAlgoChecker(t *testing.T) {
 
 	roi = NewROIRegion2D(14, 15, 0, 1)
 	roi.SetChannelsEnd(3)
-	iface, err = buf.GetPixelRegion(roi, TypeFloat)
+	iface, err = buf.GetPixelRegion(roi, TypeFloat())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -84,7 +85,7 @@ func TestAlgoChecker(t *testing.T) {
 
 func TestAlgoChannels(t *testing.T) {
 	// Create a source image
-	src, err := NewImageBufSpec(NewImageSpecSize(32, 32, 4, TypeFloat))
+	src, err := NewImageBufSpec(NewImageSpecSize(32, 32, 4, TypeFloat()))
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -108,7 +109,7 @@ func TestAlgoChannels(t *testing.T) {
 	roi := NewROIRegion2D(0, 1, 0, 1)
 	roi.SetChannelsEnd(3)
 
-	iface, err := dst.GetPixelRegion(roi, TypeFloat)
+	iface, err := dst.GetPixelRegion(roi, TypeFloat())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -135,7 +136,7 @@ func TestAlgoChannels(t *testing.T) {
 		t.Fatal(err.Error())
 	}
 
-	iface, err = dst.GetPixelRegion(roi, TypeFloat)
+	iface, err = dst.GetPixelRegion(roi, TypeFloat())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -154,7 +155,7 @@ func TestAlgoChannels(t *testing.T) {
 	checkFatalError(t, Channels(dst, src, 4, &opts))
 
 	roi.SetChannelsEnd(4)
-	iface, err = dst.GetPixelRegion(roi, TypeFloat)
+	iface, err = dst.GetPixelRegion(roi, TypeFloat())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -193,7 +194,7 @@ func TestAlgoChannels(t *testing.T) {
 
 func TestAlgoChannelAppend(t *testing.T) {
 	// Create a source image
-	rgb, err := NewImageBufSpec(NewImageSpecSize(32, 32, 3, TypeFloat))
+	rgb, err := NewImageBufSpec(NewImageSpecSize(32, 32, 3, TypeFloat()))
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -203,7 +204,7 @@ func TestAlgoChannelAppend(t *testing.T) {
 		t.Fatal(err.Error())
 	}
 
-	a, err := NewImageBufSpec(NewImageSpecSize(32, 32, 1, TypeFloat))
+	a, err := NewImageBufSpec(NewImageSpecSize(32, 32, 1, TypeFloat()))
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -229,37 +230,113 @@ func TestAlgoChannelAppend(t *testing.T) {
 	}
 }
 
-// TODO: Flatten does not seem to work as expected
-//
-// func TestAlgoFlatten(t *testing.T) {
-// 	// Create a source image
-// 	src, err := NewImageBufSpec(NewImageSpecSize(32, 32, 1, TypeFloat))
-// 	if err != nil {
-// 		t.Fatal(err.Error())
-// 	}
-//
-// 	fill := []float32{0.5}
-// 	if err = Fill(src, fill); err != nil {
-// 		t.Fatal(err.Error())
-// 	}
-//
-// 	dst, _ := NewImageBufSpec(src.Spec())
-// 	if err = Flatten(dst, src); err != nil {
-// 		t.Fatal(err.Error())
-// 	}
-//
-// 	// Check that the pixels for the region are correct
-// 	firstPixel := NewROIRegion2D(0, 1, 0, 1)
-// 	firstPixel.SetChannelsEnd(1)
-// 	iface, err := dst.GetPixelRegion(firstPixel, TypeFloat)
-// 	if err != nil {
-// 		t.Fatal(err.Error())
-// 	}
-// 	pixels := iface.([]float32)
-// 	if !reflect.DeepEqual(pixels, fill[:1]) {
-// 		t.Errorf("Expected pixels %v;  got %v", fill[:1], pixels)
-// 	}
-// }
+// Create a deep RGBAZ ImageBuf where every pixel has a sample at each of
+// the given depths, with the given alpha and premultiplied 0.5 color.
+func newDeepTestBuf(t *testing.T, alpha float32, depths ...float32) *ImageBuf {
+	buf, err := NewImageBufSpec(newDeepTestSpec())
+	checkFatalError(t, err)
+
+	for y := buf.YBegin(); y < buf.YEnd(); y++ {
+		for x := buf.XBegin(); x < buf.XEnd(); x++ {
+			buf.SetDeepSamples(x, y, 0, len(depths))
+			for s, z := range depths {
+				for c := 0; c < 3; c++ {
+					buf.SetDeepValue(x, y, 0, c, s, 0.5*alpha)
+				}
+				buf.SetDeepValue(x, y, 0, 3, s, alpha)
+				buf.SetDeepValue(x, y, 0, 4, s, z)
+			}
+		}
+	}
+	return buf
+}
+
+func TestAlgoFlatten(t *testing.T) {
+	src := newDeepTestBuf(t, 1, 2)
+
+	dst := NewImageBuf()
+	checkFatalError(t, Flatten(dst, src))
+
+	if dst.Deep() {
+		t.Fatal("Expected flattened ImageBuf not to be deep")
+	}
+
+	roi := NewROIRegion2D(0, 1, 0, 1)
+	roi.SetChannelsEnd(4)
+	iface, err := dst.GetPixelRegion(roi, TypeFloat())
+	checkFatalError(t, err)
+
+	expected := []float32{0.5, 0.5, 0.5, 1}
+	actual := iface.([]float32)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Expected pixels %v; Got %v", expected, actual)
+	}
+}
+
+func TestAlgoDeepen(t *testing.T) {
+	src, err := NewImageBufSpec(NewImageSpecSize(4, 2, 4, TypeFloat()))
+	checkFatalError(t, err)
+	checkFatalError(t, Fill(src, []float32{0.5, 0.5, 0.5, 1}))
+
+	dst := NewImageBuf()
+	checkFatalError(t, Deepen(dst, src, 3))
+
+	if !dst.Deep() {
+		t.Fatal("Expected deepened ImageBuf to be deep")
+	}
+
+	if n := dst.DeepSamples(0, 0, 0); n != 1 {
+		t.Fatalf("Expected 1 deep sample; got %d", n)
+	}
+
+	zchan := dst.Spec().ZChannel()
+	if zchan < 0 {
+		t.Fatal("Expected deepened ImageBuf to have a Z channel")
+	}
+	if z := dst.DeepValue(0, 0, 0, zchan, 0); z != 3 {
+		t.Errorf("Expected sample depth 3; got %v", z)
+	}
+}
+
+func TestAlgoDeepMerge(t *testing.T) {
+	a := newDeepTestBuf(t, 1, 1)
+	b := newDeepTestBuf(t, 1, 2)
+
+	dst := NewImageBuf()
+	checkFatalError(t, DeepMerge(dst, a, b, false))
+
+	if n := dst.DeepSamples(0, 0, 0); n != 2 {
+		t.Fatalf("Expected 2 merged samples without occlusion culling; got %d", n)
+	}
+	if z := dst.DeepValue(0, 0, 0, 4, 0); z != 1 {
+		t.Errorf("Expected nearest sample depth 1; got %v", z)
+	}
+
+	dst = NewImageBuf()
+	checkFatalError(t, DeepMerge(dst, b, a, true))
+
+	if n := dst.DeepSamples(0, 0, 0); n != 1 {
+		t.Fatalf("Expected 1 merged sample with occlusion culling; got %d", n)
+	}
+	if z := dst.DeepValue(0, 0, 0, 4, 0); z != 1 {
+		t.Errorf("Expected remaining sample depth 1; got %v", z)
+	}
+}
+
+func TestAlgoDeepHoldout(t *testing.T) {
+	src := newDeepTestBuf(t, 0.5, 1, 3)
+	holdout := newDeepTestBuf(t, 1, 2)
+
+	dst := NewImageBuf()
+	checkFatalError(t, DeepHoldout(dst, src, holdout))
+
+	if n := dst.DeepSamples(0, 0, 0); n != 1 {
+		t.Fatalf("Expected 1 sample in front of the holdout; got %d", n)
+	}
+	if z := dst.DeepValue(0, 0, 0, 4, 0); z != 1 {
+		t.Errorf("Expected remaining sample depth 1; got %v", z)
+	}
+}
 
 func TestAlgoCrop(t *testing.T) {
 	src, err := NewImageBufPath(TEST_IMAGE)
@@ -325,7 +402,7 @@ func TestAlgoCut(t *testing.T) {
 }
 
 func TestAlgoFlipFlop(t *testing.T) {
-	spec := NewImageSpecSize(16, 16, 3, TypeFloat)
+	spec := NewImageSpecSize(16, 16, 3, TypeFloat())
 	buf, err := NewImageBufSpec(spec)
 	if err != nil {
 		t.Fatal(err.Error())
@@ -343,7 +420,7 @@ func TestAlgoFlipFlop(t *testing.T) {
 
 	roi := NewROIRegion2D(0, 1, 0, 1)
 	roi.SetChannelsEnd(3)
-	iface, err := dst.GetPixelRegion(roi, TypeFloat)
+	iface, err := dst.GetPixelRegion(roi, TypeFloat())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -356,7 +433,7 @@ func TestAlgoFlipFlop(t *testing.T) {
 	// Flop
 	checkFatalError(t, Flop(dst, buf))
 
-	iface, err = dst.GetPixelRegion(roi, TypeFloat)
+	iface, err = dst.GetPixelRegion(roi, TypeFloat())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -368,7 +445,7 @@ func TestAlgoFlipFlop(t *testing.T) {
 	// Flopflop
 	checkFatalError(t, Flipflop(dst, buf))
 
-	iface, err = dst.GetPixelRegion(roi, TypeFloat)
+	iface, err = dst.GetPixelRegion(roi, TypeFloat())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -380,7 +457,7 @@ func TestAlgoFlipFlop(t *testing.T) {
 }
 
 func TestAlgoTranspose(t *testing.T) {
-	spec := NewImageSpecSize(2, 2, 1, TypeFloat)
+	spec := NewImageSpecSize(2, 2, 1, TypeFloat())
 	buf, err := NewImageBufSpec(spec)
 	if err != nil {
 		t.Fatal(err.Error())
@@ -408,7 +485,7 @@ func TestAlgoTranspose(t *testing.T) {
 }
 
 func TestAlgoColorAdd(t *testing.T) {
-	buf, err := NewImageBufSpec(NewImageSpecSize(1, 1, 3, TypeFloat))
+	buf, err := NewImageBufSpec(NewImageSpecSize(1, 1, 3, TypeFloat()))
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -432,7 +509,7 @@ func TestAlgoColorAdd(t *testing.T) {
 	}
 
 	reset()
-	b, _ := NewImageBufSpec(NewImageSpecSize(1, 1, 3, TypeFloat))
+	b, _ := NewImageBufSpec(NewImageSpecSize(1, 1, 3, TypeFloat()))
 	Fill(b, []float32{.2, .2, .2})
 	checkFatalError(t, Add(dst, buf, b))
 	actual, _ = dst.GetFloatPixels()
@@ -443,7 +520,7 @@ func TestAlgoColorAdd(t *testing.T) {
 }
 
 func TestAlgoColorSub(t *testing.T) {
-	buf, err := NewImageBufSpec(NewImageSpecSize(1, 1, 3, TypeFloat))
+	buf, err := NewImageBufSpec(NewImageSpecSize(1, 1, 3, TypeFloat()))
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -467,7 +544,7 @@ func TestAlgoColorSub(t *testing.T) {
 	}
 
 	reset()
-	b, _ := NewImageBufSpec(NewImageSpecSize(1, 1, 3, TypeFloat))
+	b, _ := NewImageBufSpec(NewImageSpecSize(1, 1, 3, TypeFloat()))
 	Fill(b, []float32{.2, .2, .2})
 	checkFatalError(t, Sub(dst, buf, b))
 	actual, _ = dst.GetFloatPixels()
@@ -478,7 +555,7 @@ func TestAlgoColorSub(t *testing.T) {
 }
 
 func TestAlgoColorMul(t *testing.T) {
-	buf, err := NewImageBufSpec(NewImageSpecSize(1, 1, 3, TypeFloat))
+	buf, err := NewImageBufSpec(NewImageSpecSize(1, 1, 3, TypeFloat()))
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -502,7 +579,7 @@ func TestAlgoColorMul(t *testing.T) {
 	}
 
 	reset()
-	b, _ := NewImageBufSpec(NewImageSpecSize(1, 1, 3, TypeFloat))
+	b, _ := NewImageBufSpec(NewImageSpecSize(1, 1, 3, TypeFloat()))
 	Fill(b, []float32{.5, .25, 1})
 	checkFatalError(t, Mul(dst, buf, b))
 	actual, _ = dst.GetFloatPixels()
@@ -537,7 +614,7 @@ func TestAlgoColorConvert(t *testing.T) {
 }
 
 func TestAlgoPremult(t *testing.T) {
-	buf, err := NewImageBufSpec(NewImageSpecSize(1, 1, 4, TypeFloat))
+	buf, err := NewImageBufSpec(NewImageSpecSize(1, 1, 4, TypeFloat()))
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -561,7 +638,7 @@ func TestAlgoPremult(t *testing.T) {
 }
 
 func TestAlgoConstantColor(t *testing.T) {
-	buf, err := NewImageBufSpec(NewImageSpecSize(16, 16, 3, TypeFloat))
+	buf, err := NewImageBufSpec(NewImageSpecSize(16, 16, 3, TypeFloat()))
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -586,7 +663,7 @@ func TestAlgoConstantColor(t *testing.T) {
 }
 
 func TestAlgoIsConstantChannel(t *testing.T) {
-	buf, err := NewImageBufSpec(NewImageSpecSize(16, 16, 3, TypeFloat))
+	buf, err := NewImageBufSpec(NewImageSpecSize(16, 16, 3, TypeFloat()))
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -627,7 +704,7 @@ func TestAlgoIsConstantChannel(t *testing.T) {
 }
 
 func TestAlgoIsMonochrome(t *testing.T) {
-	buf, err := NewImageBufSpec(NewImageSpecSize(16, 16, 3, TypeFloat))
+	buf, err := NewImageBufSpec(NewImageSpecSize(16, 16, 3, TypeFloat()))
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -689,6 +766,118 @@ func TestAlgoResize(t *testing.T) {
 	}
 }
 
+func TestAlgoResizeContext(t *testing.T) {
+	src, err := NewImageBufPath(TEST_IMAGE)
+	checkFatalError(t, err)
+
+	// Larger than a single band
+	roi := NewROIRegion2D(0, 200, 0, 150)
+
+	expected := NewImageBuf()
+	checkFatalError(t, Resize(expected, src, AlgoOpts{ROI: roi}))
+
+	dst := NewImageBuf()
+	checkFatalError(t, ResizeContext(context.Background(), dst, src, AlgoOpts{ROI: roi}))
+
+	if dst.XEnd() != 200 || dst.YEnd() != 150 || dst.NumChannels() != src.NumChannels() {
+		t.Fatalf("Expected dst of 200x150x%d; got %dx%dx%d",
+			src.NumChannels(), dst.XEnd(), dst.YEnd(), dst.NumChannels())
+	}
+
+	expectedPixels, err := expected.GetFloatPixels()
+	checkFatalError(t, err)
+	actualPixels, err := dst.GetFloatPixels()
+	checkFatalError(t, err)
+	if !reflect.DeepEqual(expectedPixels, actualPixels) {
+		t.Error("Expected ResizeContext to match Resize")
+	}
+
+	checkFatalError(t, ResizeFilterContext(context.Background(), dst, src, "lanczos3", 1.0))
+	checkFatalError(t, ResampleContext(context.Background(), dst, src, true))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dst = NewImageBuf()
+	if err = ResizeContext(ctx, dst, src, AlgoOpts{ROI: roi}); err != context.Canceled {
+		t.Errorf("Expected error %v; got %v", context.Canceled, err)
+	}
+	if dst.Initialized() {
+		t.Error("Expected dst to be untouched when the context is already cancelled")
+	}
+
+	// Cancelled after the first band
+	if err = ResizeContext(newCancelAfterContext(1), dst, src, AlgoOpts{ROI: roi}); err != context.Canceled {
+		t.Errorf("Expected error %v; got %v", context.Canceled, err)
+	}
+	if dst.Initialized() {
+		t.Error("Expected dst to be untouched when the context is cancelled part way through")
+	}
+}
+
+// A context that is cancelled once its error has been checked after
+// the given number of bands of a context-aware algorithm.
+type cancelAfterContext struct {
+	context.Context
+	checks int
+}
+
+func newCancelAfterContext(bands int) *cancelAfterContext {
+	// The context is also checked once before the first band
+	return &cancelAfterContext{Context: context.Background(), checks: bands + 1}
+}
+
+func (c *cancelAfterContext) Err() error {
+	if c.checks <= 0 {
+		return context.Canceled
+	}
+	c.checks--
+	return nil
+}
+
+func TestAlgoColorConvertContext(t *testing.T) {
+	src, err := NewImageBufPath(TEST_IMAGE)
+	checkFatalError(t, err)
+
+	expected := NewImageBuf()
+	checkFatalError(t, ColorConvert(expected, src, "lnf", "srgb8", false))
+
+	dst := NewImageBuf()
+	checkFatalError(t, ColorConvertContext(context.Background(), dst, src, "lnf", "srgb8", false))
+
+	expectedPixels, err := expected.GetFloatPixels()
+	checkFatalError(t, err)
+	actualPixels, err := dst.GetFloatPixels()
+	checkFatalError(t, err)
+	if !reflect.DeepEqual(expectedPixels, actualPixels) {
+		t.Error("Expected ColorConvertContext to match ColorConvert")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err = ColorConvertContext(ctx, dst, src, "lnf", "srgb8", false); err != context.Canceled {
+		t.Errorf("Expected error %v; got %v", context.Canceled, err)
+	}
+
+	// Cancelled after the first band, of an image of two bands
+	tall, err := NewImageBufSpec(NewImageSpecSize(4, 2*contextBand, 3, TypeFloat()))
+	checkFatalError(t, err)
+	checkFatalError(t, Zero(tall))
+
+	dst = NewImageBuf()
+	checkFatalError(t, dst.Copy(tall))
+	checkFatalError(t, dst.SetPixel(0, 0, 0, []float32{0.5, 0.5, 0.5}))
+
+	err = ColorConvertContext(newCancelAfterContext(1), dst, tall, "lnf", "srgb8", false)
+	if err != context.Canceled {
+		t.Errorf("Expected error %v; got %v", context.Canceled, err)
+	}
+	if p := dst.GetPixel(0, 0, 0); !reflect.DeepEqual(p, []float32{0.5, 0.5, 0.5}) {
+		t.Errorf("Expected dst to be unchanged when the context is cancelled part way through; got %v", p)
+	}
+}
+
 func TestAlgoResample(t *testing.T) {
 	src, err := NewImageBufPath(TEST_IMAGE)
 	if err != nil {
@@ -705,7 +894,7 @@ func TestAlgoResample(t *testing.T) {
 }
 
 func TestAlgoOver(t *testing.T) {
-	srcSpec := NewImageSpecSize(16, 16, 4, TypeFloat)
+	srcSpec := NewImageSpecSize(16, 16, 4, TypeFloat())
 	srcSpec.SetAlphaChannel(3)
 
 	srcA, err := NewImageBufSpec(srcSpec)
@@ -734,7 +923,7 @@ func TestAlgoOver(t *testing.T) {
 	// Top left pixel
 	roi := NewROIRegion2D(0, 1, 0, 1)
 	roi.SetChannelsEnd(3)
-	topIface, err := dst.GetPixelRegion(roi, TypeFloat)
+	topIface, err := dst.GetPixelRegion(roi, TypeFloat())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -747,7 +936,7 @@ func TestAlgoOver(t *testing.T) {
 }
 
 func ExampleOver() {
-	srcSpec := NewImageSpecSize(16, 16, 4, TypeFloat)
+	srcSpec := NewImageSpecSize(16, 16, 4, TypeFloat())
 	srcSpec.SetAlphaChannel(3)
 
 	srcA, _ := NewImageBufSpec(srcSpec)
@@ -766,7 +955,7 @@ func ExampleOver() {
 }
 
 func TestAlgoPaste2D(t *testing.T) {
-	srcSpec := NewImageSpecSize(16, 16, 3, TypeFloat)
+	srcSpec := NewImageSpecSize(16, 16, 3, TypeFloat())
 	src, err := NewImageBufSpec(srcSpec)
 	if err != nil {
 		t.Fatal(err.Error())
@@ -776,7 +965,7 @@ func TestAlgoPaste2D(t *testing.T) {
 	checkFatalError(t, Fill(src, topExpected))
 
 	// Destination is bigger than source
-	dstSpec := NewImageSpecSize(32, 32, 3, TypeFloat)
+	dstSpec := NewImageSpecSize(32, 32, 3, TypeFloat())
 	dst, err := NewImageBufSpec(dstSpec)
 	if err != nil {
 		t.Fatal(err.Error())
@@ -791,7 +980,7 @@ func TestAlgoPaste2D(t *testing.T) {
 	// Top left pixel
 	roi := NewROIRegion2D(0, 1, 0, 1)
 	roi.SetChannelsEnd(3)
-	topIface, err := dst.GetPixelRegion(roi, TypeFloat)
+	topIface, err := dst.GetPixelRegion(roi, TypeFloat())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -799,7 +988,7 @@ func TestAlgoPaste2D(t *testing.T) {
 	// Bottom right pixel
 	roi = NewROIRegion2D(31, 32, 31, 32)
 	roi.SetChannelsEnd(3)
-	bottomIface, err := dst.GetPixelRegion(roi, TypeFloat)
+	bottomIface, err := dst.GetPixelRegion(roi, TypeFloat())
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -817,7 +1006,7 @@ func TestAlgoPaste2D(t *testing.T) {
 }
 
 func TestAlgoRenderText(t *testing.T) {
-	spec := NewImageSpecSize(256, 256, 3, TypeFloat)
+	spec := NewImageSpecSize(256, 256, 3, TypeFloat())
 	buf, err := NewImageBufSpec(spec)
 	if err != nil {
 		t.Fatal(err.Error())
@@ -10,38 +10,472 @@ import "C"
 
 import (
 	"errors"
+	"fmt"
+	"runtime"
+	"sync"
 	"unsafe"
 )
 
 // Define an API to an abstract class that manages image files, caches of open
 // file handles as well as tiles of pixels so that truly huge amounts of image
 // data may be accessed by an application with low memory footprint.
+//
+// An ImageCache is a handle to an underlying cache, which may be shared by
+// several handles. The cache counts the handles that refer to it, including
+// those held by ImageBufs and Tiles that use it, and is only destroyed once
+// all of them have been released.
 type ImageCache struct {
-	ptr unsafe.Pointer
+	mu     sync.Mutex
+	ptr    unsafe.Pointer
+	shared bool
+}
+
+// References to the underlying caches of ImageCache handles,
+// keyed by the pointer of the cache.
+var imageCacheRefs = struct {
+	sync.Mutex
+	refs map[unsafe.Pointer]*imageCacheRef
+}{refs: make(map[unsafe.Pointer]*imageCacheRef)}
+
+type imageCacheRef struct {
+	count    int
+	shared   bool
+	teardown bool
+
+	// The most memory that Stats has seen the cache use
+	memoryPeak int64
+}
+
+// Create a new handle that holds a reference to the cache at i.
+func newImageCache(i unsafe.Pointer, shared bool) *ImageCache {
+	imageCacheRefs.Lock()
+	ref, ok := imageCacheRefs.refs[i]
+	if !ok {
+		ref = &imageCacheRef{shared: shared}
+		imageCacheRefs.refs[i] = ref
+	}
+	ref.count++
+	imageCacheRefs.Unlock()
+
+	cache := &ImageCache{ptr: i, shared: ref.shared}
+	runtime.SetFinalizer(cache, deleteImageCache)
+	return cache
+}
+
+func deleteImageCache(i *ImageCache) {
+	i.Destroy(false)
+}
+
+// Drop a reference to the cache at ptr. Once the last reference is
+// dropped, a private cache is destroyed, and a shared cache is torn
+// down if any of its handles asked for it.
+func releaseImageCache(ptr unsafe.Pointer, teardown bool) {
+	imageCacheRefs.Lock()
+	defer imageCacheRefs.Unlock()
+
+	ref, ok := imageCacheRefs.refs[ptr]
+	if !ok {
+		return
+	}
+	ref.teardown = ref.teardown || teardown
+	ref.count--
+	if ref.count > 0 {
+		return
+	}
+	delete(imageCacheRefs.refs, ptr)
+
+	if !ref.shared || ref.teardown {
+		C.ImageCache_Destroy(ptr, C.bool(ref.teardown))
+	}
+}
+
+// Return the number of references to the cache at ptr.
+func imageCacheRefCount(ptr unsafe.Pointer) int {
+	imageCacheRefs.Lock()
+	defer imageCacheRefs.Unlock()
+
+	if ref, ok := imageCacheRefs.refs[ptr]; ok {
+		return ref.count
+	}
+	return 0
 }
 
-func newImageCache(i unsafe.Pointer) *ImageCache {
-	return &ImageCache{i}
+// Return a new handle to the cache at ptr, which is not owned by any
+// other handle. Caches that are not referenced by a handle can only be
+// the shared cache, as used by an ImageBuf that was given no cache.
+func imageCacheHandle(ptr unsafe.Pointer) *ImageCache {
+	imageCacheRefs.Lock()
+	ref, ok := imageCacheRefs.refs[ptr]
+	shared := !ok || ref.shared
+	imageCacheRefs.Unlock()
+
+	return newImageCache(ptr, shared)
 }
 
 // Create an ImageCache. *This should be freed by calling ImageCache.Destroy()*
 //
 // If shared==true, it's intended to be shared with other like-minded owners
-// in the same process who also ask for a shared cache.
+// in the same process who also ask for a shared cache. Every handle to the
+// shared cache refers to the same underlying cache.
 //
 // If false, a private image cache will be created.
+//
+// A handle that is garbage collected without being destroyed is destroyed
+// by its finalizer.
 func CreateImageCache(shared bool) *ImageCache {
-	ptr := C.ImageCache_Create(C.bool(false))
-	return newImageCache(ptr)
+	ptr := C.ImageCache_Create(C.bool(shared))
+	return newImageCache(ptr, shared)
+}
+
+// Shared returns true if the handle refers to the shared ImageCache.
+func (i *ImageCache) Shared() bool {
+	return i.shared
+}
+
+// acquire returns a new handle to the same cache, which holds its own
+// reference to the cache.
+func (i *ImageCache) acquire() *ImageCache {
+	return newImageCache(i.ptr, i.shared)
 }
 
-// Destroy a ImageCache that was created using CreateImageCache().
-// When 'teardown' parameter is set to true, it will fully destroy even a "shared" ImageCache.
+// Destroy a ImageCache that was created using CreateImageCache(), or
+// returned by ImageBuf.ImageCache(). It is safe to call Destroy more than
+// once, and the handle must not be used afterwards.
+//
+// The underlying cache is only destroyed once all of the handles that
+// refer to it have been destroyed, including those held by ImageBufs
+// and Tiles. A private cache is then freed. A shared cache remains
+// available to future handles, unless the 'teardown' parameter was set to
+// true on one of its handles, in which case it is fully destroyed.
 func (i *ImageCache) Destroy(teardown bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	if i.ptr != nil {
-		C.ImageCache_Destroy(i.ptr, C.bool(teardown))
+		releaseImageCache(i.ptr, teardown)
 		i.ptr = nil
+		runtime.SetFinalizer(i, nil)
+	}
+}
+
+// SetAttribute sets an option of the cache, such as:
+//
+//	"max_memory_MB"     float32: maximum tile cache size, in MB
+//	"max_open_files"    int:     maximum number of file handles held open
+//	"autotile"          int:     tile size for untiled images, or 0 to disable
+//	"autoscanline"      bool:    whether autotiles span full scanlines
+//	"forcefloat"        bool:    convert all cached pixels to float
+//	"accept_untiled"    bool:    whether untiled images may be read
+//	"accept_unmipped"   bool:    whether unmipped images may be read
+//	"automip"           bool:    generate MIP levels for unmipped images
+//	"deduplicate"       bool:    share tiles of files with identical pixels
+//	"searchpath"        string:  colon-separated directories to search
+//	"plugin_searchpath" string:  colon-separated directories for plugins
+//
+// val may be a string, int, bool or float32. Bool values are stored as
+// the ints 0 or 1. An error is returned if the cache does not know the
+// attribute, or val is not of the type of the attribute.
+func (i *ImageCache) SetAttribute(name string, val interface{}) error {
+	c_str := C.CString(name)
+	defer C.free(unsafe.Pointer(c_str))
+
+	var ok C.bool
+	switch t := val.(type) {
+	case string:
+		c_val := C.CString(t)
+		defer C.free(unsafe.Pointer(c_val))
+		ok = C.ImageCache_attribute(i.ptr, c_str, TypeString().c(), unsafe.Pointer(&c_val))
+	case int:
+		c_val := C.int(t)
+		ok = C.ImageCache_attribute(i.ptr, c_str, TypeInt().c(), unsafe.Pointer(&c_val))
+	case bool:
+		var c_val C.int
+		if t {
+			c_val = 1
+		}
+		ok = C.ImageCache_attribute(i.ptr, c_str, TypeInt().c(), unsafe.Pointer(&c_val))
+	case float32:
+		c_val := C.float(t)
+		ok = C.ImageCache_attribute(i.ptr, c_str, TypeFloat().c(), unsafe.Pointer(&c_val))
+	default:
+		return fmt.Errorf("Value type %T is not one of (string, int, bool, float32)", t)
+	}
+	if !bool(ok) {
+		return fmt.Errorf("Failed to set ImageCache attribute %q to %T value", name, val)
+	}
+	return nil
+}
+
+// AttributeInt returns the value of an int attribute of the cache, such
+// as "max_open_files", "autotile" or the read-only "total_files". It
+// returns false if the attribute does not exist, or is not an int.
+func (i *ImageCache) AttributeInt(name string) (int, bool) {
+	c_str := C.CString(name)
+	defer C.free(unsafe.Pointer(c_str))
+
+	var c_val C.int
+	if !bool(C.ImageCache_getattribute(i.ptr, c_str, TypeInt().c(), unsafe.Pointer(&c_val))) {
+		return 0, false
+	}
+	return int(c_val), true
+}
+
+// AttributeBool returns the value of an int attribute of the cache as
+// a bool, such as "autoscanline" or "forcefloat". It returns false if the
+// attribute does not exist, or is not an int.
+func (i *ImageCache) AttributeBool(name string) (bool, bool) {
+	v, ok := i.AttributeInt(name)
+	return v != 0, ok
+}
+
+// AttributeInt64 returns the value of a 64-bit integer attribute of the
+// cache, such as the read-only "stat:cache_memory_used". It returns false
+// if the attribute does not exist, or is not an int64.
+func (i *ImageCache) AttributeInt64(name string) (int64, bool) {
+	c_str := C.CString(name)
+	defer C.free(unsafe.Pointer(c_str))
+
+	var c_val C.longlong
+	if !bool(C.ImageCache_getattribute(i.ptr, c_str, TypeInt64().c(), unsafe.Pointer(&c_val))) {
+		return 0, false
+	}
+	return int64(c_val), true
+}
+
+// AttributeFloat returns the value of a float attribute of the cache, such
+// as "max_memory_MB". It returns false if the attribute does not exist,
+// or is not a float.
+func (i *ImageCache) AttributeFloat(name string) (float32, bool) {
+	c_str := C.CString(name)
+	defer C.free(unsafe.Pointer(c_str))
+
+	var c_val C.float
+	if !bool(C.ImageCache_getattribute(i.ptr, c_str, TypeFloat().c(), unsafe.Pointer(&c_val))) {
+		return 0, false
+	}
+	return float32(c_val), true
+}
+
+// AttributeString returns the value of a string attribute of the cache,
+// such as "searchpath". It returns false if the attribute does not exist,
+// or is not a string.
+func (i *ImageCache) AttributeString(name string) (string, bool) {
+	vals, ok := i.attributeStrings(name, TypeString())
+	if !ok {
+		return "", false
+	}
+	return vals[0], true
+}
+
+// AttributeStrings returns the n values of a string array attribute of
+// the cache. It returns false if the attribute does not exist, or is not
+// a string array of length n.
+func (i *ImageCache) AttributeStrings(name string, n int) ([]string, bool) {
+	if n <= 0 {
+		return nil, false
+	}
+	return i.attributeStrings(name, TypeDesc{BaseType: BaseString, Aggregate: AggregateScalar, ArrayLen: n})
+}
+
+func (i *ImageCache) attributeStrings(name string, t TypeDesc) ([]string, bool) {
+	c_str := C.CString(name)
+	defer C.free(unsafe.Pointer(c_str))
+
+	n := maxInt(t.ArrayLen, 1)
+	c_vals := make([]*C.char, n)
+	if !bool(C.ImageCache_getattribute_strings(i.ptr, c_str, t.c(), &c_vals[0])) {
+		return nil, false
+	}
+	vals := make([]string, n)
+	for idx, c_val := range c_vals {
+		if c_val != nil {
+			vals[idx] = C.GoString(c_val)
+		}
+	}
+	return vals, true
+}
+
+// Filenames returns the names of all files known to the cache, from the
+// "total_files" and "all_filenames" attributes.
+func (i *ImageCache) Filenames() []string {
+	n, ok := i.AttributeInt("total_files")
+	if !ok || n == 0 {
+		return nil
+	}
+	names, _ := i.AttributeStrings("all_filenames", n)
+	return names
+}
+
+// ImageSpec returns a copy of the ImageSpec of a subimage and MIP level of
+// a file. If native is true, the spec describes the data of the file;
+// otherwise it describes the data as held by the cache, which may differ
+// in format and tiling, for instance with the "forcefloat" or "autotile"
+// attributes.
+func (i *ImageCache) ImageSpec(filename string, subimage, miplevel int, native bool) (*ImageSpec, error) {
+	c_str := C.CString(filename)
+	defer C.free(unsafe.Pointer(c_str))
+
+	spec := NewImageSpec(TypeUnknown())
+	ok := bool(C.ImageCache_get_imagespec(i.ptr, c_str, spec.ptr, C.int(subimage), C.int(miplevel), C.bool(native)))
+	if !ok {
+		return nil, i.errorOr("Failed to get ImageSpec of %q", filename)
+	}
+	return spec, nil
+}
+
+// Types of the values of the datanames of ImageInfo. The "averagecolor"
+// and "constantcolor" arrays have one value per channel, and are sized
+// when they are queried.
+var imageInfoTypes = map[string]TypeDesc{
+	"exists":                   TypeInt(),
+	"udim":                     TypeInt(),
+	"subimages":                TypeInt(),
+	"miplevels":                TypeInt(),
+	"channels":                 TypeInt(),
+	"format":                   TypeInt(),
+	"cachedformat":             TypeInt(),
+	"resolution":               {BaseType: BaseInt, Aggregate: AggregateScalar, ArrayLen: 2},
+	"datawindow":               {BaseType: BaseInt, Aggregate: AggregateScalar, ArrayLen: 4},
+	"displaywindow":            {BaseType: BaseInt, Aggregate: AggregateScalar, ArrayLen: 4},
+	"texturetype":              TypeString(),
+	"textureformat":            TypeString(),
+	"fileformat":               TypeString(),
+	"worldtocamera":            TypeMatrix44(),
+	"worldtoscreen":            TypeMatrix44(),
+	"averagealpha":             TypeFloat(),
+	"constantalpha":            TypeFloat(),
+	"averagecolor":             {BaseType: BaseFloat, Aggregate: AggregateScalar, ArrayLen: -1},
+	"constantcolor":            {BaseType: BaseFloat, Aggregate: AggregateScalar, ArrayLen: -1},
+	"stat:tilesread":           TypeInt64(),
+	"stat:bytesread":           TypeInt64(),
+	"stat:redundant_tiles":     TypeInt64(),
+	"stat:redundant_bytesread": TypeInt64(),
+	"stat:image_size":          TypeInt64(),
+	"stat:file_size":           TypeInt64(),
+	"stat:timesopened":         TypeInt(),
+	"stat:mipsused":            TypeInt(),
+	"stat:is_duplicate":        TypeInt(),
+	"stat:iotime":              TypeFloat(),
+}
+
+// ImageInfo retrieves information about a subimage and MIP level of a
+// file, such as:
+//
+//	"exists"         int:       1 if the file exists and is a readable image
+//	"subimages"      int:       number of subimages
+//	"miplevels"      int:       number of MIP levels of the subimage
+//	"resolution"     []int:     width and height of the MIP level
+//	"datawindow"     []int:     xmin, ymin, xmax, ymax of the pixel data
+//	"displaywindow"  []int:     xmin, ymin, xmax, ymax of the full image
+//	"channels"       int:       number of channels
+//	"fileformat"     string:    name of the file format
+//	"texturetype"    string:    "Plain Texture", "Volume Texture", etc
+//	"averagecolor"   []float32: average value of each channel
+//	"stat:tilesread" int64:     number of tiles read from the file
+//
+// Any other dataname is looked up in the attributes of the ImageSpec of
+// the subimage and MIP level. The value is returned with the same Go
+// types as ImageSpec.Attributes. An error is returned if the file could
+// not be opened, or the information is not available.
+func (i *ImageCache) ImageInfo(filename string, subimage, miplevel int, dataname string) (interface{}, error) {
+	t, ok := imageInfoTypes[dataname]
+	if !ok {
+		spec, err := i.ImageSpec(filename, subimage, miplevel, false)
+		if err != nil {
+			return nil, err
+		}
+		if t, ok = spec.AttributeType(dataname); !ok {
+			return nil, fmt.Errorf("No %q information for %q", dataname, filename)
+		}
+	}
+	if t.ArrayLen < 0 {
+		nchannels, err := i.ImageInfo(filename, subimage, miplevel, "channels")
+		if err != nil {
+			return nil, err
+		}
+		t.ArrayLen = nchannels.(int)
+	}
+
+	c_str := C.CString(filename)
+	c_name := C.CString(dataname)
+	defer func() {
+		C.free(unsafe.Pointer(c_str))
+		C.free(unsafe.Pointer(c_name))
+	}()
+
+	data := C.calloc(1, C.size_t(t.Size()))
+	defer C.free(data)
+
+	ok = bool(C.ImageCache_get_image_info(i.ptr, c_str, C.int(subimage), C.int(miplevel), c_name, t.c(), data))
+	if !ok {
+		return nil, i.errorOr("No %q information for %q", dataname, filename)
+	}
+	return decodeAttribute(t, data), nil
+}
+
+// GetPixels retrieves the rectangle of pixels defined by an ROI, from a
+// subimage and MIP level of a file, storing the pixel values in a slice
+// that has been casted to an interface, in the pixel format type described
+// by 'format'. Only the tiles that overlap the ROI are read into the cache.
+// If roi is nil or undefined, the pixels of the entire data window are
+// retrieved. The channels of the ROI are clamped to the channels of the image,
+// and an error is returned if the ROI is then empty.
+//
+// The underlying type of the returned slice is the one returned by
+// ImageBuf.GetPixels for the given format.
+func (i *ImageCache) GetPixels(filename string, subimage, miplevel int, roi *ROI, format TypeDesc) (interface{}, error) {
+	spec, err := i.ImageSpec(filename, subimage, miplevel, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if roi == nil || !roi.Defined() {
+		roi = NewROIRegion3D(
+			spec.X(), spec.X()+spec.Width(),
+			spec.Y(), spec.Y()+spec.Height(),
+			spec.Z(), spec.Z()+spec.Depth(),
+			0, spec.NumChannels())
+	} else if roi.ChannelsEnd() > spec.NumChannels() {
+		roi = roi.Copy()
+		roi.SetChannelsEnd(spec.NumChannels())
+	}
+
+	if roi.Width() <= 0 || roi.Height() <= 0 || roi.Depth() <= 0 || roi.NumChannels() <= 0 {
+		return nil, fmt.Errorf("ROI does not contain any pixels or channels of %q", filename)
+	}
+
+	pixel_iface, ptr, err := allocatePixelBufferSize(roi.NumPixels()*roi.NumChannels(), format)
+	if err != nil {
+		return nil, err
+	}
+
+	c_str := C.CString(filename)
+	defer C.free(unsafe.Pointer(c_str))
+
+	ok := bool(C.ImageCache_get_pixels(
+		i.ptr, c_str,
+		C.int(subimage), C.int(miplevel),
+		C.int(roi.XBegin()), C.int(roi.XEnd()),
+		C.int(roi.YBegin()), C.int(roi.YEnd()),
+		C.int(roi.ZBegin()), C.int(roi.ZEnd()),
+		C.int(roi.ChannelsBegin()), C.int(roi.ChannelsEnd()),
+		format.c(), ptr),
+	)
+
+	if !ok {
+		return nil, i.errorOr("Failed to get pixels of %q", filename)
+	}
+
+	return pixel_iface, nil
+}
+
+// errorOr returns the last error of the cache, or a new error with
+// the given message if the cache did not report one.
+func (i *ImageCache) errorOr(format string, args ...interface{}) error {
+	if err := i.LastError(); err != nil {
+		return err
 	}
+	return fmt.Errorf(format, args...)
 }
 
 // Return the last error generated by API calls.
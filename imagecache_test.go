@@ -1,7 +1,10 @@
 package oiio
 
 import (
+	"reflect"
+	"runtime"
 	"testing"
+	"time"
 )
 
 func TestCreateImageCache(t *testing.T) {
@@ -24,3 +27,342 @@ func TestImageCacheStats(t *testing.T) {
 	cache.Invalidate("test")
 	cache.InvalidateAll(true)
 }
+
+func TestImageCacheAttributes(t *testing.T) {
+	cache := CreateImageCache(false)
+	defer cache.Destroy(true)
+
+	checkFatalError(t, cache.SetAttribute("max_memory_MB", float32(64)))
+	if val, ok := cache.AttributeFloat("max_memory_MB"); !ok || val != 64 {
+		t.Errorf("Expected max_memory_MB 64; got %v (%v)", val, ok)
+	}
+
+	checkFatalError(t, cache.SetAttribute("max_open_files", 10))
+	if val, ok := cache.AttributeInt("max_open_files"); !ok || val != 10 {
+		t.Errorf("Expected max_open_files 10; got %v (%v)", val, ok)
+	}
+
+	checkFatalError(t, cache.SetAttribute("autotile", 64))
+	if val, ok := cache.AttributeInt("autotile"); !ok || val != 64 {
+		t.Errorf("Expected autotile 64; got %v (%v)", val, ok)
+	}
+
+	for _, name := range []string{"autoscanline", "forcefloat", "accept_untiled", "deduplicate"} {
+		for _, expected := range []bool{true, false} {
+			checkFatalError(t, cache.SetAttribute(name, expected))
+			if val, ok := cache.AttributeBool(name); !ok || val != expected {
+				t.Errorf("Expected %s %v; got %v (%v)", name, expected, val, ok)
+			}
+		}
+	}
+
+	checkFatalError(t, cache.SetAttribute("searchpath", "/tmp:/var/tmp"))
+	if val, ok := cache.AttributeString("searchpath"); !ok || val != "/tmp:/var/tmp" {
+		t.Errorf("Expected searchpath %q; got %q (%v)", "/tmp:/var/tmp", val, ok)
+	}
+
+	if err := cache.SetAttribute("not_an_attribute", 1); err == nil {
+		t.Error("Expected error setting an unknown attribute")
+	}
+	if err := cache.SetAttribute("max_open_files", []int{1}); err == nil {
+		t.Error("Expected error setting an unsupported value type")
+	}
+	if _, ok := cache.AttributeInt("not_an_attribute"); ok {
+		t.Error("Expected unknown attribute to not be found")
+	}
+	if _, ok := cache.AttributeString("max_open_files"); ok {
+		t.Error("Expected int attribute to not be found as a string")
+	}
+}
+
+func TestImageCacheFilenames(t *testing.T) {
+	cache := CreateImageCache(false)
+	defer cache.Destroy(true)
+
+	if val, ok := cache.AttributeInt("total_files"); !ok || val != 0 {
+		t.Errorf("Expected total_files 0; got %v (%v)", val, ok)
+	}
+	if names := cache.Filenames(); names != nil {
+		t.Errorf("Expected no filenames; got %v", names)
+	}
+
+	buf, err := NewImageBufPathCache(TEST_IMAGE, cache)
+	checkFatalError(t, err)
+	checkFatalError(t, buf.Read(false))
+
+	if val, ok := cache.AttributeInt("total_files"); !ok || val != 1 {
+		t.Errorf("Expected total_files 1; got %v (%v)", val, ok)
+	}
+	if names := cache.Filenames(); len(names) != 1 || names[0] != TEST_IMAGE {
+		t.Errorf("Expected filenames [%s]; got %v", TEST_IMAGE, names)
+	}
+	if _, ok := cache.AttributeInt64("stat:cache_memory_used"); !ok {
+		t.Error("Expected stat:cache_memory_used to be found")
+	}
+}
+
+func TestImageCacheImageSpec(t *testing.T) {
+	cache := CreateImageCache(false)
+	defer cache.Destroy(true)
+
+	spec, err := cache.ImageSpec(TEST_IMAGE, 0, 0, true)
+	checkFatalError(t, err)
+	if spec.Width() != 128 || spec.Height() != 64 || spec.NumChannels() != 4 {
+		t.Errorf("Expected 128x64x4 spec; got %dx%dx%d", spec.Width(), spec.Height(), spec.NumChannels())
+	}
+	if spec.Format() != TypeUint8() {
+		t.Errorf("Expected native format %v; got %v", TypeUint8(), spec.Format())
+	}
+
+	if _, err = cache.ImageSpec("/does/not/exist.png", 0, 0, false); err == nil {
+		t.Error("Expected error getting the spec of a missing file")
+	}
+	if _, err = cache.ImageSpec(TEST_IMAGE, 1, 0, false); err == nil {
+		t.Error("Expected error getting the spec of a missing subimage")
+	}
+}
+
+func TestImageCacheImageInfo(t *testing.T) {
+	cache := CreateImageCache(false)
+	defer cache.Destroy(true)
+
+	for dataname, expected := range map[string]interface{}{
+		"exists":     1,
+		"subimages":  1,
+		"miplevels":  1,
+		"channels":   4,
+		"resolution": []int{128, 64},
+		"datawindow": []int{0, 0, 127, 63},
+		"fileformat": "png",
+	} {
+		actual, err := cache.ImageInfo(TEST_IMAGE, 0, 0, dataname)
+		checkFatalError(t, err)
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("Expected %s %v; got %v", dataname, expected, actual)
+		}
+	}
+
+	actual, err := cache.ImageInfo(TEST_IMAGE, 0, 0, "averagecolor")
+	checkFatalError(t, err)
+	if colors, ok := actual.([]float32); !ok || len(colors) != 4 {
+		t.Errorf("Expected 4 average channel values; got %v", actual)
+	}
+
+	// Datanames that are not predefined are looked up in the spec
+	spec, err := cache.ImageSpec(TEST_IMAGE, 0, 0, false)
+	checkFatalError(t, err)
+	for _, attr := range spec.Attributes() {
+		actual, err := cache.ImageInfo(TEST_IMAGE, 0, 0, attr.Name)
+		checkFatalError(t, err)
+		if !reflect.DeepEqual(attr.Value, actual) {
+			t.Errorf("Expected %s %v; got %v", attr.Name, attr.Value, actual)
+		}
+	}
+
+	if _, err = cache.ImageInfo(TEST_IMAGE, 0, 0, "not_an_attribute"); err == nil {
+		t.Error("Expected error getting unknown information")
+	}
+
+	actual, err = cache.ImageInfo("/does/not/exist.png", 0, 0, "exists")
+	checkFatalError(t, err)
+	if actual != 0 {
+		t.Errorf("Expected a missing file to not exist; got %v", actual)
+	}
+}
+
+func TestImageCacheGetPixels(t *testing.T) {
+	cache := CreateImageCache(false)
+	defer cache.Destroy(true)
+
+	buf, err := NewImageBufPath(TEST_IMAGE)
+	checkFatalError(t, err)
+
+	expected, err := buf.GetPixels(TypeFloat())
+	checkFatalError(t, err)
+	actual, err := cache.GetPixels(TEST_IMAGE, 0, 0, nil, TypeFloat())
+	checkFatalError(t, err)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Error("Expected the pixels of the cache to match the pixels of the ImageBuf")
+	}
+
+	roi := NewROIRegion3D(10, 30, 5, 20, 0, 1, 1, 3)
+	expected, err = buf.GetPixelRegion(roi, TypeUint8())
+	checkFatalError(t, err)
+	actual, err = cache.GetPixels(TEST_IMAGE, 0, 0, roi, TypeUint8())
+	checkFatalError(t, err)
+	if pixels := actual.([]uint8); len(pixels) != 20*15*2 {
+		t.Fatalf("Expected %d values; got %d", 20*15*2, len(pixels))
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Error("Expected the ROI pixels of the cache to match the pixels of the ImageBuf")
+	}
+
+	// Channels beyond the image are clamped
+	actual, err = cache.GetPixels(TEST_IMAGE, 0, 0, NewROIRegion2D(0, 2, 0, 2), TypeUint8())
+	checkFatalError(t, err)
+	if pixels := actual.([]uint8); len(pixels) != 2*2*4 {
+		t.Errorf("Expected %d values; got %d", 2*2*4, len(pixels))
+	}
+
+	// Empty ROIs are an error, rather than a panic
+	for _, roi := range []*ROI{
+		NewROIRegion3D(0, 2, 0, 2, 0, 1, 4, 6),
+		NewROIRegion3D(0, 2, 0, 2, 0, 1, 3, 2),
+		NewROIRegion2D(2, 0, 0, 2),
+		NewROIRegion2D(2, 0, 2, 0),
+	} {
+		if _, err = cache.GetPixels(TEST_IMAGE, 0, 0, roi, TypeUint8()); err == nil {
+			t.Errorf("Expected error getting the pixels of the empty ROI %v", roi)
+		}
+	}
+
+	if _, err = cache.GetPixels("/does/not/exist.png", 0, 0, nil, TypeFloat()); err == nil {
+		t.Error("Expected error getting the pixels of a missing file")
+	}
+}
+
+func TestImageCacheSharedHandles(t *testing.T) {
+	a := CreateImageCache(true)
+	defer a.Destroy(false)
+	b := CreateImageCache(true)
+	defer b.Destroy(false)
+
+	if !a.Shared() || !b.Shared() {
+		t.Fatal("Expected shared caches")
+	}
+	if a.ptr != b.ptr {
+		t.Fatal("Expected shared handles to refer to the same cache")
+	}
+
+	private := CreateImageCache(false)
+	defer private.Destroy(false)
+	if private.Shared() || private.ptr == a.ptr {
+		t.Fatal("Expected a private cache that is not the shared cache")
+	}
+
+	buf, err := NewImageBufPathCache(TEST_IMAGE, a)
+	checkFatalError(t, err)
+	checkFatalError(t, buf.Read(false))
+
+	found := false
+	for _, name := range b.Filenames() {
+		found = found || name == TEST_IMAGE
+	}
+	if !found {
+		t.Errorf("Expected %s in the files of the other shared handle", TEST_IMAGE)
+	}
+	for _, name := range private.Filenames() {
+		if name == TEST_IMAGE {
+			t.Errorf("Expected %s to not be in the files of the private cache", TEST_IMAGE)
+		}
+	}
+
+	if statsA, statsB := a.GetStats(1), b.GetStats(1); statsA != statsB {
+		t.Errorf("Expected shared handles to report the same stats:\n%s\n%s", statsA, statsB)
+	}
+	memA, _ := a.AttributeInt64("stat:cache_memory_used")
+	memB, _ := b.AttributeInt64("stat:cache_memory_used")
+	if memA != memB {
+		t.Errorf("Expected shared handles to report the same cache memory; got %d, %d", memA, memB)
+	}
+
+	// Destroying one shared handle does not affect the other
+	a.Destroy(false)
+	if n, ok := b.AttributeInt("total_files"); !ok || n == 0 {
+		t.Errorf("Expected the shared cache to remain usable; got total_files %v (%v)", n, ok)
+	}
+}
+
+func TestImageCacheDestroy(t *testing.T) {
+	cache := CreateImageCache(false)
+	ptr := cache.ptr
+	if n := imageCacheRefCount(ptr); n != 1 {
+		t.Fatalf("Expected 1 reference; got %d", n)
+	}
+
+	buf, err := NewImageBufPathCache(TEST_IMAGE, cache)
+	checkFatalError(t, err)
+	if n := imageCacheRefCount(ptr); n != 2 {
+		t.Errorf("Expected the ImageBuf to hold a reference; got %d references", n)
+	}
+
+	handle := buf.ImageCache()
+	if handle.ptr != ptr || handle.Shared() {
+		t.Error("Expected ImageBuf.ImageCache() to return a handle to the private cache")
+	}
+	if n := imageCacheRefCount(ptr); n != 3 {
+		t.Errorf("Expected 3 references; got %d", n)
+	}
+
+	// Destroy is idempotent, and the cache outlives the handles
+	// while the ImageBuf uses it
+	cache.Destroy(true)
+	cache.Destroy(true)
+	handle.Destroy(false)
+	if n := imageCacheRefCount(ptr); n != 1 {
+		t.Errorf("Expected 1 reference; got %d", n)
+	}
+	checkFatalError(t, buf.Read(false))
+	if _, err = buf.GetPixels(TypeFloat()); err != nil {
+		t.Errorf("Expected the ImageBuf to read through its cache: %v", err)
+	}
+
+	deleteImageBuf(buf)
+	if n := imageCacheRefCount(ptr); n != 0 {
+		t.Errorf("Expected no references; got %d", n)
+	}
+}
+
+func TestImageCacheDefaultImageBuf(t *testing.T) {
+	buf, err := NewImageBufPath(TEST_IMAGE)
+	checkFatalError(t, err)
+	checkFatalError(t, buf.Read(false))
+
+	cache := buf.ImageCache()
+	if cache == nil {
+		t.Fatal("Expected the ImageBuf to be backed by a cache")
+	}
+	if !cache.Shared() {
+		t.Error("Expected the ImageBuf to use the shared cache")
+	}
+	shared := CreateImageCache(true)
+	defer shared.Destroy(false)
+	if cache.ptr != shared.ptr {
+		t.Error("Expected the ImageBuf to use the shared cache")
+	}
+
+	// Destroying the handle does not tear down the shared cache
+	cache.Destroy(false)
+	if _, err = buf.GetPixels(TypeFloat()); err != nil {
+		t.Errorf("Expected the ImageBuf to remain usable: %v", err)
+	}
+
+	// Nor does tearing it down, while an ImageBuf holds its own reference
+	torn, err := NewImageBufPath(TEST_IMAGE)
+	checkFatalError(t, err)
+	torn.ImageCache().Destroy(true)
+	if n := imageCacheRefCount(shared.ptr); n < 1 {
+		t.Errorf("Expected the ImageBuf to hold a reference to the shared cache; got %d", n)
+	}
+	checkFatalError(t, torn.Read(false))
+	if _, err = torn.GetPixels(TypeFloat()); err != nil {
+		t.Errorf("Expected the ImageBuf to remain usable after a teardown: %v", err)
+	}
+
+	if NewImageBuf().ImageCache() != nil {
+		t.Error("Expected no cache for an uninitialized ImageBuf")
+	}
+}
+
+func TestImageCacheFinalizer(t *testing.T) {
+	ptr := CreateImageCache(false).ptr
+
+	deadline := time.Now().Add(5 * time.Second)
+	for imageCacheRefCount(ptr) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the finalizer to release the private cache")
+		}
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,208 @@
+package oiio
+
+import (
+	"expvar"
+	"time"
+	"unsafe"
+)
+
+// ImageCacheStats holds the statistics of an ImageCache, as reported by
+// its "stat:" attributes. Statistics that are not reported by the version
+// of OIIO in use are zero.
+type ImageCacheStats struct {
+	// Files known to the cache, and files actually read from
+	TotalFiles  int `json:"total_files"`
+	UniqueFiles int `json:"unique_files"`
+
+	// File handles opened over the lifetime of the cache, currently
+	// open, and the most open at once
+	FilesOpened   int `json:"files_opened"`
+	FilesOpen     int `json:"files_open"`
+	FilesOpenPeak int `json:"files_open_peak"`
+
+	// Tiles read into the cache, currently held, and the most held at once
+	TilesCreated int `json:"tiles_created"`
+	TilesCurrent int `json:"tiles_current"`
+	TilesPeak    int `json:"tiles_peak"`
+
+	// Tile lookups, of which those that missed the per-thread microcache,
+	// and those that missed the cache and read the tile from its file
+	TileLookups      int64 `json:"tile_lookups"`
+	MicrocacheMisses int64 `json:"microcache_misses"`
+	CacheHits        int64 `json:"cache_hits"`
+	CacheMisses      int64 `json:"cache_misses"`
+
+	// Memory held by the tiles of the cache, in bytes, and the most memory
+	// held at once. OIIO does not report a peak, so MemoryPeak is the
+	// largest MemoryUsed seen by any call to Stats on the cache, and misses
+	// peaks between calls.
+	MemoryUsed int64 `json:"memory_used"`
+	MemoryPeak int64 `json:"memory_peak"`
+
+	// Bytes read from files, total size of the files, and total size
+	// of their decoded pixels
+	BytesRead int64 `json:"bytes_read"`
+	FilesSize int64 `json:"files_size"`
+	ImageSize int64 `json:"image_size"`
+
+	// Tiles that were read more than once, and their bytes, over all files
+	RedundantTiles     int64 `json:"redundant_tiles"`
+	RedundantBytesRead int64 `json:"redundant_bytes_read"`
+
+	// Time spent reading and opening files, over all threads
+	FileIOTime   time.Duration `json:"file_io_time"`
+	FileOpenTime time.Duration `json:"file_open_time"`
+
+	Files []ImageCacheFileStats `json:"files"`
+}
+
+// ImageCacheFileStats holds the statistics of a file of an ImageCache.
+type ImageCacheFileStats struct {
+	Name               string        `json:"name"`
+	TimesOpened        int           `json:"times_opened"`
+	TilesRead          int64         `json:"tiles_read"`
+	BytesRead          int64         `json:"bytes_read"`
+	RedundantTiles     int64         `json:"redundant_tiles"`
+	RedundantBytesRead int64         `json:"redundant_bytes_read"`
+	IOTime             time.Duration `json:"io_time"`
+	MipsUsed           bool          `json:"mips_used"`
+	Duplicate          bool          `json:"duplicate"`
+}
+
+// Stats returns the statistics of the cache, and of each of its files.
+func (i *ImageCache) Stats() ImageCacheStats {
+	var s ImageCacheStats
+
+	s.TotalFiles = i.statInt("total_files")
+	s.UniqueFiles = i.statInt("stat:unique_files")
+
+	s.FilesOpened = i.statInt("stat:open_files_created")
+	s.FilesOpen = i.statInt("stat:open_files_current")
+	s.FilesOpenPeak = i.statInt("stat:open_files_peak")
+
+	s.TilesCreated = i.statInt("stat:tiles_created")
+	s.TilesCurrent = i.statInt("stat:tiles_current")
+	s.TilesPeak = i.statInt("stat:tiles_peak")
+
+	s.TileLookups = i.statInt64("stat:find_tile_calls")
+	s.MicrocacheMisses = i.statInt64("stat:find_tile_microcache_misses")
+	s.CacheMisses = i.statInt64("stat:find_tile_cache_misses")
+	if s.TileLookups > s.CacheMisses {
+		s.CacheHits = s.TileLookups - s.CacheMisses
+	}
+
+	s.MemoryUsed = i.statInt64("stat:cache_memory_used")
+	s.MemoryPeak = recordMemoryUsed(i.ptr, s.MemoryUsed)
+	s.BytesRead = i.statInt64("stat:bytes_read")
+	s.FilesSize = i.statInt64("stat:files_totalsize")
+	s.ImageSize = i.statInt64("stat:image_size")
+
+	s.FileIOTime = i.statDuration("stat:fileio_time")
+	s.FileOpenTime = i.statDuration("stat:fileopen_time")
+
+	for _, name := range i.Filenames() {
+		f := i.fileStats(name)
+		s.RedundantTiles += f.RedundantTiles
+		s.RedundantBytesRead += f.RedundantBytesRead
+		s.Files = append(s.Files, f)
+	}
+	return s
+}
+
+// Record the memory used by the cache at ptr, and return the most memory
+// it has been seen to use. The peak is kept with the references to the
+// cache, so that it is shared by all of its handles.
+func recordMemoryUsed(ptr unsafe.Pointer, used int64) int64 {
+	imageCacheRefs.Lock()
+	defer imageCacheRefs.Unlock()
+
+	ref, ok := imageCacheRefs.refs[ptr]
+	if !ok {
+		return used
+	}
+	if used > ref.memoryPeak {
+		ref.memoryPeak = used
+	}
+	return ref.memoryPeak
+}
+
+// Return the statistics of the file of the cache with the given name.
+func (i *ImageCache) fileStats(name string) ImageCacheFileStats {
+	f := ImageCacheFileStats{Name: name}
+
+	info := func(dataname string) interface{} {
+		val, _ := i.ImageInfo(name, 0, 0, dataname)
+		return val
+	}
+	if v, ok := info("stat:timesopened").(int); ok {
+		f.TimesOpened = v
+	}
+	if v, ok := info("stat:tilesread").(int64); ok {
+		f.TilesRead = v
+	}
+	if v, ok := info("stat:bytesread").(int64); ok {
+		f.BytesRead = v
+	}
+	if v, ok := info("stat:redundant_tiles").(int64); ok {
+		f.RedundantTiles = v
+	}
+	if v, ok := info("stat:redundant_bytesread").(int64); ok {
+		f.RedundantBytesRead = v
+	}
+	if v, ok := info("stat:iotime").(float32); ok {
+		f.IOTime = secondsDuration(v)
+	}
+	if v, ok := info("stat:mipsused").(int); ok {
+		f.MipsUsed = v != 0
+	}
+	if v, ok := info("stat:is_duplicate").(int); ok {
+		f.Duplicate = v != 0
+	}
+	return f
+}
+
+// Return the value of an integer stat attribute of the cache, or zero if
+// the attribute is not reported. Releases of OIIO differ in which counters
+// are 64-bit, so both sizes are queried.
+func (i *ImageCache) statInt64(name string) int64 {
+	if val, ok := i.AttributeInt64(name); ok {
+		return val
+	}
+	val, _ := i.AttributeInt(name)
+	return int64(val)
+}
+
+func (i *ImageCache) statInt(name string) int {
+	return int(i.statInt64(name))
+}
+
+func (i *ImageCache) statDuration(name string) time.Duration {
+	val, _ := i.AttributeFloat(name)
+	return secondsDuration(val)
+}
+
+// Convert the seconds of a time stat to a Duration.
+func secondsDuration(seconds float32) time.Duration {
+	return time.Duration(float64(seconds) * float64(time.Second))
+}
+
+// PublishStats publishes the statistics of the cache as an expvar variable
+// with the given name, which reports the ImageCacheStats of the cache as
+// JSON each time it is read. As with expvar.Publish, it panics if the name
+// is already in use.
+//
+// Since expvar variables can not be removed, the published variable holds
+// its own reference to the cache, and keeps it from being destroyed.
+func (i *ImageCache) PublishStats(name string) {
+	cache := i.acquire()
+	defer func() {
+		// Drop the reference if the name is already in use
+		if r := recover(); r != nil {
+			cache.Destroy(false)
+			panic(r)
+		}
+	}()
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return cache.Stats()
+	}))
+}
@@ -0,0 +1,116 @@
+package oiio
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+)
+
+// Return a private cache that has read TEST_IMAGE, and that is only
+// referenced by the returned handle.
+func newTestStatsCache(t *testing.T) *ImageCache {
+	cache := CreateImageCache(false)
+	_, err := cache.GetPixels(TEST_IMAGE, 0, 0, nil, TypeFloat())
+	checkFatalError(t, err)
+	return cache
+}
+
+func TestImageCacheStatsStruct(t *testing.T) {
+	cache := CreateImageCache(false)
+	defer cache.Destroy(false)
+
+	stats := cache.Stats()
+	if stats.TotalFiles != 0 || stats.TilesCreated != 0 || len(stats.Files) != 0 {
+		t.Errorf("Expected empty stats for a new cache; got %+v", stats)
+	}
+
+	_, err := cache.GetPixels(TEST_IMAGE, 0, 0, nil, TypeFloat())
+	checkFatalError(t, err)
+	_, err = cache.GetPixels(TEST_IMAGE, 0, 0, NewROIRegion2D(0, 8, 0, 8), TypeFloat())
+	checkFatalError(t, err)
+
+	stats = cache.Stats()
+	if stats.TotalFiles != 1 {
+		t.Errorf("Expected 1 file; got %d", stats.TotalFiles)
+	}
+	if stats.TilesCreated == 0 || stats.TilesCurrent == 0 || stats.TilesPeak < stats.TilesCurrent {
+		t.Errorf("Expected tiles to be read; got created %d, current %d, peak %d",
+			stats.TilesCreated, stats.TilesCurrent, stats.TilesPeak)
+	}
+	if stats.FilesOpened == 0 {
+		t.Error("Expected a file to be opened")
+	}
+	if stats.BytesRead == 0 || stats.MemoryUsed == 0 {
+		t.Errorf("Expected bytes to be read into memory; got read %d, used %d",
+			stats.BytesRead, stats.MemoryUsed)
+	}
+	if stats.MemoryPeak != stats.MemoryUsed {
+		t.Errorf("Expected the peak memory %d to be the memory used %d", stats.MemoryPeak, stats.MemoryUsed)
+	}
+
+	// The peak is kept once the memory is freed
+	peak := stats.MemoryPeak
+	cache.Invalidate(TEST_IMAGE)
+	if stats := cache.Stats(); stats.MemoryPeak != peak || stats.MemoryUsed > peak {
+		t.Errorf("Expected peak memory %d to be kept; got peak %d, used %d",
+			peak, stats.MemoryPeak, stats.MemoryUsed)
+	}
+	if stats.TileLookups == 0 || stats.CacheHits+stats.CacheMisses != stats.TileLookups {
+		t.Errorf("Expected hits %d and misses %d to add up to the lookups %d",
+			stats.CacheHits, stats.CacheMisses, stats.TileLookups)
+	}
+
+	if len(stats.Files) != 1 {
+		t.Fatalf("Expected stats of 1 file; got %d", len(stats.Files))
+	}
+	file := stats.Files[0]
+	if file.Name != TEST_IMAGE {
+		t.Errorf("Expected stats of %s; got %s", TEST_IMAGE, file.Name)
+	}
+	if file.TimesOpened == 0 || file.TilesRead == 0 || file.BytesRead == 0 {
+		t.Errorf("Expected the file to be read; got %+v", file)
+	}
+	if file.RedundantTiles != stats.RedundantTiles {
+		t.Errorf("Expected redundant tiles %d; got %d", file.RedundantTiles, stats.RedundantTiles)
+	}
+}
+
+func TestImageCachePublishStats(t *testing.T) {
+	cache := newTestStatsCache(t)
+	defer cache.Destroy(false)
+
+	const name = "oiio_test_imagecache"
+	cache.PublishStats(name)
+
+	v := expvar.Get(name)
+	if v == nil {
+		t.Fatalf("Expected expvar %q to be published", name)
+	}
+
+	var stats ImageCacheStats
+	checkFatalError(t, json.Unmarshal([]byte(v.String()), &stats))
+	if stats.TotalFiles != 1 || len(stats.Files) != 1 || stats.Files[0].Name != TEST_IMAGE {
+		t.Errorf("Expected the published stats of %s; got %+v", TEST_IMAGE, stats)
+	}
+
+	// The published variable keeps the cache alive
+	ptr := cache.ptr
+	cache.Destroy(false)
+	if n := imageCacheRefCount(ptr); n != 1 {
+		t.Errorf("Expected the published stats to hold the only reference to the cache; got %d", n)
+	}
+
+	other := CreateImageCache(false)
+	defer other.Destroy(false)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected publishing a duplicate name to panic")
+			}
+		}()
+		other.PublishStats(name)
+	}()
+	if n := imageCacheRefCount(other.ptr); n != 1 {
+		t.Errorf("Expected a failed publish to not hold a reference; got %d references", n)
+	}
+}
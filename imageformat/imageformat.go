@@ -0,0 +1,189 @@
+// Package imageformat registers decoders for the image file formats that
+// OpenImageIO can read, with the standard library image package.
+//
+// It is intended to be imported for its side effects only, so that
+// image.Decode and image.DecodeConfig work on formats such as OpenEXR,
+// DPX, Cineon and TIFF:
+//
+//	import _ "github.com/justinfx/openimageigo/imageformat"
+//
+// Formats that are already supported by the standard library (PNG, JPEG
+// and GIF) are not registered. Decoded images are built with
+// oiio.NewImageFromPixels, and DecodeConfig reports the matching color
+// model without reading any pixels.
+//
+// Images are decoded in memory, through oiio.OpenImageInputReader, so
+// this requires OpenImageIO 2.0 or newer and a format that can be read
+// through an IOProxy; other formats fail to decode with an error.
+// DecodeConfig only reads the first MaxHeaderSize bytes of the stream,
+// so an image whose header lies further into the file (ie. a TIFF that
+// stores its directory at the end) can be decoded, but not configured.
+//
+// Targa has no magic number. It is matched, after every other format,
+// by the leading header fields of the uncompressed and RLE color-mapped,
+// true-color and grayscale images, with one of their usual pixel depths.
+// Targa files with other pixel depths are not recognized.
+package imageformat
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"io/ioutil"
+
+	oiio "github.com/justinfx/openimageigo"
+)
+
+// A Format is an image file format that is registered with the image package.
+type Format struct {
+	// The name of the format, as reported by image.Decode
+	Name string
+	// The file extension OpenImageIO associates with the format
+	Extension string
+	// The magic prefixes that identify the format.
+	// A "?" matches any byte.
+	Magic []string
+}
+
+// Formats lists all of the formats that are registered, in the
+// order in which they are sniffed.
+var Formats = []Format{
+	{"exr", ".exr", []string{"\x76\x2f\x31\x01"}},
+	{"dpx", ".dpx", []string{"SDPX", "XPDS"}},
+	{"cineon", ".cin", []string{"\x80\x2a\x5f\xd7", "\xd7\x5f\x2a\x80"}},
+	{"tiff", ".tif", []string{"II*\x00", "MM\x00*"}},
+	{"hdr", ".hdr", []string{"#?RADIANCE", "#?RGBE"}},
+	{"psd", ".psd", []string{"8BPS"}},
+	{"jpeg2000", ".jp2", []string{"\x00\x00\x00\x0cjP  \r\n\x87\n"}},
+	{"jpeg2000", ".j2k", []string{"\xff\x4f\xff\x51"}},
+	{"iff", ".iff", []string{"FOR4"}},
+	{"softimage", ".pic", []string{"\x53\x80\xf6\x34"}},
+	{"sgi", ".sgi", []string{"\x01\xda"}},
+	{"fits", ".fits", []string{"SIMPLE  ="}},
+	{"dds", ".dds", []string{"DDS "}},
+	{"bmp", ".bmp", []string{"BM"}},
+	{"ico", ".ico", []string{"\x00\x00\x01\x00"}},
+	{"pnm", ".pnm", []string{"P1", "P2", "P3", "P4", "P5", "P6"}},
+	{"tga", ".tga", tgaMagic()},
+}
+
+// MaxHeaderSize is the number of bytes that DecodeConfig reads from
+// the stream to find the header of an image.
+const MaxHeaderSize = 1 << 20
+
+// Return the magic prefixes of a Targa file. Since Targa has no magic
+// number, they match the first 17 bytes of the header: the color map
+// type, the image type, the color map spec and the pixel depth, with
+// the id length, origin and dimensions left open.
+func tgaMagic() []string {
+	var magic []string
+	header := func(cmapType, imageType, cmapSpec string, depths ...byte) {
+		for _, depth := range depths {
+			magic = append(magic, "?"+cmapType+imageType+cmapSpec+"????????"+string([]byte{depth}))
+		}
+	}
+	noColorMap := "\x00\x00\x00\x00\x00"
+
+	// True-color, uncompressed and RLE
+	for _, imageType := range []string{"\x02", "\x0a"} {
+		header("\x00", imageType, noColorMap, 16, 24, 32)
+	}
+	// Grayscale, uncompressed and RLE
+	for _, imageType := range []string{"\x03", "\x0b"} {
+		header("\x00", imageType, noColorMap, 8, 16)
+	}
+	// Color-mapped, uncompressed and RLE, with 8 bit indices into a
+	// color map of 15, 16, 24 or 32 bit entries
+	for _, imageType := range []string{"\x01", "\x09"} {
+		for _, entrySize := range []string{"\x0f", "\x10", "\x18", "\x20"} {
+			header("\x01", imageType, "????"+entrySize, 8)
+		}
+	}
+	return magic
+}
+
+func init() {
+	for _, f := range Formats {
+		for _, magic := range f.Magic {
+			image.RegisterFormat(f.Name, magic, decoder(f.Extension), configDecoder(f.Extension))
+		}
+	}
+}
+
+func decoder(ext string) func(io.Reader) (image.Image, error) {
+	return func(r io.Reader) (image.Image, error) {
+		return decode(r, ext)
+	}
+}
+
+func configDecoder(ext string) func(io.Reader) (image.Config, error) {
+	return func(r io.Reader) (image.Config, error) {
+		return decodeConfig(r, ext)
+	}
+}
+
+// Decode the first subimage of an image, from a stream of the given format.
+func decode(r io.Reader, ext string) (image.Image, error) {
+	in, cleanup, err := openImageInput(r, ext)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	spec := in.Spec()
+	if spec.Deep() {
+		return nil, errors.New("imageformat: deep images can not be decoded")
+	}
+
+	format := oiio.TypeUint16()
+	if spec.ColorModel() == color.NRGBAModel {
+		format = oiio.TypeUint8()
+	}
+
+	pixels, err := in.ReadImageFormat(format, nil)
+	if err != nil {
+		return nil, err
+	}
+	return oiio.NewImageFromPixels(spec, pixels)
+}
+
+// Decode the dimensions and color model of the first subimage of an
+// image, from a stream of the given format, without reading the pixels.
+// Only the first MaxHeaderSize bytes of the stream are read.
+func decodeConfig(r io.Reader, ext string) (image.Config, error) {
+	in, cleanup, err := openImageInput(io.LimitReader(r, MaxHeaderSize), ext)
+	if err != nil {
+		return image.Config{}, err
+	}
+	defer cleanup()
+
+	spec := in.Spec()
+	return image.Config{
+		ColorModel: spec.ColorModel(),
+		Width:      spec.Width(),
+		Height:     spec.Height(),
+	}, nil
+}
+
+// Open an ImageInput on the contents of the stream, which are read into
+// memory once and then read by the ImageInput in place. The returned
+// cleanup function closes the ImageInput.
+func openImageInput(r io.Reader, ext string) (*oiio.ImageInput, func(), error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	in, err := oiio.OpenImageInputReader(ext, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("imageformat: %s", err)
+	}
+
+	cleanup := func() {
+		in.Close()
+	}
+	return in, cleanup, nil
+}
@@ -0,0 +1,161 @@
+package imageformat
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"os"
+	"testing"
+
+	oiio "github.com/justinfx/openimageigo"
+)
+
+func TestDecode(t *testing.T) {
+	for _, test := range []struct {
+		path   string
+		format string
+	}{
+		{"../testdata/subimages.exr", "exr"},
+		{"../testdata/checker_mip.tx", "tiff"},
+	} {
+		expected := readConfig(t, test.path)
+
+		f, err := os.Open(test.path)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		img, format, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %s", test.path, err.Error())
+		}
+
+		if format != test.format {
+			t.Errorf("%s: Expected format %q; got %q", test.path, test.format, format)
+		}
+		b := img.Bounds()
+		if b.Dx() != expected.Width || b.Dy() != expected.Height {
+			t.Errorf("%s: Expected size %dx%d; got %dx%d",
+				test.path, expected.Width, expected.Height, b.Dx(), b.Dy())
+		}
+		if img.ColorModel() != expected.ColorModel {
+			t.Errorf("%s: Expected decoded color model to match the spec", test.path)
+		}
+	}
+}
+
+func TestDecodeConfig(t *testing.T) {
+	path := "../testdata/subimages.exr"
+	expected := readConfig(t, path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer f.Close()
+
+	cfg, format, err := image.DecodeConfig(f)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if format != "exr" {
+		t.Errorf("Expected format %q; got %q", "exr", format)
+	}
+	if cfg.Width != expected.Width || cfg.Height != expected.Height {
+		t.Errorf("Expected size %dx%d; got %dx%d", expected.Width, expected.Height, cfg.Width, cfg.Height)
+	}
+	if cfg.ColorModel != expected.ColorModel {
+		t.Error("Expected config color model to match the spec")
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	// Valid magic, but not a valid image
+	data := []byte("\x76\x2f\x31\x01 not really an exr file")
+	if _, _, err := image.Decode(bytes.NewReader(data)); err == nil {
+		t.Error("Expected an error when decoding an invalid image")
+	}
+
+	if _, _, err := image.Decode(bytes.NewReader([]byte("unknown"))); err != image.ErrFormat {
+		t.Errorf("Expected image.ErrFormat for an unknown format; got %v", err)
+	}
+
+	// A Targa image type without a usual pixel depth is not sniffed
+	tga := []byte("\x00\x00\x02\x00\x00\x00\x00\x00 not really a tga file")
+	if _, _, err := image.Decode(bytes.NewReader(tga)); err != image.ErrFormat {
+		t.Errorf("Expected image.ErrFormat for a headerless format; got %v", err)
+	}
+}
+
+func TestDecodeTGAHeader(t *testing.T) {
+	for _, header := range []string{
+		"\x00\x00\x02\x00\x00\x00\x00\x00\x00\x00\x00\x00\x04\x00\x04\x00\x18\x00",
+		"\x00\x00\x0a\x00\x00\x00\x00\x00\x00\x00\x00\x00\x04\x00\x04\x00\x20\x08",
+		"\x00\x00\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00\x04\x00\x04\x00\x08\x00",
+		"\x00\x01\x01\x00\x00\x00\x01\x18\x00\x00\x00\x00\x04\x00\x04\x00\x08\x00",
+	} {
+		// Only the header is present, so decoding fails after sniffing
+		_, format, err := image.DecodeConfig(bytes.NewReader([]byte(header)))
+		if err == image.ErrFormat {
+			t.Errorf("Expected the header %q to be sniffed as tga", header)
+			continue
+		}
+		if format != "tga" {
+			t.Errorf("Expected format %q; got %q", "tga", format)
+		}
+	}
+}
+
+// A reader that counts the bytes read from it
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestDecodeConfigReadsHeaderOnly(t *testing.T) {
+	f, err := os.Open("../testdata/subimages.exr")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer f.Close()
+
+	// The image is followed by an endless stream of zeros
+	r := &countingReader{r: io.MultiReader(f, zeroReader{})}
+	image.DecodeConfig(r)
+
+	// image.DecodeConfig buffers up to 4KB ahead of the decoder
+	if limit := MaxHeaderSize + 4096; r.n > limit {
+		t.Errorf("Expected at most %d bytes to be read; got %d", limit, r.n)
+	}
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// Read the expected config directly with an ImageInput
+func readConfig(t *testing.T, path string) image.Config {
+	in, err := oiio.OpenImageInput(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer in.Close()
+
+	spec := in.Spec()
+	return image.Config{
+		ColorModel: spec.ColorModel(),
+		Width:      spec.Width(),
+		Height:     spec.Height(),
+	}
+}
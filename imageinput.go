@@ -9,7 +9,10 @@ package oiio
 import "C"
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"runtime"
 	"unsafe"
 )
@@ -17,10 +20,13 @@ import (
 // ImageInput abstracts the reading of an image file in a file format-agnostic manner.
 type ImageInput struct {
 	ptr unsafe.Pointer
+
+	// Source of an ImageInput opened with OpenImageInputReader
+	stream *stream
 }
 
 func newImageInput(i unsafe.Pointer) *ImageInput {
-	in := &ImageInput{i}
+	in := &ImageInput{ptr: i}
 	runtime.SetFinalizer(in, deleteImageInput)
 	return in
 }
@@ -36,13 +42,125 @@ func deleteImageInput(i *ImageInput) {
 // Create an ImageInput subclass instance that is able to read the given file and open it,
 // returning the opened ImageInput if successful. If it fails, return error.
 func OpenImageInput(filename string) (*ImageInput, error) {
+	return OpenImageInputConfig(filename, nil)
+}
+
+// OpenImageInputConfig creates an ImageInput that is able to read the given
+// file and opens it, as with OpenImageInput, passing the attributes of config
+// to the reader as hints about how to read the file. Readers ignore any
+// hints they do not understand. Common hints include:
+//    "oiio:UnassociatedAlpha" (int)   Do not premultiply the color
+//                                     channels by alpha, if the file
+//                                     stores unassociated alpha.
+//    "oiio:RawColor" (int)            Do not convert the color values
+//                                     from the color space of the file.
+//    "raw:*"                          Camera raw decoding options.
+//
+// A nil config is the same as OpenImageInput.
+func OpenImageInputConfig(filename string, config *ImageSpec) (*ImageInput, error) {
 	c_str := C.CString(filename)
 	defer C.free(unsafe.Pointer(c_str))
 
-	cfg := unsafe.Pointer(nil)
+	var cfg unsafe.Pointer
+	if config != nil {
+		cfg = config.ptr
+	}
 	ptr := C.ImageInput_Open(c_str, cfg)
+	if ptr == nil {
+		return nil, openInputError(filename)
+	}
+
+	in := newImageInput(ptr)
+
+	return in, in.LastError()
+}
+
+// CreateImageInput creates an ImageInput that is able to read the given
+// file, and opens it. Format plugins are searched for in pluginPath, a
+// colon-separated list of directories, before the default plugin path.
+// This allows formats that are not built into OpenImageIO to be read.
+func CreateImageInput(filename, pluginPath string) (*ImageInput, error) {
+	c_str := C.CString(filename)
+	defer C.free(unsafe.Pointer(c_str))
+
+	c_path := C.CString(pluginPath)
+	defer C.free(unsafe.Pointer(c_path))
+
+	ptr := C.ImageInput_Create(c_str, c_path)
+	if ptr == nil {
+		return nil, openInputError(filename)
+	}
+
+	in := newImageInput(ptr)
+
+	spec := NewImageSpec(TypeUnknown())
+	if !bool(C.ImageInput_open(in.ptr, c_str, spec.ptr)) {
+		err := in.LastError()
+		if err == nil {
+			err = fmt.Errorf("Could not open %q for reading", filename)
+		}
+		return nil, err
+	}
+
+	return in, nil
+}
+
+// Return the error for an ImageInput that could not be created.
+func openInputError(filename string) error {
+	if err := globalError(); err != nil {
+		return err
+	}
+	return fmt.Errorf("Could not open %q for reading", filename)
+}
+
+// OpenImageInputReader creates an ImageInput that reads an image from r,
+// instead of from a file, and opens it. The size is the total number of
+// bytes that can be read from r. The name is used to choose the format by
+// its extension (ie. "image.exr"), and may also be just the format name
+// or extension (ie. "exr"). If name is empty, every format is tried.
+//
+// The image is read from r as needed, through an OpenImageIO IOProxy,
+// without copying it to a file. This requires OpenImageIO 2.0 or newer,
+// and a format that Supports("ioproxy"); an error is returned otherwise.
+func OpenImageInputReader(name string, r io.ReaderAt, size int64) (*ImageInput, error) {
+	if err := checkIOProxy(); err != nil {
+		return nil, err
+	}
+
+	if name != "" {
+		return openImageInputProxy(name, r, size)
+	}
+
+	for _, format := range formatNames() {
+		if !inputSupportsIOProxy(format) {
+			continue
+		}
+		if in, err := openImageInputProxy(format, r, size); err == nil {
+			return in, nil
+		}
+	}
+	return nil, errors.New("No format that can read from memory recognized the image")
+}
+
+// Open an ImageInput that reads from r through an IOProxy. The IOProxy is
+// released when the ImageInput is closed.
+func openImageInputProxy(name string, r io.ReaderAt, size int64) (*ImageInput, error) {
+	if !inputSupportsIOProxy(name) {
+		return nil, fmt.Errorf("The format of %q can not read from memory", name)
+	}
+
+	c_str := C.CString(name)
+	defer C.free(unsafe.Pointer(c_str))
+
+	s := newReaderStream(name, r, size)
+	ptr := unsafe.Pointer(C.ImageInput_Open_IOProxy(c_str, s.proxy))
+	if ptr == nil {
+		s.release()
+		return nil, openInputError(name)
+	}
 
 	in := newImageInput(ptr)
+	in.stream = s
 
 	return in, in.LastError()
 }
@@ -79,7 +197,12 @@ func (i *ImageInput) Open(filename string) error {
 
 // Close an image that we are totally done with.
 func (i *ImageInput) Close() error {
-	if !bool(C.ImageInput_close(i.ptr)) {
+	ok := bool(C.ImageInput_close(i.ptr))
+	if i.stream != nil {
+		i.stream.release()
+		i.stream = nil
+	}
+	if !ok {
 		return i.LastError()
 	}
 	return nil
@@ -179,7 +302,7 @@ func (i *ImageInput) SeekSubimage(index int, newSpec *ImageSpec) bool {
 	}
 
 	if newSpec == nil || newSpec.ptr == nil {
-		newSpec = NewImageSpec(TypeUnknown)
+		newSpec = NewImageSpec(TypeUnknown())
 	}
 
 	ok := C.ImageInput_seek_subimage(i.ptr, C.int(index), newSpec.ptr)
@@ -213,7 +336,7 @@ func (i *ImageInput) SeekMipLevel(subimage, miplevel int, newSpec *ImageSpec) bo
 	}
 
 	if newSpec == nil || newSpec.ptr == nil {
-		newSpec = NewImageSpec(TypeUnknown)
+		newSpec = NewImageSpec(TypeUnknown())
 	}
 
 	ok := C.ImageInput_seek_subimage_miplevel(i.ptr, C.int(subimage), C.int(miplevel), newSpec.ptr)
@@ -242,22 +365,22 @@ func (i *ImageInput) ReadImage() ([]float32, error) {
 //
 // The underlying type of data is determined by the given TypeDesc.
 // Returned interface{} will be:
-//     TypeUint8   => []uint8
-//     TypeInt8    => []int8
-//     TypeUint16  => []uint16
-//     TypeInt16   => []int16
-//     TypeUint    => []uint
-//     TypeInt     => []int
-//     TypeUint64  => []uint64
-//     TypeInt64   => []int64
-//     TypeHalf    => []float32
-//     TypeFloat   => []float32
-//     TypeDouble  => []float64
+//     TypeUint8()   => []uint8
+//     TypeInt8()    => []int8
+//     TypeUint16()  => []uint16
+//     TypeInt16()   => []int16
+//     TypeUint()    => []uint
+//     TypeInt()     => []int
+//     TypeUint64()  => []uint64
+//     TypeInt64()   => []int64
+//     TypeHalf()    => []float32
+//     TypeFloat()   => []float32
+//     TypeDouble()  => []float64
 //
 // Example:
 //
 //     // Without a callback
-//     val, err := in.ReadImageFormat(TypeFloat, nil)
+//     val, err := in.ReadImageFormat(TypeFloat(), nil)
 //     if err != nil {
 //         panic(err.Error())
 //     }
@@ -269,7 +392,7 @@ func (i *ImageInput) ReadImage() ([]float32, error) {
 //         // Keep processing (return true to abort)
 //         return false
 //     }
-//     val, _ = in.ReadImageFormat(TypeFloat, &cbk)
+//     val, _ = in.ReadImageFormat(TypeFloat(), &cbk)
 //     floatPixels = val.([]float32)
 //
 func (i *ImageInput) ReadImageFormat(format TypeDesc, progress *ProgressCallback) (interface{}, error) {
@@ -280,14 +403,33 @@ func (i *ImageInput) ReadImageFormat(format TypeDesc, progress *ProgressCallback
 		return nil, err
 	}
 
-	var cbk unsafe.Pointer = nil
-	if progress != nil {
-		cbk = unsafe.Pointer(progress)
+	cbk := registerProgress(progress)
+
+	C.ImageInput_read_image_format(i.ptr, format.c(), ptr, C.uintptr_t(cbk))
+
+	err = i.LastError()
+	if perr := releaseProgress(cbk); perr != nil {
+		err = perr
 	}
 
-	C.ImageInput_read_image_format(i.ptr, (C.TypeDesc)(format), ptr, cbk)
+	return pixel_iface, err
+}
+
+// ReadImageFormatContext is ReadImageFormat, but aborts the read and returns
+// the error of ctx once ctx is done. The optional progress callback is
+// still called while the read is in progress.
+func (i *ImageInput) ReadImageFormatContext(ctx context.Context, format TypeDesc,
+	progress *ProgressCallback) (interface{}, error) {
 
-	return pixel_iface, i.LastError()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pixels, err := i.ReadImageFormat(format, contextProgress(ctx, progress))
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	return pixels, err
 }
 
 // Read the scanline that includes pixels (*,y,z), converting if necessary
@@ -319,3 +461,226 @@ func (i *ImageInput) ReadTile(x, y, z int) ([]float32, error) {
 
 	return pixels, i.LastError()
 }
+
+// Read all scanlines that include pixels (*,y,z) for ybegin <= y < yend,
+// and only channels [chbegin,chend), converting if necessary from the
+// native data format of the file into contiguous pixels of the
+// requested format (z==0 for non-volume images).
+// The size of the slice is: width * (yend-ybegin) * (chend-chbegin)
+//
+// The type of the returned slice follows the same rules as ReadImageFormat.
+func (i *ImageInput) ReadScanlinesFormat(ybegin, yend, z, chbegin, chend int, format TypeDesc) (interface{}, error) {
+	return i.ReadScanlinesStrides(ybegin, yend, z, chbegin, chend, format, AutoStride, AutoStride)
+}
+
+// Read scanlines as with ReadScanlinesFormat, but with explicit strides
+// (in bytes) between adjacent pixels and scanlines of the returned slice.
+// Any stride may be AutoStride to have it computed for contiguous data.
+// The returned slice is sized to hold the last pixel of the last scanline.
+//
+// For example, an xstride of 4 bytes when reading 3 channels as TypeUint8()
+// leaves room for a fourth channel, which is left as zero.
+func (i *ImageInput) ReadScanlinesStrides(ybegin, yend, z, chbegin, chend int, format TypeDesc,
+	xstride, ystride int) (interface{}, error) {
+
+	memformat := pixelBufferMemoryFormat(format)
+	elemsize, err := pixelViewElemSize(memformat)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := i.Spec()
+	size, err := stridedBufferSize(spec.Width(), yend-ybegin, 1, chend-chbegin,
+		elemsize, xstride, ystride, AutoStride)
+	if err != nil {
+		return nil, err
+	}
+
+	pixel_iface, ptr, err := allocatePixelBufferSize(size, format)
+	if err != nil {
+		return nil, err
+	}
+
+	ok := C.ImageInput_read_scanlines_format(i.ptr, C.int(ybegin), C.int(yend), C.int(z),
+		C.int(chbegin), C.int(chend), memformat.c(), ptr,
+		C.stride_t(xstride), C.stride_t(ystride))
+	if !bool(ok) {
+		return nil, i.LastError()
+	}
+	return pixel_iface, nil
+}
+
+// Read the block of tiles that include all pixels and channels in
+// the ROI [xbegin,xend) X [ybegin,yend) X [zbegin,zend) X [chbegin,chend),
+// converting if necessary from the native data format of the file into
+// contiguous pixels of the requested format.
+// The begin/end pairs must correctly delineate tile boundaries, with the
+// exception that they may also be the image edges.
+// The size of the slice is:
+// (xend-xbegin) * (yend-ybegin) * (zend-zbegin) * (chend-chbegin)
+//
+// The type of the returned slice follows the same rules as ReadImageFormat.
+func (i *ImageInput) ReadTilesFormat(xbegin, xend, ybegin, yend, zbegin, zend,
+	chbegin, chend int, format TypeDesc) (interface{}, error) {
+
+	return i.ReadTilesStrides(xbegin, xend, ybegin, yend, zbegin, zend, chbegin, chend,
+		format, AutoStride, AutoStride, AutoStride)
+}
+
+// Read tiles as with ReadTilesFormat, but with explicit strides (in bytes)
+// between adjacent pixels, scanlines, and volumetric slices of the
+// returned slice. Any stride may be AutoStride to have it computed for
+// contiguous data. The returned slice is sized to hold the last pixel
+// of the region.
+func (i *ImageInput) ReadTilesStrides(xbegin, xend, ybegin, yend, zbegin, zend,
+	chbegin, chend int, format TypeDesc, xstride, ystride, zstride int) (interface{}, error) {
+
+	memformat := pixelBufferMemoryFormat(format)
+	elemsize, err := pixelViewElemSize(memformat)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := stridedBufferSize(xend-xbegin, yend-ybegin, zend-zbegin, chend-chbegin,
+		elemsize, xstride, ystride, zstride)
+	if err != nil {
+		return nil, err
+	}
+
+	pixel_iface, ptr, err := allocatePixelBufferSize(size, format)
+	if err != nil {
+		return nil, err
+	}
+
+	ok := C.ImageInput_read_tiles_format(i.ptr,
+		C.int(xbegin), C.int(xend),
+		C.int(ybegin), C.int(yend),
+		C.int(zbegin), C.int(zend),
+		C.int(chbegin), C.int(chend),
+		memformat.c(), ptr,
+		C.stride_t(xstride), C.stride_t(ystride), C.stride_t(zstride))
+	if !bool(ok) {
+		return nil, i.LastError()
+	}
+	return pixel_iface, nil
+}
+
+// Read the scanline that includes pixels (*,y,z) into raw bytes, in
+// the native data format of the file, without any conversion (z==0 for
+// non-volume images). If the file has per-channel formats, each pixel
+// holds its channels in their own formats, as given by
+// Spec().ChannelFormat(). Use ImageSpec.DecodeNativePixels to split the
+// data into typed per-channel slices.
+// The size of the slice is: Spec().ScanlineBytes(true)
+func (i *ImageInput) ReadNativeScanline(y, z int) ([]byte, error) {
+	size := i.Spec().ScanlineBytes(true)
+	if size <= 0 {
+		return nil, fmt.Errorf("Invalid native scanline size %d", size)
+	}
+
+	data := make([]byte, size)
+	ok := C.ImageInput_read_native_scanline(i.ptr, C.int(y), C.int(z), unsafe.Pointer(&data[0]))
+	if !bool(ok) {
+		return nil, i.LastError()
+	}
+	return data, nil
+}
+
+// Read the tile whose upper-left origin is (x,y,z) into raw bytes, in
+// the native data format of the file, without any conversion, as with
+// ReadNativeScanline (z==0 for non-volume images).
+// The size of the slice is: Spec().TileBytes(true)
+func (i *ImageInput) ReadNativeTile(x, y, z int) ([]byte, error) {
+	size := i.Spec().TileBytes(true)
+	if size <= 0 {
+		return nil, fmt.Errorf("Invalid native tile size %d", size)
+	}
+
+	data := make([]byte, size)
+	ok := C.ImageInput_read_native_tile(i.ptr, C.int(x), C.int(y), C.int(z), unsafe.Pointer(&data[0]))
+	if !bool(ok) {
+		return nil, i.LastError()
+	}
+	return data, nil
+}
+
+// Read the block of tiles that include all pixels in
+// [xbegin,xend) X [ybegin,yend) X [zbegin,zend) into raw bytes, in
+// the native data format of the file, without any conversion, as with
+// ReadNativeScanline. The begin/end pairs must correctly delineate tile
+// boundaries, with the exception that they may also be the image edges.
+// The size of the slice is:
+// (xend-xbegin) * (yend-ybegin) * (zend-zbegin) * Spec().PixelBytes(true)
+func (i *ImageInput) ReadNativeTiles(xbegin, xend, ybegin, yend, zbegin, zend int) ([]byte, error) {
+	size := (xend - xbegin) * (yend - ybegin) * (zend - zbegin) * i.Spec().PixelBytes(true)
+	if size <= 0 {
+		return nil, fmt.Errorf("Invalid native tiles size %d", size)
+	}
+
+	data := make([]byte, size)
+	ok := C.ImageInput_read_native_tiles(i.ptr,
+		C.int(xbegin), C.int(xend),
+		C.int(ybegin), C.int(yend),
+		C.int(zbegin), C.int(zend),
+		unsafe.Pointer(&data[0]))
+	if !bool(ok) {
+		return nil, i.LastError()
+	}
+	return data, nil
+}
+
+// Read native deep data from the scanlines that include pixels (*,y,z)
+// for all ybegin <= y < yend, and channels [chbegin,chend).
+// Pixels in the returned DeepData are indexed from the first pixel
+// of scanline ybegin.
+func (i *ImageInput) ReadNativeDeepScanlines(ybegin, yend, z, chbegin, chend int) (*DeepData, error) {
+	if err := checkDeepData(); err != nil {
+		return nil, err
+	}
+
+	dd := newDeepData(C.DeepData_New())
+	ok := C.ImageInput_read_native_deep_scanlines(i.ptr, C.int(ybegin), C.int(yend), C.int(z),
+		C.int(chbegin), C.int(chend), dd.ptr)
+	if !bool(ok) {
+		return nil, i.LastError()
+	}
+	return dd, nil
+}
+
+// Read native deep data from the block of tiles that include all pixels
+// in [xbegin,xend) X [ybegin,yend) X [zbegin,zend), and channels [chbegin,chend).
+// The begin/end pairs must correctly delineate tile boundaries.
+func (i *ImageInput) ReadNativeDeepTiles(xbegin, xend, ybegin, yend, zbegin, zend,
+	chbegin, chend int) (*DeepData, error) {
+
+	if err := checkDeepData(); err != nil {
+		return nil, err
+	}
+
+	dd := newDeepData(C.DeepData_New())
+	ok := C.ImageInput_read_native_deep_tiles(i.ptr,
+		C.int(xbegin), C.int(xend),
+		C.int(ybegin), C.int(yend),
+		C.int(zbegin), C.int(zend),
+		C.int(chbegin), C.int(chend),
+		dd.ptr)
+	if !bool(ok) {
+		return nil, i.LastError()
+	}
+	return dd, nil
+}
+
+// Read the entire deep data image of the current subimage and MIP level,
+// for all channels. Scanlines or tiles are read automatically.
+func (i *ImageInput) ReadNativeDeepImage() (*DeepData, error) {
+	if err := checkDeepData(); err != nil {
+		return nil, err
+	}
+
+	dd := newDeepData(C.DeepData_New())
+	ok := C.ImageInput_read_native_deep_image(i.ptr, dd.ptr)
+	if !bool(ok) {
+		return nil, i.LastError()
+	}
+	return dd, nil
+}
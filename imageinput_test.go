@@ -1,6 +1,11 @@
 package oiio
 
 import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
 	"testing"
 )
 
@@ -34,6 +39,91 @@ func TestOpenImageInput(t *testing.T) {
 
 }
 
+func TestOpenImageInputConfig(t *testing.T) {
+	config := NewImageSpec(TypeUnknown())
+	checkFatalError(t, config.SetAttribute("oiio:UnassociatedAlpha", 1))
+
+	in, err := OpenImageInputConfig(TEST_IMAGE, config)
+	checkFatalError(t, err)
+	defer in.Close()
+
+	if actual := in.FormatName(); actual != "png" {
+		t.Errorf("Expected FormatName 'png' but got %q", actual)
+	}
+
+	in, err = OpenImageInputConfig(TEST_IMAGE, nil)
+	checkFatalError(t, err)
+	in.Close()
+
+	if _, err = OpenImageInputConfig("testdata/missing.exr", config); err == nil {
+		t.Error("Expected an error opening a missing file")
+	}
+}
+
+func TestCreateImageInput(t *testing.T) {
+	expected, err := OpenImageInput(TEST_IMAGE)
+	checkFatalError(t, err)
+	defer expected.Close()
+
+	expectedPixels, err := expected.ReadImage()
+	checkFatalError(t, err)
+
+	in, err := CreateImageInput(TEST_IMAGE, os.TempDir())
+	checkFatalError(t, err)
+	defer in.Close()
+
+	if actual := in.FormatName(); actual != "png" {
+		t.Errorf("Expected FormatName 'png' but got %q", actual)
+	}
+
+	pixels, err := in.ReadImage()
+	checkFatalError(t, err)
+	if !reflect.DeepEqual(expectedPixels, pixels) {
+		t.Error("Pixels read from the created ImageInput do not match OpenImageInput")
+	}
+
+	if _, err = CreateImageInput("testdata/missing.exr", ""); err == nil {
+		t.Error("Expected an error opening a missing file")
+	}
+}
+
+func TestOpenImageInputReader(t *testing.T) {
+	requireIOProxy(t, "png")
+
+	data, err := ioutil.ReadFile(TEST_IMAGE)
+	checkFatalError(t, err)
+
+	expected, err := OpenImageInput(TEST_IMAGE)
+	checkFatalError(t, err)
+	defer expected.Close()
+
+	expectedPixels, err := expected.ReadImage()
+	checkFatalError(t, err)
+
+	for _, name := range []string{"image.png", "png", ""} {
+		in, err := OpenImageInputReader(name, bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("%q: %s", name, err.Error())
+		}
+
+		if in.FormatName() != "png" {
+			t.Errorf("%q: Expected format png; got %q", name, in.FormatName())
+		}
+
+		pixels, err := in.ReadImage()
+		checkFatalError(t, err)
+		if !reflect.DeepEqual(expectedPixels, pixels) {
+			t.Errorf("%q: Pixels read from the reader do not match the file", name)
+		}
+
+		checkFatalError(t, in.Close())
+	}
+
+	if _, err = OpenImageInputReader("png", bytes.NewReader([]byte("not a png")), 9); err == nil {
+		t.Error("Expected an error when reading an invalid image")
+	}
+}
+
 func TestImageInputReadImage(t *testing.T) {
 	in, err := OpenImageInput(TEST_IMAGE)
 	if err != nil {
@@ -61,7 +151,7 @@ func TestImageInputReadImageFormat(t *testing.T) {
 
 	// nil Callback read
 	//
-	pixel_iface, err = in.ReadImageFormat(TypeFloat, nil)
+	pixel_iface, err = in.ReadImageFormat(TypeFloat(), nil)
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -82,7 +172,7 @@ func TestImageInputReadImageFormat(t *testing.T) {
 		return false
 	}
 
-	pixel_iface, err = in.ReadImageFormat(TypeFloat, &progress)
+	pixel_iface, err = in.ReadImageFormat(TypeFloat(), &progress)
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -99,7 +189,7 @@ func TestImageInputReadImageFormat(t *testing.T) {
 		return true
 	}
 
-	pixel_iface, err = in.ReadImageFormat(TypeFloat, &progress)
+	pixel_iface, err = in.ReadImageFormat(TypeFloat(), &progress)
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -111,6 +201,42 @@ func TestImageInputReadImageFormat(t *testing.T) {
 
 }
 
+func TestImageInputReadImageFormatContext(t *testing.T) {
+	in, err := OpenImageInput(TEST_IMAGE)
+	checkFatalError(t, err)
+	defer in.Close()
+
+	expected, err := in.ReadImageFormat(TypeUint8(), nil)
+	checkFatalError(t, err)
+
+	actual, err := in.ReadImageFormatContext(context.Background(), TypeUint8(), nil)
+	checkFatalError(t, err)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Error("Expected pixels to match ReadImageFormat")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err = in.ReadImageFormatContext(ctx, TypeUint8(), nil); err != context.Canceled {
+		t.Errorf("Expected error %v; got %v", context.Canceled, err)
+	}
+
+	// Cancelled from the progress callback, while the read is in progress
+	ctx, cancel = context.WithCancel(context.Background())
+	var cancelling ProgressCallback = func(done float32) bool {
+		cancel()
+		return false
+	}
+	pixels, err := in.ReadImageFormatContext(ctx, TypeUint8(), &cancelling)
+	if err != context.Canceled {
+		t.Errorf("Expected error %v; got %v", context.Canceled, err)
+	}
+	if pixels != nil {
+		t.Errorf("Expected no pixels from a cancelled read; got %T", pixels)
+	}
+}
+
 func TestImageInputReadScanline(t *testing.T) {
 	in, err := OpenImageInput(TEST_IMAGE)
 	if err != nil {
@@ -157,6 +283,137 @@ func TestImageInputReadTile(t *testing.T) {
 	}
 }
 
+func TestImageInputReadScanlinesFormat(t *testing.T) {
+	in, err := OpenImageInput(TEST_IMAGE)
+	checkFatalError(t, err)
+	defer in.Close()
+
+	width := in.Spec().Width()
+
+	// Only the blue channel
+	iface, err := in.ReadScanlinesFormat(16, 18, 0, 2, 3, TypeUint8())
+	checkFatalError(t, err)
+
+	pixels, ok := iface.([]uint8)
+	if !ok {
+		t.Fatalf("Expected []uint8 pixels; got %T", iface)
+	}
+	if len(pixels) != width*2 {
+		t.Fatalf("Expected %d values; got %d", width*2, len(pixels))
+	}
+	if pixels[0] != 128 || pixels[16] != 255 {
+		t.Errorf("Expected blue values 128 and 255; got %d and %d", pixels[0], pixels[16])
+	}
+
+	// RGB into 4-channel pixels
+	iface, err = in.ReadScanlinesStrides(16, 17, 0, 0, 3, TypeUint8(), 4, AutoStride)
+	checkFatalError(t, err)
+
+	pixels = iface.([]uint8)
+	if expected := (width-1)*4 + 3; len(pixels) != expected {
+		t.Fatalf("Expected %d values; got %d", expected, len(pixels))
+	}
+	if actual := pixels[16*4 : 16*4+4]; !reflect.DeepEqual(actual, []uint8{0, 0, 255, 0}) {
+		t.Errorf("Expected strided pixel [0 0 255 0]; got %v", actual)
+	}
+
+	if _, err = in.ReadScanlinesFormat(0, 1, 0, 0, 3, TypeUnknown()); err == nil {
+		t.Error("Expected an error reading with an invalid format")
+	}
+}
+
+func TestImageInputReadTilesFormat(t *testing.T) {
+	in, err := OpenImageInput(`testdata/checker_mip.tx`)
+	checkFatalError(t, err)
+	defer in.Close()
+
+	size := in.Spec().TileWidth()
+
+	expected, err := in.ReadTile(size, 0, 0)
+	checkFatalError(t, err)
+
+	iface, err := in.ReadTilesFormat(0, size*2, 0, size, 0, 1, 0, 1, TypeFloat())
+	checkFatalError(t, err)
+
+	pixels := iface.([]float32)
+	if len(pixels) != size*size*2 {
+		t.Fatalf("Expected %d values; got %d", size*size*2, len(pixels))
+	}
+	for y := 0; y < size; y++ {
+		actual := pixels[y*size*2+size : (y+1)*size*2]
+		if !reflect.DeepEqual(actual, expected[y*size:(y+1)*size]) {
+			t.Fatalf("Scanline %d of the second tile does not match ReadTile", y)
+		}
+	}
+
+	// The first tile, spread over every other value
+	iface, err = in.ReadTilesStrides(0, size, 0, size, 0, 1, 0, 1, TypeUint16(), 4, AutoStride, AutoStride)
+	checkFatalError(t, err)
+
+	strided := iface.([]uint16)
+	if len(strided) != size*size*2-1 {
+		t.Fatalf("Expected %d values; got %d", size*size*2-1, len(strided))
+	}
+	if strided[1] != 0 {
+		t.Errorf("Expected the gaps between strided pixels to be 0; got %d", strided[1])
+	}
+}
+
+func TestImageInputReadNative(t *testing.T) {
+	in, err := OpenImageInput(TEST_IMAGE)
+	checkFatalError(t, err)
+	defer in.Close()
+
+	spec := in.Spec()
+
+	data, err := in.ReadNativeScanline(16, 0)
+	checkFatalError(t, err)
+	if len(data) != spec.ScanlineBytes(true) {
+		t.Fatalf("Expected %d bytes; got %d", spec.ScanlineBytes(true), len(data))
+	}
+
+	expected, err := in.ReadScanlinesFormat(16, 17, 0, 0, spec.NumChannels(), TypeUint8())
+	checkFatalError(t, err)
+	if !bytes.Equal(data, expected.([]uint8)) {
+		t.Error("Expected native scanline to match the uint8 scanline")
+	}
+
+	// Tiles
+	in, err = OpenImageInput(`testdata/checker_mip.tx`)
+	checkFatalError(t, err)
+	defer in.Close()
+
+	spec = in.Spec()
+	size := spec.TileWidth()
+
+	data, err = in.ReadNativeTile(size, 0, 0)
+	checkFatalError(t, err)
+	if len(data) != spec.TileBytes(true) {
+		t.Fatalf("Expected %d bytes; got %d", spec.TileBytes(true), len(data))
+	}
+
+	tiles, err := in.ReadNativeTiles(0, size*2, 0, size, 0, 1)
+	checkFatalError(t, err)
+	if len(tiles) != size*size*2*spec.PixelBytes(true) {
+		t.Fatalf("Expected %d bytes; got %d", size*size*2*spec.PixelBytes(true), len(tiles))
+	}
+
+	expected, err = in.ReadTilesFormat(0, size*2, 0, size, 0, 1, 0, spec.NumChannels(), TypeUint8())
+	checkFatalError(t, err)
+	if !bytes.Equal(tiles, expected.([]uint8)) {
+		t.Error("Expected native tiles to match the uint8 tiles")
+	}
+
+	channels, err := spec.DecodeNativePixels(data)
+	checkFatalError(t, err)
+	if len(channels) != spec.NumChannels() {
+		t.Fatalf("Expected %d channels; got %d", spec.NumChannels(), len(channels))
+	}
+	if !bytes.Equal(channels[0].([]uint8), data) {
+		t.Error("Expected decoded single channel to match the native tile")
+	}
+}
+
 func TestImageInputSubimage(t *testing.T) {
 	filepath := `testdata/subimages.exr`
 	in, err := OpenImageInput(filepath)
@@ -179,7 +436,7 @@ func TestImageInputSubimage(t *testing.T) {
 	}
 
 	expected = 2
-	newSpec := NewImageSpec(TypeUnknown)
+	newSpec := NewImageSpec(TypeUnknown())
 	ok = in.SeekSubimage(expected, newSpec)
 	if !ok {
 		t.Fatalf("Failed while seeking to subimage %d", expected)
@@ -219,7 +476,7 @@ func TestImageInputMipLevel(t *testing.T) {
 	}
 
 	expected = 2
-	newSpec := NewImageSpec(TypeUnknown)
+	newSpec := NewImageSpec(TypeUnknown())
 	ok = in.SeekMipLevel(0, expected, newSpec)
 	if !ok {
 		t.Fatalf("Failed while seeking to mip level %d", expected)
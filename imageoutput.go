@@ -10,23 +10,43 @@ import "C"
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"runtime"
 	"unsafe"
 )
 
+// Modes that may be passed to ImageOutput.Open()
+type OpenMode int
+
+const (
+	// Create a new file, or overwrite an existing file
+	OpenModeCreate OpenMode = C.OPENMODE_CREATE
+	// Append a new subimage to a file that is already open.
+	// Requires Supports("multiimage") and Supports("appendsubimage")
+	OpenModeAppendSubimage OpenMode = C.OPENMODE_APPENDSUBIMAGE
+	// Append a new MIP level to the current subimage of a file that
+	// is already open. Requires Supports("mipmap")
+	OpenModeAppendMIPLevel OpenMode = C.OPENMODE_APPENDMIPLEVEL
+)
+
 // ImageOutput abstracts the writing of an image file in a file format-agnostic manner.
 type ImageOutput struct {
 	ptr unsafe.Pointer
+
+	// Destination of an ImageOutput opened with OpenWriter
+	stream *stream
 }
 
 func newImageOutput(i unsafe.Pointer) *ImageOutput {
-	in := &ImageOutput{i}
+	in := &ImageOutput{ptr: i}
 	runtime.SetFinalizer(in, deleteImageOutput)
 	return in
 }
 
 func deleteImageOutput(i *ImageOutput) {
 	if i.ptr != nil {
+		C.ImageOutput_close(i.ptr)
 		C.free(i.ptr)
 		i.ptr = nil
 	}
@@ -36,15 +56,27 @@ func deleteImageOutput(i *ImageOutput) {
 // inferred from the extension of the name. This just creates the ImageOutput, it
 // does not open the file.
 func OpenImageOutput(filename string) (*ImageOutput, error) {
-	c_str := C.CString(filename)
+	return CreateImageOutput(filename, "")
+}
 
-	// TODO: Provide optional argument for plugin path
-	c_path := C.CString("")
+// CreateImageOutput creates an ImageOutput that will write to a file, as
+// with OpenImageOutput. Format plugins are searched for in pluginPath, a
+// colon-separated list of directories, before the default plugin path.
+// This allows formats that are not built into OpenImageIO to be written.
+func CreateImageOutput(filename, pluginPath string) (*ImageOutput, error) {
+	c_str := C.CString(filename)
+	c_path := C.CString(pluginPath)
 
 	defer C.free(unsafe.Pointer(c_str))
 	defer C.free(unsafe.Pointer(c_path))
 
 	ptr := C.ImageOutput_Create(c_str, c_path)
+	if ptr == nil {
+		if err := globalError(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("Could not create an ImageOutput for %q", filename)
+	}
 
 	out := newImageOutput(ptr)
 
@@ -123,3 +155,360 @@ func (i *ImageOutput) Spec() *ImageSpec {
 func (i *ImageOutput) FormatName() string {
 	return C.GoString(C.ImageOutput_format_name(i.ptr))
 }
+
+// Open the file with the given name, with resolution and other format
+// data as given in spec. An error is returned if the file could not be
+// opened, or if the spec describes something the format cannot support.
+// The mode describes whether a new file is created (OpenModeCreate), or
+// whether a new subimage (OpenModeAppendSubimage) or MIP level
+// (OpenModeAppendMIPLevel) is appended to the file that is already open.
+func (i *ImageOutput) Open(filename string, spec *ImageSpec, mode OpenMode) error {
+	c_str := C.CString(filename)
+	defer C.free(unsafe.Pointer(c_str))
+
+	ok := C.ImageOutput_open(i.ptr, c_str, spec.ptr, C.OpenMode(mode))
+	if !bool(ok) {
+		return i.LastError()
+	}
+	return nil
+}
+
+// Open a multi-subimage file with the given name, with the specs
+// for every subimage known up front. This is required by formats that
+// do not Supports("appendsubimage"), and need to know about all of the
+// subimages before writing the first one.
+//
+// After OpenMulti, the pixels of the first subimage are written, and
+// each subsequent subimage is started with
+// Open(filename, specs[n], OpenModeAppendSubimage).
+func (i *ImageOutput) OpenMulti(filename string, specs []*ImageSpec) error {
+	if len(specs) == 0 {
+		return errors.New("OpenMulti requires at least one ImageSpec")
+	}
+
+	c_str := C.CString(filename)
+	defer C.free(unsafe.Pointer(c_str))
+
+	c_specs := make([]unsafe.Pointer, len(specs))
+	for idx, spec := range specs {
+		c_specs[idx] = spec.ptr
+	}
+
+	ok := C.ImageOutput_open_multi(i.ptr, c_str, C.int(len(specs)), &c_specs[0])
+	if !bool(ok) {
+		return i.LastError()
+	}
+	return nil
+}
+
+// OpenWriter opens the ImageOutput to write a new image to w, instead of
+// to a file, with resolution and other format data as given in spec.
+// The name is passed to the format plugin in place of a file name, and
+// may be a file name (ie. "image.exr") or just the format name (ie. "exr").
+//
+// The image is encoded in memory, through an OpenImageIO IOProxy, since
+// formats such as OpenEXR seek back to patch the file as it is written.
+// The complete image is written to w when the ImageOutput is closed, so
+// Close must always be called, and its error checked.
+//
+// This requires OpenImageIO 2.0 or newer, and a format that
+// Supports("ioproxy"); an error is returned otherwise.
+func (i *ImageOutput) OpenWriter(w io.Writer, formatName string, spec *ImageSpec) error {
+	if i.stream != nil {
+		return errors.New("ImageOutput is already open with a writer")
+	}
+	if err := checkIOProxy(); err != nil {
+		return err
+	}
+	if !i.Supports("ioproxy") {
+		return fmt.Errorf("The %s format can not write to memory", i.FormatName())
+	}
+
+	c_str := C.CString(formatName)
+	defer C.free(unsafe.Pointer(c_str))
+
+	s := newWriterStream(formatName, w)
+	if !bool(C.ImageOutput_open_ioproxy(i.ptr, c_str, spec.ptr, s.proxy)) {
+		s.release()
+		return i.LastError()
+	}
+
+	i.stream = s
+	return nil
+}
+
+// Close an image that we are totally done with. This should leave
+// the file in a valid state, and flush any remaining pixels to disk.
+// If the ImageOutput was opened with OpenWriter, the image is written
+// to the io.Writer.
+func (i *ImageOutput) Close() error {
+	ok := bool(C.ImageOutput_close(i.ptr))
+
+	var err error
+	if !ok {
+		err = i.LastError()
+	}
+
+	if i.stream != nil {
+		if ok {
+			err = i.stream.flush()
+		}
+		i.stream.release()
+		i.stream = nil
+	}
+
+	return err
+}
+
+// Write a full scanline that includes pixels (*,y,z). (z is ignored for
+// 2D non-volume images.) The pixels slice is expected to hold
+// width * channels contiguous values, which are converted from the
+// memory layout of the slice into the data format of the file.
+//
+// The pixels slice may be any of:
+//     []uint8, []int8, []uint16, []int16, []uint32, []int32,
+//     []uint64, []int64, []uint, []int, []float32, []float64
+func (i *ImageOutput) WriteScanline(y, z int, pixels interface{}) error {
+	ptr, size, format, err := pixelBufferInfo(pixels)
+	if err != nil {
+		return err
+	}
+
+	spec := i.Spec()
+	if err = checkPixelBufferSize(size, spec.Width()*spec.NumChannels()); err != nil {
+		return err
+	}
+
+	ok := C.ImageOutput_write_scanline(i.ptr, C.int(y), C.int(z), format.c(), ptr,
+		C.stride_t(AutoStride))
+	if !bool(ok) {
+		return i.LastError()
+	}
+	return nil
+}
+
+// Write multiple scanlines that include pixels (*,y,z) for all
+// ybegin <= y < yend, from the contiguous pixels slice.
+// The slice is expected to hold (yend - ybegin) * width * channels values.
+//
+// The pixels slice may be any of the types accepted by WriteScanline.
+func (i *ImageOutput) WriteScanlines(ybegin, yend, z int, pixels interface{}) error {
+	ptr, size, format, err := pixelBufferInfo(pixels)
+	if err != nil {
+		return err
+	}
+
+	spec := i.Spec()
+	if err = checkPixelBufferSize(size, (yend-ybegin)*spec.Width()*spec.NumChannels()); err != nil {
+		return err
+	}
+
+	ok := C.ImageOutput_write_scanlines(i.ptr, C.int(ybegin), C.int(yend), C.int(z),
+		format.c(), ptr, C.stride_t(AutoStride), C.stride_t(AutoStride))
+	if !bool(ok) {
+		return i.LastError()
+	}
+	return nil
+}
+
+// Write the tile with (x,y,z) as the upper left corner. (z is ignored
+// for 2D non-volume images.) The pixels slice is expected to hold
+// tilewidth * tileheight * tiledepth * channels contiguous values.
+//
+// The pixels slice may be any of the types accepted by WriteScanline.
+func (i *ImageOutput) WriteTile(x, y, z int, pixels interface{}) error {
+	ptr, size, format, err := pixelBufferInfo(pixels)
+	if err != nil {
+		return err
+	}
+
+	spec := i.Spec()
+	if err = checkPixelBufferSize(size, spec.TilePixels()*spec.NumChannels()); err != nil {
+		return err
+	}
+
+	ok := C.ImageOutput_write_tile(i.ptr, C.int(x), C.int(y), C.int(z), format.c(), ptr,
+		C.stride_t(AutoStride), C.stride_t(AutoStride), C.stride_t(AutoStride))
+	if !bool(ok) {
+		return i.LastError()
+	}
+	return nil
+}
+
+// Write the block of multiple tiles that include all pixels in
+// [xbegin,xend) X [ybegin,yend) X [zbegin,zend). The begin/end
+// pairs must correctly delineate tile boundaries, with the exception
+// that it may also be the end of the image data if the image
+// resolution is not a whole multiple of the tile size.
+//
+// The pixels slice may be any of the types accepted by WriteScanline.
+func (i *ImageOutput) WriteTiles(xbegin, xend, ybegin, yend, zbegin, zend int, pixels interface{}) error {
+	ptr, size, format, err := pixelBufferInfo(pixels)
+	if err != nil {
+		return err
+	}
+
+	expected := (xend - xbegin) * (yend - ybegin) * (zend - zbegin) * i.Spec().NumChannels()
+	if err = checkPixelBufferSize(size, expected); err != nil {
+		return err
+	}
+
+	ok := C.ImageOutput_write_tiles(i.ptr,
+		C.int(xbegin), C.int(xend),
+		C.int(ybegin), C.int(yend),
+		C.int(zbegin), C.int(zend),
+		format.c(), ptr,
+		C.stride_t(AutoStride), C.stride_t(AutoStride), C.stride_t(AutoStride))
+	if !bool(ok) {
+		return i.LastError()
+	}
+	return nil
+}
+
+// Write a rectangle of pixels given by the range
+// [xbegin,xend) X [ybegin,yend) X [zbegin,zend).
+// This is only supported by formats that report Supports("rectangles").
+//
+// The pixels slice may be any of the types accepted by WriteScanline.
+func (i *ImageOutput) WriteRectangle(xbegin, xend, ybegin, yend, zbegin, zend int, pixels interface{}) error {
+	ptr, size, format, err := pixelBufferInfo(pixels)
+	if err != nil {
+		return err
+	}
+
+	expected := (xend - xbegin) * (yend - ybegin) * (zend - zbegin) * i.Spec().NumChannels()
+	if err = checkPixelBufferSize(size, expected); err != nil {
+		return err
+	}
+
+	ok := C.ImageOutput_write_rectangle(i.ptr,
+		C.int(xbegin), C.int(xend),
+		C.int(ybegin), C.int(yend),
+		C.int(zbegin), C.int(zend),
+		format.c(), ptr,
+		C.stride_t(AutoStride), C.stride_t(AutoStride), C.stride_t(AutoStride))
+	if !bool(ok) {
+		return i.LastError()
+	}
+	return nil
+}
+
+// Write the entire image of width * height * depth * channels contiguous
+// pixels, converting from the memory layout of the slice into the data
+// format of the file. Scanlines or tiles are written automatically.
+//
+// This call supports passing a callback pointer to both track the progress,
+// and to optionally abort the processing. The callback function will receive
+// a float32 value indicating the percentage done of the processing, and should
+// return true if the process should abort, and false if it should continue.
+//
+// The pixels slice may be any of the types accepted by WriteScanline.
+//
+// Example:
+//
+//     spec := NewImageSpecSize(640, 480, 3, TypeUint8())
+//     out, _ := OpenImageOutput("out.png")
+//     if err := out.Open("out.png", spec, OpenModeCreate); err != nil {
+//         panic(err.Error())
+//     }
+//     pixels := make([]float32, 640*480*3)
+//     if err := out.WriteImage(pixels, nil); err != nil {
+//         panic(err.Error())
+//     }
+//     out.Close()
+//
+func (i *ImageOutput) WriteImage(pixels interface{}, progress *ProgressCallback) error {
+	return i.WriteImageStrides(pixels, AutoStride, AutoStride, AutoStride, progress)
+}
+
+// Write the entire image, as with WriteImage, but with explicit
+// strides (in bytes) between adjacent pixels, scanlines, and volumetric
+// slices of the pixels slice. Any stride may be AutoStride to have it
+// computed for contiguous data. The slice must be long enough to hold
+// the last pixel of the image, as laid out by the strides.
+func (i *ImageOutput) WriteImageStrides(pixels interface{}, xstride, ystride, zstride int,
+	progress *ProgressCallback) error {
+
+	ptr, size, format, err := pixelBufferInfo(pixels)
+	if err != nil {
+		return err
+	}
+
+	elemsize, err := pixelViewElemSize(format)
+	if err != nil {
+		return err
+	}
+
+	spec := i.Spec()
+	expected, err := stridedBufferSize(spec.Width(), spec.Height(), spec.Depth(), spec.NumChannels(),
+		elemsize, xstride, ystride, zstride)
+	if err != nil {
+		return err
+	}
+	if err = checkPixelBufferSize(size, expected); err != nil {
+		return err
+	}
+
+	cbk := registerProgress(progress)
+
+	ok := C.ImageOutput_write_image(i.ptr, format.c(), ptr,
+		C.stride_t(xstride), C.stride_t(ystride), C.stride_t(zstride), C.uintptr_t(cbk))
+	if err := releaseProgress(cbk); err != nil {
+		// Discard the error of the aborted operation
+		i.LastError()
+		return err
+	}
+	if !bool(ok) {
+		return i.LastError()
+	}
+	return nil
+}
+
+// Write deep scanlines containing pixels (*,y,z), for all y in
+// [ybegin,yend), from the deep data. Requires Supports("deepdata").
+func (i *ImageOutput) WriteDeepScanlines(ybegin, yend, z int, deepdata *DeepData) error {
+	if err := checkDeepData(); err != nil {
+		return err
+	}
+
+	ok := C.ImageOutput_write_deep_scanlines(i.ptr, C.int(ybegin), C.int(yend), C.int(z), deepdata.ptr)
+	if !bool(ok) {
+		return i.LastError()
+	}
+	return nil
+}
+
+// Write the block of deep tiles that include all pixels in
+// [xbegin,xend) X [ybegin,yend) X [zbegin,zend), from the deep data.
+// The begin/end pairs must correctly delineate tile boundaries.
+// Requires Supports("deepdata").
+func (i *ImageOutput) WriteDeepTiles(xbegin, xend, ybegin, yend, zbegin, zend int, deepdata *DeepData) error {
+	if err := checkDeepData(); err != nil {
+		return err
+	}
+
+	ok := C.ImageOutput_write_deep_tiles(i.ptr,
+		C.int(xbegin), C.int(xend),
+		C.int(ybegin), C.int(yend),
+		C.int(zbegin), C.int(zend),
+		deepdata.ptr)
+	if !bool(ok) {
+		return i.LastError()
+	}
+	return nil
+}
+
+// Write the entire deep image described by the deep data.
+// Scanlines or tiles are written automatically.
+// Requires Supports("deepdata").
+func (i *ImageOutput) WriteDeepImage(deepdata *DeepData) error {
+	if err := checkDeepData(); err != nil {
+		return err
+	}
+
+	ok := C.ImageOutput_write_deep_image(i.ptr, deepdata.ptr)
+	if !bool(ok) {
+		return i.LastError()
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package oiio
 
 import (
+	"bytes"
+	"os"
+	"reflect"
 	"testing"
 )
 
@@ -19,6 +22,19 @@ func TestOpenImageOutput(t *testing.T) {
 
 }
 
+func TestCreateImageOutput(t *testing.T) {
+	out, err := CreateImageOutput("image.png", os.TempDir())
+	checkFatalError(t, err)
+
+	if actual := out.FormatName(); actual != "png" {
+		t.Errorf("Expected FormatName 'png' but got actual %q", actual)
+	}
+
+	if _, err = CreateImageOutput("image.notaformat", ""); err == nil {
+		t.Error("Expected an error creating an ImageOutput for an unknown format")
+	}
+}
+
 func TestImageOutputSupports(t *testing.T) {
 
 	// Test using .exr format as it has the widest support feature
@@ -55,3 +71,255 @@ func TestImageOutputSupports(t *testing.T) {
 	}
 
 }
+
+func TestImageOutputWriteImage(t *testing.T) {
+	outfile := createOutputFile()
+	defer os.Remove(outfile)
+
+	out, err := OpenImageOutput(outfile)
+	checkFatalError(t, err)
+
+	spec := NewImageSpecSize(32, 16, 3, TypeUint8())
+	checkFatalError(t, out.Open(outfile, spec, OpenModeCreate))
+
+	pixels := make([]float32, 32*16*3)
+	for i := range pixels {
+		pixels[i] = 1
+	}
+
+	var progress ProgressCallback = func(done float32) bool {
+		// no cancel
+		return false
+	}
+
+	checkFatalError(t, out.WriteImage(pixels, &progress))
+	checkFatalError(t, out.Close())
+
+	in, err := OpenImageInput(outfile)
+	checkFatalError(t, err)
+
+	actual, err := in.ReadImage()
+	checkFatalError(t, err)
+
+	if !reflect.DeepEqual(pixels, actual) {
+		t.Fatal("Pixels read back from file do not match the pixels written")
+	}
+
+	if err = out.WriteImage(pixels[:10], nil); err == nil {
+		t.Error("Expected an error when writing a pixel slice that is too small")
+	}
+}
+
+func TestImageOutputWriteImageStrides(t *testing.T) {
+	outfile := createOutputFile()
+	defer os.Remove(outfile)
+
+	out, err := OpenImageOutput(outfile)
+	checkFatalError(t, err)
+
+	spec := NewImageSpecSize(32, 16, 3, TypeUint8())
+	checkFatalError(t, out.Open(outfile, spec, OpenModeCreate))
+
+	// 3 channels, with room for a 4th value per pixel
+	xstride := 4 * 4
+	pixels := make([]float32, 32*16*4)
+	for i := range pixels {
+		if i%4 != 3 {
+			pixels[i] = 1
+		}
+	}
+
+	if err = out.WriteImageStrides(pixels[:32*16*3], xstride, AutoStride, AutoStride, nil); err == nil {
+		t.Error("Expected an error when writing a strided pixel slice that is too small")
+	}
+
+	checkFatalError(t, out.WriteImageStrides(pixels, xstride, AutoStride, AutoStride, nil))
+	checkFatalError(t, out.Close())
+
+	in, err := OpenImageInput(outfile)
+	checkFatalError(t, err)
+
+	actual, err := in.ReadImage()
+	checkFatalError(t, err)
+
+	for i, v := range actual {
+		if v != 1 {
+			t.Fatalf("Expected pixel value 1 at index %d; got %v", i, v)
+		}
+	}
+}
+
+func TestImageOutputWriteScanline(t *testing.T) {
+	outfile := createOutputFile()
+	defer os.Remove(outfile)
+
+	out, err := OpenImageOutput(outfile)
+	checkFatalError(t, err)
+
+	spec := NewImageSpecSize(8, 4, 1, TypeUint8())
+	checkFatalError(t, out.Open(outfile, spec, OpenModeCreate))
+
+	line := make([]uint8, 8)
+	for y := 0; y < 2; y++ {
+		for x := range line {
+			line[x] = uint8(y*8 + x)
+		}
+		checkFatalError(t, out.WriteScanline(y, 0, line))
+	}
+
+	lines := make([]uint8, 2*8)
+	for i := range lines {
+		lines[i] = uint8(16 + i)
+	}
+	checkFatalError(t, out.WriteScanlines(2, 4, 0, lines))
+	checkFatalError(t, out.Close())
+
+	in, err := OpenImageInput(outfile)
+	checkFatalError(t, err)
+
+	iface, err := in.ReadImageFormat(TypeUint8(), nil)
+	checkFatalError(t, err)
+
+	actual := iface.([]uint8)
+	for i, val := range actual {
+		if int(val) != i {
+			t.Fatalf("Expected pixel %d to be %d; got %d", i, i, val)
+		}
+	}
+}
+
+func TestImageOutputWriteTile(t *testing.T) {
+	outfile := createOutputFileExt("exr")
+	defer os.Remove(outfile)
+
+	out, err := OpenImageOutput(outfile)
+	checkFatalError(t, err)
+
+	if !out.Supports("tiles") {
+		t.Skipf("Format %q does not support tiles", out.FormatName())
+	}
+
+	spec := NewImageSpecSize(64, 64, 1, TypeFloat())
+	spec.SetTileWidth(32)
+	spec.SetTileHeight(32)
+	spec.SetTileDepth(1)
+	checkFatalError(t, out.Open(outfile, spec, OpenModeCreate))
+
+	tile := make([]float32, 32*32)
+	for i := range tile {
+		tile[i] = 0.5
+	}
+	checkFatalError(t, out.WriteTile(0, 0, 0, tile))
+	checkFatalError(t, out.WriteTile(32, 0, 0, tile))
+
+	tiles := make([]float32, 64*32)
+	checkFatalError(t, out.WriteTiles(0, 64, 32, 64, 0, 1, tiles))
+	checkFatalError(t, out.Close())
+
+	in, err := OpenImageInput(outfile)
+	checkFatalError(t, err)
+
+	actual, err := in.ReadTile(32, 0, 0)
+	checkFatalError(t, err)
+	if actual[0] != 0.5 {
+		t.Errorf("Expected first pixel of tile (32,0) to be 0.5; got %v", actual[0])
+	}
+
+	actual, err = in.ReadTile(0, 32, 0)
+	checkFatalError(t, err)
+	if actual[0] != 0 {
+		t.Errorf("Expected first pixel of tile (0,32) to be 0; got %v", actual[0])
+	}
+}
+
+func TestImageOutputMipLevels(t *testing.T) {
+	srcfile := `testdata/checker_mip.tx`
+	outfile := createOutputFileExt("tif")
+	defer os.Remove(outfile)
+
+	out, err := OpenImageOutput(outfile)
+	checkFatalError(t, err)
+
+	if !out.Supports("mipmap") {
+		t.Skipf("Format %q does not support mipmap", out.FormatName())
+	}
+
+	if err = out.OpenMulti(outfile, nil); err == nil {
+		t.Error("Expected an error when calling OpenMulti with no specs")
+	}
+
+	levels := 3
+	for level := 0; level < levels; level++ {
+		buf, err := NewImageBufSubImage(srcfile, 0, level, nil)
+		checkFatalError(t, err)
+
+		mode := OpenModeAppendMIPLevel
+		if level == 0 {
+			mode = OpenModeCreate
+		}
+		checkFatalError(t, out.Open(outfile, buf.Spec(), mode))
+		checkFatalError(t, buf.WriteImageOutput(out))
+	}
+	checkFatalError(t, out.Close())
+
+	in, err := OpenImageInput(outfile)
+	checkFatalError(t, err)
+
+	actual := 0
+	for i := 0; in.SeekMipLevel(0, i, nil); i++ {
+		actual++
+	}
+	if actual != levels {
+		t.Fatalf("Expected %d MIP levels to be written; got %d", levels, actual)
+	}
+}
+
+func TestImageOutputOpenWriter(t *testing.T) {
+	spec := NewImageSpecSize(4, 2, 3, TypeFloat())
+	pixels := make([]float32, 4*2*3)
+	for i := range pixels {
+		pixels[i] = float32(i) / float32(len(pixels))
+	}
+
+	requireIOProxy(t, "exr", "png")
+
+	for _, format := range []string{"exr", "png"} {
+		out, err := OpenImageOutput(format)
+		checkFatalError(t, err)
+
+		var buf bytes.Buffer
+		if !out.Supports("ioproxy") {
+			if err = out.OpenWriter(&buf, format, spec); err == nil {
+				t.Errorf("%s: Expected an error when the format can not write to memory", format)
+			}
+			continue
+		}
+		checkFatalError(t, out.OpenWriter(&buf, format, spec))
+		if err = out.OpenWriter(&buf, format, spec); err == nil {
+			t.Errorf("%s: Expected an error opening an already open writer", format)
+		}
+		checkFatalError(t, out.WriteImage(pixels, nil))
+
+		if buf.Len() != 0 {
+			t.Errorf("%s: Expected nothing to be written before Close", format)
+		}
+		checkFatalError(t, out.Close())
+		if buf.Len() == 0 {
+			t.Fatalf("%s: Expected the image to be written on Close", format)
+		}
+
+		in, err := OpenImageInputReader(format, bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		checkFatalError(t, err)
+
+		actual, err := in.ReadImage()
+		checkFatalError(t, err)
+		checkFatalError(t, in.Close())
+
+		if format == "exr" && !reflect.DeepEqual(pixels, actual) {
+			t.Errorf("%s: Expected pixels %v; got %v", format, pixels, actual)
+		}
+		if len(actual) != len(pixels) {
+			t.Errorf("%s: Expected %d values; got %d", format, len(pixels), len(actual))
+		}
+	}
+}
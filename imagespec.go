@@ -9,7 +9,9 @@ package oiio
 import "C"
 
 import (
+	"encoding/xml"
 	"fmt"
+	"reflect"
 	"runtime"
 	"unsafe"
 )
@@ -35,13 +37,13 @@ func deleteImageSpec(i *ImageSpec) {
 
 // given just the data format, set the default quantize and set all other channels to something reasonable.
 func NewImageSpec(format TypeDesc) *ImageSpec {
-	spec := C.ImageSpec_New((C.TypeDesc)(format))
+	spec := C.ImageSpec_New(format.c())
 	return newImageSpec(spec)
 }
 
 // for simple 2D scanline image with nothing special. If fmt is not supplied, default to unsigned 8-bit data.
 func NewImageSpecSize(x, y, chans int, format TypeDesc) *ImageSpec {
-	spec := C.ImageSpec_New_Size(C.int(x), C.int(y), C.int(chans), (C.TypeDesc)(format))
+	spec := C.ImageSpec_New_Size(C.int(x), C.int(y), C.int(chans), format.c())
 	return newImageSpec(spec)
 }
 
@@ -132,7 +134,48 @@ func (s *ImageSpec) SizeSafe() bool {
 }
 
 func (s *ImageSpec) ChannelFormat(chanNum int) TypeDesc {
-	return (TypeDesc)(C.ImageSpec_channelformat(s.ptr, C.int(chanNum)))
+	return newTypeDesc(C.ImageSpec_channelformat(s.ptr, C.int(chanNum)))
+}
+
+// Split raw pixel data in the native format of a file, such as read by
+// ImageInput.ReadNativeScanline, into one slice of values per channel.
+// Each slice holds the values of a channel exactly as they are stored,
+// typed according to ChannelFormat(), with TypeHalf() values returned
+// as their []uint16 bit patterns.
+func (s *ImageSpec) DecodeNativePixels(data []byte) ([]interface{}, error) {
+	nchannels := s.NumChannels()
+	pixelsize := s.PixelBytes(true)
+	if nchannels <= 0 || pixelsize <= 0 {
+		return nil, fmt.Errorf("Invalid native pixel size %d for %d channels", pixelsize, nchannels)
+	}
+	if len(data)%pixelsize != 0 {
+		return nil, fmt.Errorf("Data length %d is not a multiple of the native pixel size %d",
+			len(data), pixelsize)
+	}
+
+	npixels := len(data) / pixelsize
+	channels := make([]interface{}, nchannels)
+	offset := 0
+
+	for c := 0; c < nchannels; c++ {
+		typ, err := pixelViewType(s.ChannelFormat(c))
+		if err != nil {
+			return nil, fmt.Errorf("Channel %d: %s", c, err)
+		}
+		elemsize := int(typ.Elem().Size())
+
+		values := reflect.MakeSlice(typ, npixels, npixels)
+		dst := byteView(values)
+		for p := 0; p < npixels; p++ {
+			src := p*pixelsize + offset
+			copy(dst[p*elemsize:(p+1)*elemsize], data[src:src+elemsize])
+		}
+
+		channels[c] = values.Interface()
+		offset += elemsize
+	}
+
+	return channels, nil
 }
 
 // Properties
@@ -279,25 +322,42 @@ func (s *ImageSpec) SetNumChannels(val int) {
 
 // data format of the channels
 func (s *ImageSpec) Format() TypeDesc {
-	return (TypeDesc)(C.ImageSpec_format(s.ptr))
+	return newTypeDesc(C.ImageSpec_format(s.ptr))
 }
 
 // Set the data format, and as a side effect set quantize to good defaults for that format
 func (s *ImageSpec) SetFormat(format TypeDesc) {
-	C.ImageSpec_set_format(s.ptr, (C.TypeDesc)(format))
+	C.ImageSpec_set_format(s.ptr, format.c())
 }
 
 // Optional per-channel formats.
+// Channels without a format of their own are TypeUnknown().
 func (s *ImageSpec) ChannelFormats() []TypeDesc {
-	formats := make([]TypeDesc, s.NumChannels())
-	formats_ptr := (*C.TypeDesc)(unsafe.Pointer(&formats[0]))
-	C.ImageSpec_channelformats(s.ptr, formats_ptr)
+	c_formats := make([]C.FullTypeDesc, s.NumChannels())
+	for i := range c_formats {
+		c_formats[i] = TypeUnknown().c()
+	}
+	C.ImageSpec_channelformats(s.ptr, &c_formats[0])
+
+	formats := make([]TypeDesc, len(c_formats))
+	for i, f := range c_formats {
+		formats[i] = newTypeDesc(f)
+	}
 	return formats
 }
 
-func (s *ImageSpec) SetChannelFormats(formats []TypeDesc) {
-	formats_ptr := (*C.TypeDesc)(unsafe.Pointer(&formats[0]))
-	C.ImageSpec_set_channelformats(s.ptr, formats_ptr)
+// Set the per-channel formats. There must be one format for each channel;
+// an error is returned otherwise.
+func (s *ImageSpec) SetChannelFormats(formats []TypeDesc) error {
+	if len(formats) != s.NumChannels() {
+		return fmt.Errorf("Expected %d channel formats; got %d", s.NumChannels(), len(formats))
+	}
+	c_formats := make([]C.FullTypeDesc, len(formats))
+	for i, f := range formats {
+		c_formats[i] = f.c()
+	}
+	C.ImageSpec_set_channelformats(s.ptr, &c_formats[0])
+	return nil
 }
 
 // String name of each channel
@@ -313,8 +373,12 @@ func (s *ImageSpec) ChannelNames() []string {
 }
 
 // SetChannelNames re-labels each existing channel,
-// from a slice of string names.
-func (s *ImageSpec) SetChannelNames(names []string) {
+// from a slice of string names. There must be one name for each
+// channel; an error is returned otherwise.
+func (s *ImageSpec) SetChannelNames(names []string) error {
+	if len(names) != s.NumChannels() {
+		return fmt.Errorf("Expected %d channel names; got %d", s.NumChannels(), len(names))
+	}
 	c_names := make([]*C.char, len(names))
 	for i, n := range names {
 		c_names[i] = C.CString(n)
@@ -322,11 +386,40 @@ func (s *ImageSpec) SetChannelNames(names []string) {
 	}
 	c_names_ptr := (**C.char)(unsafe.Pointer(&c_names[0]))
 	C.ImageSpec_set_channelnames(s.ptr, c_names_ptr)
+	return nil
 }
 
 // Convert ImageSpec class into XML string.
 func (s *ImageSpec) ToXml() string {
-	return C.GoString(C.ImageSpec_to_xml(s.ptr))
+	c_str := C.ImageSpec_to_xml(s.ptr)
+	defer C.free(unsafe.Pointer(c_str))
+	return C.GoString(c_str)
+}
+
+// ImageSpecFromXML creates a new ImageSpec from the XML of an ImageSpec,
+// such as returned by ImageSpec.ToXml. An error is returned if the XML
+// is not well-formed, or is not an ImageSpec element.
+//
+// Only the geometry, data format and channel names are restored, since
+// OpenImageIO does not read the extra attribs back from XML. Use the
+// JSON encoding of an ImageSpec to round trip its attributes.
+func ImageSpecFromXML(data string) (*ImageSpec, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal([]byte(data), &root); err != nil {
+		return nil, fmt.Errorf("Invalid ImageSpec XML: %v", err)
+	}
+	if root.XMLName.Local != "ImageSpec" {
+		return nil, fmt.Errorf("Expected an ImageSpec XML element; got %q", root.XMLName.Local)
+	}
+
+	c_str := C.CString(data)
+	defer C.free(unsafe.Pointer(c_str))
+
+	spec := NewImageSpec(TypeUnknown())
+	C.ImageSpec_from_xml(spec.ptr, c_str)
+	return spec, nil
 }
 
 // Index of alpha channel, or -1 if not known.
@@ -358,7 +451,8 @@ func (s *ImageSpec) SetDeep(val bool) {
 }
 
 // SetAttribute sets a metadata value in the extra attribs. Acceptable types are
-// string, int, and float32.
+// string, int, and float32, and the types of the typed setters:
+// []int, []float32, Matrix44, Vec3, Rational, TimeCode, []byte and []string.
 //
 // Example:
 // 		s = NewImageSpec(...)
@@ -378,8 +472,25 @@ func (s *ImageSpec) SetAttribute(name string, val interface{}) error {
 		C.ImageSpec_attribute_float(s.ptr, c_str, C.float(t))
 	case int:
 		C.ImageSpec_attribute_int(s.ptr, c_str, C.int(t))
+	case []int:
+		return s.SetAttributeInts(name, t)
+	case []float32:
+		return s.SetAttributeFloats(name, t)
+	case Matrix44:
+		s.SetAttributeMatrix44(name, t)
+	case Vec3:
+		s.SetAttributeVec3(name, t)
+	case Rational:
+		s.SetAttributeRational(name, t)
+	case TimeCode:
+		s.SetAttributeTimeCode(name, t)
+	case []byte:
+		return s.SetAttributeBytes(name, t)
+	case []string:
+		return s.SetAttributeStrings(name, t)
 	default:
-		return fmt.Errorf("Value type %T is not one of (string, int, float32)", t)
+		return fmt.Errorf("Value type %T is not one of (string, int, float32, "+
+			"[]int, []float32, Matrix44, Vec3, Rational, TimeCode, []byte, []string)", t)
 	}
 	return nil
 }
@@ -436,12 +547,12 @@ func (s *ImageSpec) EraseAttribute(name string, caseSensitive bool) {
 	c_str := C.CString(name)
 	defer C.free(unsafe.Pointer(c_str))
 
-	C.ImageSpec_erase_attribute(s.ptr, c_str, C.TYPE_UNKNOWN, C.bool(caseSensitive))
+	C.ImageSpec_erase_attribute(s.ptr, c_str, TypeUnknown().c(), C.bool(caseSensitive))
 }
 
 // EraseAttributeType removes the specified attribute from the list of extra_attribs.
 // If not found, do nothing.
-// If searchtype is anything but TypeUnknown, restrict matches to only those of
+// If searchtype is anything but TypeUnknown(), restrict matches to only those of
 // the given type.
 // If caseSensitive is true, the name search will be case-sensitive, otherwise
 // the name search will be performed without regard to case
@@ -449,5 +560,416 @@ func (s *ImageSpec) EraseAttributeType(name string, searchType TypeDesc, caseSen
 	c_str := C.CString(name)
 	defer C.free(unsafe.Pointer(c_str))
 
-	C.ImageSpec_erase_attribute(s.ptr, c_str, C.TypeDesc(searchType), C.bool(caseSensitive))
+	C.ImageSpec_erase_attribute(s.ptr, c_str, searchType.c(), C.bool(caseSensitive))
+}
+
+// Matrix44 is a 4x4 matrix of float32 values, in row-major order,
+// such as the "worldtocamera" and "worldtoscreen" attributes.
+type Matrix44 [16]float32
+
+// Vec3 is a vector, point, normal or color of 3 float32 values.
+type Vec3 [3]float32
+
+// Rational is a ratio of two integers, as used by EXIF metadata.
+type Rational struct {
+	Numerator   int32
+	Denominator int32
+}
+
+// TimeCode is a SMPTE timecode, packed into the time and flags
+// word and the user bits word, as stored in "smpte:TimeCode".
+type TimeCode [2]uint32
+
+// Attribute is a named metadata value from the extra attribs
+// of an ImageSpec.
+type Attribute struct {
+	Name string
+
+	// The type of the value
+	Type TypeDesc
+
+	// The value, typed as follows:
+	//     TypeString()                => string
+	//     TypeInt()                   => int
+	//     TypeFloat()                 => float32
+	//     TypeMatrix44()              => Matrix44
+	//     TypeColor(), TypePoint(), ... => Vec3
+	//     TypeRational()              => Rational
+	//     TypeTimeCode()              => TimeCode
+	//     Arrays of BaseUint8       => []byte
+	//     Other arrays and vectors  => []string, []int or []float32
+	// Other base types are scalars or slices of the Go type that
+	// ImageSpec.DecodeNativePixels uses for the base type.
+	Value interface{}
+}
+
+// Attributes returns a copy of every extra attrib, in order.
+func (s *ImageSpec) Attributes() []Attribute {
+	num := int(C.ImageSpec_num_attributes(s.ptr))
+	attrs := make([]Attribute, 0, num)
+
+	for i := 0; i < num; i++ {
+		t := newTypeDesc(C.ImageSpec_attribute_type(s.ptr, C.int(i)))
+		attrs = append(attrs, Attribute{
+			Name:  C.GoString(C.ImageSpec_attribute_name(s.ptr, C.int(i))),
+			Type:  t,
+			Value: decodeAttribute(t, C.ImageSpec_attribute_data(s.ptr, C.int(i))),
+		})
+	}
+
+	runtime.KeepAlive(s)
+	return attrs
+}
+
+// Find an attribute by name, without regard to case, and return
+// its type and a copy of its value.
+func (s *ImageSpec) findAttribute(name string) (TypeDesc, interface{}, bool) {
+	c_str := C.CString(name)
+	defer C.free(unsafe.Pointer(c_str))
+
+	index := C.ImageSpec_find_attribute(s.ptr, c_str)
+	if index < 0 {
+		return TypeUnknown(), nil, false
+	}
+
+	t := newTypeDesc(C.ImageSpec_attribute_type(s.ptr, index))
+	val := decodeAttribute(t, C.ImageSpec_attribute_data(s.ptr, index))
+
+	runtime.KeepAlive(s)
+	return t, val, true
+}
+
+// Copy an attribute value of type t from the memory at data.
+func decodeAttribute(t TypeDesc, data unsafe.Pointer) interface{} {
+	vals := attributeBaseValues(t, data)
+	if vals == nil {
+		return nil
+	}
+	scalar := t.NumValues() == 1 && !t.IsArray()
+
+	switch v := vals.(type) {
+	case []string:
+		if scalar {
+			return v[0]
+		}
+		return v
+
+	case []float32:
+		if t.Aggregate == AggregateMatrix44 && !t.IsArray() {
+			var m Matrix44
+			copy(m[:], v)
+			return m
+		}
+		if t.Aggregate == AggregateVec3 && !t.IsArray() {
+			var vec Vec3
+			copy(vec[:], v)
+			return vec
+		}
+
+	case []int32:
+		if t == TypeRational() {
+			return Rational{Numerator: v[0], Denominator: v[1]}
+		}
+		ints := make([]int, len(v))
+		for i, val := range v {
+			ints[i] = int(val)
+		}
+		if scalar {
+			return ints[0]
+		}
+		return ints
+
+	case []uint32:
+		if t == TypeTimeCode() {
+			return TimeCode{v[0], v[1]}
+		}
+	}
+
+	if scalar {
+		return reflect.ValueOf(vals).Index(0).Interface()
+	}
+	return vals
+}
+
+// Copy the base values of an attribute of type t from the memory at
+// data, as a []string, or a slice of the type of pixelViewType.
+// Returns nil if the base type has no Go representation.
+func attributeBaseValues(t TypeDesc, data unsafe.Pointer) interface{} {
+	n := t.NumValues()
+	if data == nil || n < 1 {
+		return nil
+	}
+
+	if t.BaseType == BaseString {
+		ptrs := (*[1 << 28]*C.char)(data)[:n:n]
+		strs := make([]string, n)
+		for i, p := range ptrs {
+			strs[i] = C.GoString(p)
+		}
+		return strs
+	}
+
+	view, err := pixelSliceView(data, n, t.Scalar())
+	if err != nil {
+		return nil
+	}
+	src := reflect.ValueOf(view)
+	dst := reflect.MakeSlice(src.Type(), n, n)
+	reflect.Copy(dst, src)
+	return dst.Interface()
+}
+
+// Set an attribute of type t from the values at data.
+func (s *ImageSpec) setAttributeData(name string, t TypeDesc, data unsafe.Pointer) {
+	c_str := C.CString(name)
+	defer C.free(unsafe.Pointer(c_str))
+
+	C.ImageSpec_attribute_type_data(s.ptr, c_str, t.c(), data)
+}
+
+// SetAttributeInts sets an int array attribute. An error is returned
+// if vals is empty.
+func (s *ImageSpec) SetAttributeInts(name string, vals []int) error {
+	if len(vals) == 0 {
+		return fmt.Errorf("Attribute %q must have at least one value", name)
+	}
+	c_vals := make([]int32, len(vals))
+	for i, v := range vals {
+		c_vals[i] = int32(v)
+	}
+	t := TypeDesc{BaseType: BaseInt, Aggregate: AggregateScalar, ArrayLen: len(vals)}
+	s.setAttributeData(name, t, unsafe.Pointer(&c_vals[0]))
+	return nil
+}
+
+// AttributeInts looks up an existing int attrib by name and returns
+// all of its values. It returns false if the attrib does not exist,
+// or is not of TypeInt().
+func (s *ImageSpec) AttributeInts(name string) ([]int, bool) {
+	t, val, ok := s.findAttribute(name)
+	if !ok || t.BaseType != BaseInt {
+		return nil, false
+	}
+	switch v := val.(type) {
+	case int:
+		return []int{v}, true
+	case Rational:
+		return []int{int(v.Numerator), int(v.Denominator)}, true
+	case []int:
+		return v, true
+	}
+	return nil, false
+}
+
+// SetAttributeFloats sets a float array attribute. An error is returned
+// if vals is empty.
+func (s *ImageSpec) SetAttributeFloats(name string, vals []float32) error {
+	if len(vals) == 0 {
+		return fmt.Errorf("Attribute %q must have at least one value", name)
+	}
+	t := TypeDesc{BaseType: BaseFloat, Aggregate: AggregateScalar, ArrayLen: len(vals)}
+	s.setAttributeData(name, t, unsafe.Pointer(&vals[0]))
+	return nil
+}
+
+// AttributeFloats looks up an existing float attrib by name and returns
+// all of its values, including those of vectors and matrices. It returns
+// false if the attrib does not exist, or is not of TypeFloat().
+func (s *ImageSpec) AttributeFloats(name string) ([]float32, bool) {
+	t, val, ok := s.findAttribute(name)
+	if !ok || t.BaseType != BaseFloat {
+		return nil, false
+	}
+	switch v := val.(type) {
+	case float32:
+		return []float32{v}, true
+	case Matrix44:
+		return v[:], true
+	case Vec3:
+		return v[:], true
+	case []float32:
+		return v, true
+	}
+	return nil, false
+}
+
+// SetAttributeMatrix44 sets a 4x4 float matrix attribute.
+func (s *ImageSpec) SetAttributeMatrix44(name string, m Matrix44) {
+	s.setAttributeData(name, TypeMatrix44(), unsafe.Pointer(&m[0]))
+}
+
+// AttributeMatrix44 looks up an existing 4x4 float matrix attrib by name.
+// It returns false if the attrib does not exist, or is not a matrix.
+func (s *ImageSpec) AttributeMatrix44(name string) (Matrix44, bool) {
+	_, val, _ := s.findAttribute(name)
+	m, ok := val.(Matrix44)
+	return m, ok
+}
+
+// SetAttributeVec3 sets a 3 float vector attribute.
+func (s *ImageSpec) SetAttributeVec3(name string, v Vec3) {
+	t := TypeDesc{BaseType: BaseFloat, Aggregate: AggregateVec3}
+	s.setAttributeData(name, t, unsafe.Pointer(&v[0]))
+}
+
+// AttributeVec3 looks up an existing 3 float vector, point, normal or
+// color attrib by name. It returns false if the attrib does not exist,
+// or is not a 3 float vector.
+func (s *ImageSpec) AttributeVec3(name string) (Vec3, bool) {
+	_, val, _ := s.findAttribute(name)
+	v, ok := val.(Vec3)
+	return v, ok
+}
+
+// SetAttributeRational sets a rational attribute.
+func (s *ImageSpec) SetAttributeRational(name string, r Rational) {
+	vals := [2]int32{r.Numerator, r.Denominator}
+	s.setAttributeData(name, TypeRational(), unsafe.Pointer(&vals[0]))
+}
+
+// AttributeRational looks up an existing rational attrib by name.
+// It returns false if the attrib does not exist, or is not a rational.
+func (s *ImageSpec) AttributeRational(name string) (Rational, bool) {
+	_, val, _ := s.findAttribute(name)
+	r, ok := val.(Rational)
+	return r, ok
+}
+
+// SetAttributeTimeCode sets a SMPTE timecode attribute.
+func (s *ImageSpec) SetAttributeTimeCode(name string, tc TimeCode) {
+	s.setAttributeData(name, TypeTimeCode(), unsafe.Pointer(&tc[0]))
+}
+
+// AttributeTimeCode looks up an existing SMPTE timecode attrib by name.
+// It returns false if the attrib does not exist, or is not a timecode.
+func (s *ImageSpec) AttributeTimeCode(name string) (TimeCode, bool) {
+	_, val, _ := s.findAttribute(name)
+	tc, ok := val.(TimeCode)
+	return tc, ok
+}
+
+// SetAttributeBytes sets a uint8 array attribute, such as a binary
+// blob of metadata. An error is returned if data is empty.
+func (s *ImageSpec) SetAttributeBytes(name string, data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("Attribute %q must have at least one value", name)
+	}
+	t := TypeDesc{BaseType: BaseUint8, Aggregate: AggregateScalar, ArrayLen: len(data)}
+	s.setAttributeData(name, t, unsafe.Pointer(&data[0]))
+	return nil
+}
+
+// AttributeBytes looks up an existing uint8 attrib by name and returns
+// all of its values. It returns false if the attrib does not exist,
+// or is not of TypeUint8().
+func (s *ImageSpec) AttributeBytes(name string) ([]byte, bool) {
+	t, val, ok := s.findAttribute(name)
+	if !ok || t.BaseType != BaseUint8 {
+		return nil, false
+	}
+	switch v := val.(type) {
+	case uint8:
+		return []byte{v}, true
+	case []uint8:
+		return v, true
+	}
+	return nil, false
+}
+
+// SetAttributeStrings sets a string array attribute. An error is
+// returned if vals is empty.
+func (s *ImageSpec) SetAttributeStrings(name string, vals []string) error {
+	if len(vals) == 0 {
+		return fmt.Errorf("Attribute %q must have at least one value", name)
+	}
+	c_vals := C.makeCharArray(C.int(len(vals)))
+	defer C.freeCharArray(c_vals, C.int(len(vals)))
+	for i, v := range vals {
+		C.setArrayString(c_vals, C.CString(v), C.int(i))
+	}
+	t := TypeDesc{BaseType: BaseString, Aggregate: AggregateScalar, ArrayLen: len(vals)}
+	s.setAttributeData(name, t, unsafe.Pointer(c_vals))
+	return nil
+}
+
+// AttributeStrings looks up an existing string attrib by name and returns
+// all of its values. It returns false if the attrib does not exist,
+// or is not of TypeString().
+func (s *ImageSpec) AttributeStrings(name string) ([]string, bool) {
+	t, val, ok := s.findAttribute(name)
+	if !ok || t.BaseType != BaseString {
+		return nil, false
+	}
+	switch v := val.(type) {
+	case string:
+		return []string{v}, true
+	case []string:
+		return v, true
+	}
+	return nil, false
+}
+
+// AttributeType looks up an existing attrib by name and returns its type.
+// It returns false if the attrib does not exist.
+func (s *ImageSpec) AttributeType(name string) (TypeDesc, bool) {
+	c_str := C.CString(name)
+	defer C.free(unsafe.Pointer(c_str))
+
+	index := C.ImageSpec_find_attribute(s.ptr, c_str)
+	if index < 0 {
+		return TypeUnknown(), false
+	}
+	return newTypeDesc(C.ImageSpec_attribute_type(s.ptr, index)), true
+}
+
+// SetAttributeType sets an attribute of any type, such as TypeColor() or
+// TypeKeyCode(), from all of its base values. vals must be a []string for
+// BaseString, a []int for BaseInt, and otherwise a slice of the Go type
+// that ImageSpec.DecodeNativePixels uses for the base type, such as
+// []float32 for BaseFloat. It must hold t.NumValues() values.
+//
+// Example:
+//     s.SetAttributeType("tint", TypeColor(), []float32{1, 0.5, 0.25})
+func (s *ImageSpec) SetAttributeType(name string, t TypeDesc, vals interface{}) error {
+	n := t.NumValues()
+
+	switch v := vals.(type) {
+	case []string:
+		if t.BaseType != BaseString || len(v) != n {
+			return fmt.Errorf("Expected %d values of type %v; got %d strings", n, t, len(v))
+		}
+		c_vals := C.makeCharArray(C.int(n))
+		defer C.freeCharArray(c_vals, C.int(n))
+		for i, val := range v {
+			C.setArrayString(c_vals, C.CString(val), C.int(i))
+		}
+		s.setAttributeData(name, t, unsafe.Pointer(c_vals))
+		return nil
+
+	case []int:
+		if t.BaseType != BaseInt || len(v) != n {
+			return fmt.Errorf("Expected %d values of type %v; got %d ints", n, t, len(v))
+		}
+		c_vals := make([]int32, n)
+		for i, val := range v {
+			c_vals[i] = int32(val)
+		}
+		s.setAttributeData(name, t, unsafe.Pointer(&c_vals[0]))
+		return nil
+	}
+
+	typ, err := pixelViewType(t.Scalar())
+	if err != nil {
+		return fmt.Errorf("Cannot set attribute %q of type %v: %v", name, t, err)
+	}
+	val := reflect.ValueOf(vals)
+	if !val.IsValid() || val.Type() != typ {
+		return fmt.Errorf("Expected %v values for type %v; got %T", typ, t, vals)
+	}
+	if val.Len() != n {
+		return fmt.Errorf("Expected %d values of type %v; got %d", n, t, val.Len())
+	}
+	s.setAttributeData(name, t, unsafe.Pointer(val.Pointer()))
+	runtime.KeepAlive(vals)
+	return nil
 }
@@ -1,14 +1,15 @@
 package oiio
 
 import (
+	"reflect"
 	"testing"
 )
 
 func TestNewImageSpec(t *testing.T) {
-	spec := NewImageSpec(TypeFloat)
-	spec = NewImageSpecSize(512, 512, 3, TypeDouble)
+	spec := NewImageSpec(TypeFloat())
+	spec = NewImageSpecSize(512, 512, 3, TypeDouble())
 
-	spec.SetFormat(TypeHalf)
+	spec.SetFormat(TypeHalf())
 	spec.DefaultChannelNames()
 
 	expected := 2
@@ -60,7 +61,7 @@ func TestNewImageSpec(t *testing.T) {
 	spec.SizeSafe()
 
 	format := spec.ChannelFormat(0)
-	if format != TypeHalf {
+	if format != TypeHalf() {
 		t.Errorf("Expected TypeHalf (8), got %v", format)
 	}
 }
@@ -85,7 +86,7 @@ func TestImageSpecProperties(t *testing.T) {
 	if spec.AlphaChannel() != -1 {
 		t.Errorf("Expected alpha index to be -1;  got %v", spec.AlphaChannel())
 	}
-	if spec.Format() != TypeUint8 {
+	if spec.Format() != TypeUint8() {
 		t.Errorf("Expected data format to be TypeUint8; got %v", spec.Format())
 	}
 
@@ -113,6 +114,69 @@ func TestImageSpecProperties(t *testing.T) {
 
 }
 
+func TestImageSpecDecodeNativePixels(t *testing.T) {
+	spec := NewImageSpecSize(2, 1, 2, TypeUint8())
+	checkFatalError(t, spec.SetChannelFormats([]TypeDesc{TypeUint8(), TypeUint16()}))
+
+	if spec.PixelBytes(true) != 3 {
+		t.Fatalf("Expected native pixel size of 3; got %d", spec.PixelBytes(true))
+	}
+
+	expected := []interface{}{[]uint8{7, 9}, []uint16{0x0102, 0x0304}}
+
+	// Interleave the channels in native byte order
+	values := byteView(reflect.ValueOf(expected[1]))
+	data := []byte{7, values[0], values[1], 9, values[2], values[3]}
+
+	actual, err := spec.DecodeNativePixels(data)
+	checkFatalError(t, err)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %v; got %v", expected, actual)
+	}
+
+	if _, err = spec.DecodeNativePixels(data[:4]); err == nil {
+		t.Error("Expected an error decoding a partial pixel")
+	}
+}
+
+func TestImageSpecSetChannelFormats(t *testing.T) {
+	spec := NewImageSpecSize(2, 1, 3, TypeUint8())
+
+	for _, formats := range [][]TypeDesc{
+		{TypeUint8(), TypeUint16()},
+		{TypeUint8(), TypeUint16(), TypeFloat(), TypeHalf()},
+	} {
+		if err := spec.SetChannelFormats(formats); err == nil {
+			t.Errorf("Expected an error setting %d formats on 3 channels", len(formats))
+		}
+	}
+
+	expected := []TypeDesc{TypeUint8(), TypeUint16(), TypeFloat()}
+	checkFatalError(t, spec.SetChannelFormats(expected))
+	if actual := spec.ChannelFormats(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %v; got %v", expected, actual)
+	}
+}
+
+func TestImageSpecSetChannelNames(t *testing.T) {
+	spec := NewImageSpecSize(2, 1, 3, TypeUint8())
+
+	for _, names := range [][]string{
+		{"R", "G"},
+		{"R", "G", "B", "A"},
+	} {
+		if err := spec.SetChannelNames(names); err == nil {
+			t.Errorf("Expected an error setting %d names on 3 channels", len(names))
+		}
+	}
+
+	expected := []string{"X", "Y", "Z"}
+	checkFatalError(t, spec.SetChannelNames(expected))
+	if actual := spec.ChannelNames(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %v; got %v", expected, actual)
+	}
+}
+
 func TestImageSpecStringAttribute(t *testing.T) {
 	spec, err := getTestImageSpec()
 	if err != nil {
@@ -231,19 +295,158 @@ func TestImageSpecEraseAttribute(t *testing.T) {
 		t.Error("Expected STR_VALUE attribute to have been erased")
 	}
 
-	spec.EraseAttributeType("INT_VALUE", TypeDouble, false)
+	spec.EraseAttributeType("INT_VALUE", TypeDouble(), false)
 
 	if spec.AttributeInt("INT_VALUE") == 0 {
 		t.Fatal("INT_VALUE was not expected to have been erased non-matching search type")
 	}
 
-	spec.EraseAttributeType("INT_VALUE", TypeInt, false)
+	spec.EraseAttributeType("INT_VALUE", TypeInt(), false)
 
 	if spec.AttributeInt("INT_VALUE") != 0 {
 		t.Error("Expected INT_VALUE attribute to have been erased")
 	}
 }
 
+func TestImageSpecAttributes(t *testing.T) {
+	spec := NewImageSpecSize(64, 64, 3, TypeFloat())
+
+	camera := Matrix44{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 5, 6, 7, 1}
+
+	array := func(base BaseType, n int) TypeDesc {
+		return TypeDesc{BaseType: base, Aggregate: AggregateScalar, ArrayLen: n}
+	}
+	float3 := TypeDesc{BaseType: BaseFloat, Aggregate: AggregateVec3}
+
+	expected := []Attribute{
+		{"str", TypeString(), "value"},
+		{"int", TypeInt(), 42},
+		{"float", TypeFloat(), float32(1.5)},
+		{"ints", array(BaseInt, 3), []int{1, 2, 3}},
+		{"GPS:Latitude", array(BaseFloat, 3), []float32{45, 30, 15.5}},
+		{"worldtocamera", TypeMatrix44(), camera},
+		{"position", float3, Vec3{1, 2, 3}},
+		{"ExposureTime", TypeRational(), Rational{1, 250}},
+		{"smpte:TimeCode", TypeTimeCode(), TimeCode{0x01020304, 0}},
+		{"blob", array(BaseUint8, 4), []byte{0xde, 0xad, 0xbe, 0xef}},
+		{"keywords", array(BaseString, 2), []string{"a", "b"}},
+	}
+
+	for _, attr := range expected {
+		checkFatalError(t, spec.SetAttribute(attr.Name, attr.Value))
+	}
+
+	actual := spec.Attributes()
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Expected attributes:\n%v\ngot:\n%v", expected, actual)
+	}
+
+	// Copying every attribute is lossless
+	dst := NewImageSpecSize(64, 64, 3, TypeFloat())
+	for _, attr := range actual {
+		checkFatalError(t, dst.SetAttribute(attr.Name, attr.Value))
+	}
+	if copied := dst.Attributes(); !reflect.DeepEqual(expected, copied) {
+		t.Errorf("Expected copied attributes:\n%v\ngot:\n%v", expected, copied)
+	}
+
+	if err := spec.SetAttribute("unsupported", 1.5); err == nil {
+		t.Error("Expected an error setting a float64 attribute")
+	}
+}
+
+func TestImageSpecTypedAttributes(t *testing.T) {
+	spec := NewImageSpecSize(64, 64, 3, TypeFloat())
+
+	checkFatalError(t, spec.SetAttributeInts("ints", []int{-1, 2}))
+	if actual, ok := spec.AttributeInts("INTS"); !ok || !reflect.DeepEqual(actual, []int{-1, 2}) {
+		t.Errorf("Expected ints [-1 2]; got %v (%v)", actual, ok)
+	}
+
+	checkFatalError(t, spec.SetAttributeFloats("floats", []float32{0.5}))
+	if actual, ok := spec.AttributeFloats("floats"); !ok || !reflect.DeepEqual(actual, []float32{0.5}) {
+		t.Errorf("Expected floats [0.5]; got %v (%v)", actual, ok)
+	}
+
+	m := Matrix44{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	spec.SetAttributeMatrix44("worldtoscreen", m)
+	if actual, ok := spec.AttributeMatrix44("worldtoscreen"); !ok || actual != m {
+		t.Errorf("Expected matrix %v; got %v (%v)", m, actual, ok)
+	}
+	if actual, ok := spec.AttributeFloats("worldtoscreen"); !ok || len(actual) != 16 || actual[15] != 16 {
+		t.Errorf("Expected the 16 floats of the matrix; got %v (%v)", actual, ok)
+	}
+
+	v := Vec3{0.25, 0.5, 0.75}
+	spec.SetAttributeVec3("color", v)
+	if actual, ok := spec.AttributeVec3("color"); !ok || actual != v {
+		t.Errorf("Expected vec3 %v; got %v (%v)", v, actual, ok)
+	}
+
+	r := Rational{24000, 1001}
+	spec.SetAttributeRational("FramesPerSecond", r)
+	if actual, ok := spec.AttributeRational("FramesPerSecond"); !ok || actual != r {
+		t.Errorf("Expected rational %v; got %v (%v)", r, actual, ok)
+	}
+
+	tc := TimeCode{0x10203040, 0x1}
+	spec.SetAttributeTimeCode("smpte:TimeCode", tc)
+	if actual, ok := spec.AttributeTimeCode("smpte:TimeCode"); !ok || actual != tc {
+		t.Errorf("Expected timecode %v; got %v (%v)", tc, actual, ok)
+	}
+
+	checkFatalError(t, spec.SetAttributeBytes("GPS:VersionID", []byte{2, 2, 0, 0}))
+	if actual, ok := spec.AttributeBytes("GPS:VersionID"); !ok || !reflect.DeepEqual(actual, []byte{2, 2, 0, 0}) {
+		t.Errorf("Expected bytes [2 2 0 0]; got %v (%v)", actual, ok)
+	}
+
+	checkFatalError(t, spec.SetAttributeStrings("names", []string{"x", "y", "z"}))
+	if actual, ok := spec.AttributeStrings("names"); !ok || !reflect.DeepEqual(actual, []string{"x", "y", "z"}) {
+		t.Errorf("Expected strings [x y z]; got %v (%v)", actual, ok)
+	}
+
+	// Mismatched and missing attributes
+	if _, ok := spec.AttributeMatrix44("color"); ok {
+		t.Error("Expected a vec3 attribute not to be read as a matrix")
+	}
+	if _, ok := spec.AttributeInts("floats"); ok {
+		t.Error("Expected a float attribute not to be read as ints")
+	}
+	if _, ok := spec.AttributeStrings("missing"); ok {
+		t.Error("Expected a missing attribute not to be found")
+	}
+
+	if err := spec.SetAttributeInts("empty", nil); err == nil {
+		t.Error("Expected an error setting an empty int array")
+	}
+}
+
+func TestImageSpecFromXML(t *testing.T) {
+	spec := NewImageSpecSize(32, 16, 3, TypeFloat())
+	checkFatalError(t, spec.SetChannelNames([]string{"R", "G", "B"}))
+
+	actual, err := ImageSpecFromXML(spec.ToXml())
+	checkFatalError(t, err)
+
+	if actual.Width() != 32 || actual.Height() != 16 || actual.NumChannels() != 3 {
+		t.Errorf("Expected 32x16 with 3 channels; got %dx%d with %d",
+			actual.Width(), actual.Height(), actual.NumChannels())
+	}
+	if actual.Format() != TypeFloat() {
+		t.Errorf("Expected format %v; got %v", TypeFloat(), actual.Format())
+	}
+	if names := actual.ChannelNames(); !reflect.DeepEqual(names, []string{"R", "G", "B"}) {
+		t.Errorf("Expected channel names [R G B]; got %v", names)
+	}
+
+	if _, err = ImageSpecFromXML("<ImageSpec>"); err == nil {
+		t.Error("Expected an error for malformed XML")
+	}
+	if _, err = ImageSpecFromXML("<Other></Other>"); err == nil {
+		t.Error("Expected an error for XML that is not an ImageSpec")
+	}
+}
+
 func getTestImageSpec() (*ImageSpec, error) {
 	in, err := OpenImageInput(TEST_IMAGE)
 	if err != nil {
@@ -0,0 +1,388 @@
+package oiio
+
+/*
+#include "stdlib.h"
+
+#include "cpp/oiio.h"
+
+*/
+import "C"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// The JSON representation of an ImageSpec. Fields are named after
+// the members of OIIO::ImageSpec, and types by TypeDesc.String.
+type imageSpecJSON struct {
+	X              int             `json:"x"`
+	Y              int             `json:"y"`
+	Z              int             `json:"z"`
+	Width          int             `json:"width"`
+	Height         int             `json:"height"`
+	Depth          int             `json:"depth"`
+	FullX          int             `json:"full_x"`
+	FullY          int             `json:"full_y"`
+	FullZ          int             `json:"full_z"`
+	FullWidth      int             `json:"full_width"`
+	FullHeight     int             `json:"full_height"`
+	FullDepth      int             `json:"full_depth"`
+	TileWidth      int             `json:"tile_width"`
+	TileHeight     int             `json:"tile_height"`
+	TileDepth      int             `json:"tile_depth"`
+	NumChannels    int             `json:"nchannels"`
+	Format         string          `json:"format"`
+	ChannelFormats []string        `json:"channelformats,omitempty"`
+	ChannelNames   []string        `json:"channelnames"`
+	AlphaChannel   int             `json:"alpha_channel"`
+	ZChannel       int             `json:"z_channel"`
+	Deep           bool            `json:"deep"`
+	Attributes     []attributeJSON `json:"attributes"`
+}
+
+// The JSON representation of an extra attrib. The value is a single
+// JSON value for a scalar type, and an array of all of the base values
+// otherwise.
+type attributeJSON struct {
+	Name  string          `json:"name"`
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+func newImageSpecJSON(s *ImageSpec) *imageSpecJSON {
+	js := &imageSpecJSON{
+		X:            s.X(),
+		Y:            s.Y(),
+		Z:            s.Z(),
+		Width:        s.Width(),
+		Height:       s.Height(),
+		Depth:        s.Depth(),
+		FullX:        s.FullX(),
+		FullY:        s.FullY(),
+		FullZ:        s.FullZ(),
+		FullWidth:    s.FullWidth(),
+		FullHeight:   s.FullHeight(),
+		FullDepth:    s.FullDepth(),
+		TileWidth:    s.TileWidth(),
+		TileHeight:   s.TileHeight(),
+		TileDepth:    s.TileDepth(),
+		NumChannels:  s.NumChannels(),
+		Format:       s.Format().String(),
+		ChannelNames: []string{},
+		AlphaChannel: s.AlphaChannel(),
+		ZChannel:     s.ZChannel(),
+		Deep:         s.Deep(),
+		Attributes:   []attributeJSON{},
+	}
+
+	if js.NumChannels > 0 {
+		js.ChannelNames = s.ChannelNames()
+
+		// Per-channel formats are only stored if the spec has them
+		formats := s.ChannelFormats()
+		for _, f := range formats {
+			if f != TypeUnknown() {
+				js.ChannelFormats = make([]string, len(formats))
+				for i, f := range formats {
+					js.ChannelFormats[i] = f.String()
+				}
+				break
+			}
+		}
+	}
+
+	return js
+}
+
+// MarshalJSON encodes the geometry, channels, formats and all of the
+// extra attribs of the ImageSpec as a JSON object. Attributes whose
+// values cannot be represented, such as pointers, are skipped.
+// Half values are encoded as the uint16 bits of each value.
+func (s *ImageSpec) MarshalJSON() ([]byte, error) {
+	js := newImageSpecJSON(s)
+
+	num := int(C.ImageSpec_num_attributes(s.ptr))
+	for i := 0; i < num; i++ {
+		t := newTypeDesc(C.ImageSpec_attribute_type(s.ptr, C.int(i)))
+		vals := attributeBaseValues(t, C.ImageSpec_attribute_data(s.ptr, C.int(i)))
+		if vals == nil {
+			continue
+		}
+
+		value, err := encodeAttributeValues(t, vals)
+		if err != nil {
+			return nil, err
+		}
+
+		js.Attributes = append(js.Attributes, attributeJSON{
+			Name:  C.GoString(C.ImageSpec_attribute_name(s.ptr, C.int(i))),
+			Type:  t.String(),
+			Value: value,
+		})
+	}
+
+	runtime.KeepAlive(s)
+	return json.Marshal(js)
+}
+
+// UnmarshalJSON replaces the ImageSpec with one decoded from the JSON
+// of ImageSpec.MarshalJSON. A zero ImageSpec, such as new(ImageSpec),
+// is allocated.
+func (s *ImageSpec) UnmarshalJSON(data []byte) error {
+	var js imageSpecJSON
+	if err := json.Unmarshal(data, &js); err != nil {
+		return err
+	}
+
+	format, err := parseTypeName(js.Format)
+	if err != nil {
+		return err
+	}
+
+	spec := NewImageSpec(format)
+	spec.SetX(js.X)
+	spec.SetY(js.Y)
+	spec.SetZ(js.Z)
+	spec.SetWidth(js.Width)
+	spec.SetHeight(js.Height)
+	spec.SetDepth(js.Depth)
+	spec.SetFullX(js.FullX)
+	spec.SetFullY(js.FullY)
+	spec.SetFullZ(js.FullZ)
+	spec.SetFullWidth(js.FullWidth)
+	spec.SetFullHeight(js.FullHeight)
+	spec.SetFullDepth(js.FullDepth)
+	spec.SetTileWidth(js.TileWidth)
+	spec.SetTileHeight(js.TileHeight)
+	spec.SetTileDepth(js.TileDepth)
+	spec.SetNumChannels(js.NumChannels)
+	spec.SetAlphaChannel(js.AlphaChannel)
+	spec.SetZChannel(js.ZChannel)
+	spec.SetDeep(js.Deep)
+
+	if len(js.ChannelNames) > 0 {
+		if len(js.ChannelNames) != js.NumChannels {
+			return fmt.Errorf("Expected %d channel names; got %d", js.NumChannels, len(js.ChannelNames))
+		}
+		if err = spec.SetChannelNames(js.ChannelNames); err != nil {
+			return err
+		}
+	}
+
+	if len(js.ChannelFormats) > 0 {
+		if len(js.ChannelFormats) != js.NumChannels {
+			return fmt.Errorf("Expected %d channel formats; got %d", js.NumChannels, len(js.ChannelFormats))
+		}
+		formats := make([]TypeDesc, len(js.ChannelFormats))
+		for i, name := range js.ChannelFormats {
+			if formats[i], err = parseTypeName(name); err != nil {
+				return err
+			}
+		}
+		if err = spec.SetChannelFormats(formats); err != nil {
+			return err
+		}
+	}
+
+	for _, attr := range js.Attributes {
+		t, err := parseTypeName(attr.Type)
+		if err != nil {
+			return fmt.Errorf("Attribute %q: %v", attr.Name, err)
+		}
+		vals, err := decodeAttributeValues(t, attr.Value)
+		if err != nil {
+			return fmt.Errorf("Attribute %q: %v", attr.Name, err)
+		}
+		if err = spec.SetAttributeType(attr.Name, t, vals); err != nil {
+			return err
+		}
+	}
+
+	if s.ptr == nil {
+		s.ptr = unsafe.Pointer(C.ImageSpec_New(TypeUnknown().c()))
+		runtime.SetFinalizer(s, deleteImageSpec)
+	}
+	C.ImageSpec_copy(s.ptr, spec.ptr)
+
+	runtime.KeepAlive(spec)
+	return nil
+}
+
+// Parse a type name, including the name of TypeUnknown().
+func parseTypeName(name string) (TypeDesc, error) {
+	if name == "" || name == TypeUnknown().String() {
+		return TypeUnknown(), nil
+	}
+	return ParseTypeDesc(name)
+}
+
+// Encode the base values of an attribute, as a single JSON value if
+// the type is a scalar, or as an array. Numbers are formatted with
+// the precision of their type, so that they decode to the same value.
+func encodeAttributeValues(t TypeDesc, vals interface{}) (json.RawMessage, error) {
+	var out interface{}
+
+	if strs, ok := vals.([]string); ok {
+		out = strs
+	} else {
+		slice := reflect.ValueOf(vals)
+		nums := make([]json.Number, slice.Len())
+		for i := range nums {
+			elem := slice.Index(i)
+			switch elem.Kind() {
+			case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				nums[i] = json.Number(strconv.FormatInt(elem.Int(), 10))
+			case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				nums[i] = json.Number(strconv.FormatUint(elem.Uint(), 10))
+			case reflect.Float32, reflect.Float64:
+				nums[i] = json.Number(strconv.FormatFloat(elem.Float(), 'g', -1, elem.Type().Bits()))
+			}
+		}
+		out = nums
+	}
+
+	if t.NumValues() == 1 && !t.IsArray() {
+		out = reflect.ValueOf(out).Index(0).Interface()
+	}
+	return json.Marshal(out)
+}
+
+// Decode the base values of an attribute of type t, from a single JSON
+// value or an array, into values accepted by ImageSpec.SetAttributeType.
+func decodeAttributeValues(t TypeDesc, raw json.RawMessage) (interface{}, error) {
+	isArray := bytes.HasPrefix(bytes.TrimSpace(raw), []byte("["))
+
+	if t.BaseType == BaseString {
+		var strs []string
+		if isArray {
+			if err := json.Unmarshal(raw, &strs); err != nil {
+				return nil, err
+			}
+		} else {
+			var str string
+			if err := json.Unmarshal(raw, &str); err != nil {
+				return nil, err
+			}
+			strs = []string{str}
+		}
+		return strs, nil
+	}
+
+	var nums []json.Number
+	if isArray {
+		if err := json.Unmarshal(raw, &nums); err != nil {
+			return nil, err
+		}
+	} else {
+		var num json.Number
+		if err := json.Unmarshal(raw, &num); err != nil {
+			return nil, err
+		}
+		nums = []json.Number{num}
+	}
+
+	typ, err := pixelViewType(t.Scalar())
+	if err != nil {
+		return nil, fmt.Errorf("Type %v has no values: %v", t, err)
+	}
+
+	vals := reflect.MakeSlice(typ, len(nums), len(nums))
+	for i, num := range nums {
+		elem := vals.Index(i)
+		bits := elem.Type().Bits()
+
+		switch elem.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v, err := strconv.ParseInt(string(num), 10, bits)
+			if err != nil {
+				return nil, err
+			}
+			elem.SetInt(v)
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v, err := strconv.ParseUint(string(num), 10, bits)
+			if err != nil {
+				return nil, err
+			}
+			elem.SetUint(v)
+		case reflect.Float32, reflect.Float64:
+			v, err := strconv.ParseFloat(string(num), bits)
+			if err != nil {
+				return nil, err
+			}
+			elem.SetFloat(v)
+		}
+	}
+	return vals.Interface(), nil
+}
+
+// ImageSpecDiff is a difference between two ImageSpecs. It is either
+// a field, named as in the JSON of an ImageSpec, such as "width" or
+// "channelnames", or an extra attrib. Old and New hold the value in
+// each ImageSpec, with the value of a missing attribute being nil.
+type ImageSpecDiff struct {
+	Name      string
+	Attribute bool
+	Old, New  interface{}
+}
+
+func (d ImageSpecDiff) String() string {
+	return fmt.Sprintf("%s: %v -> %v", d.Name, d.Old, d.New)
+}
+
+// Diff reports every field and extra attrib that differs between the
+// ImageSpec and other, in the order of the fields, then the attributes
+// of the ImageSpec, then the attributes that only exist in other.
+// Attributes differ if their types or values differ. A nil slice is
+// returned if the ImageSpecs are the same.
+func (s *ImageSpec) Diff(other *ImageSpec) []ImageSpecDiff {
+	var diffs []ImageSpecDiff
+
+	a := reflect.ValueOf(newImageSpecJSON(s)).Elem()
+	b := reflect.ValueOf(newImageSpecJSON(other)).Elem()
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		if field.Name == "Attributes" {
+			continue
+		}
+		oldVal, newVal := a.Field(i).Interface(), b.Field(i).Interface()
+		if !reflect.DeepEqual(oldVal, newVal) {
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			diffs = append(diffs, ImageSpecDiff{Name: name, Old: oldVal, New: newVal})
+		}
+	}
+
+	otherAttrs := other.Attributes()
+	byName := make(map[string]Attribute, len(otherAttrs))
+	for _, attr := range otherAttrs {
+		byName[attr.Name] = attr
+	}
+
+	seen := make(map[string]bool)
+	for _, attr := range s.Attributes() {
+		seen[attr.Name] = true
+		diff := ImageSpecDiff{Name: attr.Name, Attribute: true, Old: attr.Value}
+
+		if otherAttr, ok := byName[attr.Name]; ok {
+			if otherAttr.Type == attr.Type && reflect.DeepEqual(otherAttr.Value, attr.Value) {
+				continue
+			}
+			diff.New = otherAttr.Value
+		}
+		diffs = append(diffs, diff)
+	}
+
+	for _, attr := range otherAttrs {
+		if !seen[attr.Name] {
+			diffs = append(diffs, ImageSpecDiff{Name: attr.Name, Attribute: true, New: attr.Value})
+		}
+	}
+
+	return diffs
+}
@@ -0,0 +1,118 @@
+package oiio
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestJSONSpec(t *testing.T) *ImageSpec {
+	spec := NewImageSpecSize(64, 32, 4, TypeHalf())
+	spec.SetX(-8)
+	spec.SetFullWidth(128)
+	spec.SetTileWidth(16)
+	spec.SetTileHeight(16)
+	spec.SetChannelNames([]string{"R", "G", "B", "A"})
+	spec.SetChannelFormats([]TypeDesc{TypeHalf(), TypeHalf(), TypeHalf(), TypeFloat()})
+	spec.SetAlphaChannel(3)
+
+	spec.SetAttribute("Software", "oiio test")
+	spec.SetAttribute("oiio:BitsPerSample", 16)
+	spec.SetAttribute("PixelAspectRatio", float32(0.1))
+	spec.SetAttributeMatrix44("worldtocamera", Matrix44{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0.5, -2, 1e-7, 1})
+	spec.SetAttributeRational("FramesPerSecond", Rational{24000, 1001})
+	spec.SetAttributeTimeCode("smpte:TimeCode", TimeCode{0x01020304, 0})
+	checkFatalError(t, spec.SetAttributeType("tint", TypeColor(), []float32{1, 0.5, 0.25}))
+	checkFatalError(t, spec.SetAttributeType("big", TypeUint64(), []uint64{1<<64 - 1}))
+	checkFatalError(t, spec.SetAttributeType("precise", TypeDouble(), []float64{0.1}))
+	checkFatalError(t, spec.SetAttributeBytes("GPS:VersionID", []byte{2, 2, 0, 0}))
+	checkFatalError(t, spec.SetAttributeStrings("keywords", []string{"a", "b"}))
+
+	return spec
+}
+
+func TestImageSpecJSON(t *testing.T) {
+	spec := newTestJSONSpec(t)
+
+	data, err := json.Marshal(spec)
+	checkFatalError(t, err)
+
+	var fields map[string]interface{}
+	checkFatalError(t, json.Unmarshal(data, &fields))
+	for _, key := range []string{"width", "full_width", "tile_width", "format", "channelformats", "channelnames", "attributes"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("Expected JSON field %q in %s", key, data)
+		}
+	}
+	if fields["format"] != "half" {
+		t.Errorf("Expected format half; got %v", fields["format"])
+	}
+
+	// Into a zero ImageSpec
+	decoded := new(ImageSpec)
+	checkFatalError(t, json.Unmarshal(data, decoded))
+
+	if diffs := spec.Diff(decoded); diffs != nil {
+		t.Errorf("Expected the decoded ImageSpec to match; got differences %v", diffs)
+	}
+	if !reflect.DeepEqual(spec.Attributes(), decoded.Attributes()) {
+		t.Errorf("Expected attributes:\n%v\ngot:\n%v", spec.Attributes(), decoded.Attributes())
+	}
+
+	// Replacing an existing ImageSpec
+	existing := NewImageSpecSize(8, 8, 1, TypeUint8())
+	existing.SetAttribute("stale", 1)
+	checkFatalError(t, json.Unmarshal(data, existing))
+
+	if diffs := spec.Diff(existing); diffs != nil {
+		t.Errorf("Expected the replaced ImageSpec to match; got differences %v", diffs)
+	}
+}
+
+func TestImageSpecJSONErrors(t *testing.T) {
+	invalid := []string{
+		`{"format": "not a type"}`,
+		`{"nchannels": 2, "channelnames": ["R"]}`,
+		`{"attributes": [{"name": "a", "type": "int", "value": "one"}]}`,
+		`{"attributes": [{"name": "a", "type": "color", "value": [1, 2]}]}`,
+		`{"attributes": [{"name": "a", "type": "uint8", "value": 256}]}`,
+	}
+
+	for _, data := range invalid {
+		spec := NewImageSpec(TypeUnknown())
+		if err := json.Unmarshal([]byte(data), spec); err == nil {
+			t.Errorf("Expected an error decoding %s", data)
+		}
+	}
+}
+
+func TestImageSpecDiff(t *testing.T) {
+	a := newTestJSONSpec(t)
+	b := newTestJSONSpec(t)
+
+	if diffs := a.Diff(b); diffs != nil {
+		t.Fatalf("Expected no differences; got %v", diffs)
+	}
+
+	b.SetWidth(128)
+	b.SetAttribute("Software", "other")
+	b.EraseAttribute("tint", true)
+	b.SetAttribute("new", 1)
+
+	expected := []ImageSpecDiff{
+		{Name: "width", Old: 64, New: 128},
+		{Name: "Software", Attribute: true, Old: "oiio test", New: "other"},
+		{Name: "tint", Attribute: true, Old: Vec3{1, 0.5, 0.25}},
+		{Name: "new", Attribute: true, New: 1},
+	}
+
+	actual := a.Diff(b)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Expected differences:\n%v\ngot:\n%v", expected, actual)
+	}
+
+	if s := actual[0].String(); !strings.Contains(s, "width") || !strings.Contains(s, "128") {
+		t.Errorf("Expected the difference to describe the width; got %q", s)
+	}
+}
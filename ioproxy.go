@@ -0,0 +1,178 @@
+package oiio
+
+/*
+#include "stdlib.h"
+
+#include "cpp/oiio.h"
+
+*/
+import "C"
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"runtime"
+	"runtime/cgo"
+	"strings"
+	"unsafe"
+)
+
+//export ioproxy_read_at
+func ioproxy_read_at(handle C.uintptr_t, data unsafe.Pointer, size C.size_t, offset C.int64_t) C.size_t {
+	r, ok := cgo.Handle(handle).Value().(io.ReaderAt)
+	if !ok || size == 0 {
+		return 0
+	}
+	view, err := pixelSliceView(data, int(size), TypeUint8())
+	if err != nil {
+		return 0
+	}
+	n, _ := r.ReadAt(view.([]uint8), int64(offset))
+	return C.size_t(n)
+}
+
+// Return an error if the linked OpenImageIO can not read or write
+// images in memory.
+func checkIOProxy() error {
+	if !bool(C.ioproxy_supported()) {
+		return errors.New("Reading and writing images in memory requires OpenImageIO 2.0 or newer")
+	}
+	return nil
+}
+
+// Return whether the format of the named file (or the named format)
+// can read an image in memory, through an IOProxy.
+func inputSupportsIOProxy(name string) bool {
+	c_str := C.CString(name)
+	defer C.free(unsafe.Pointer(c_str))
+
+	c_feature := C.CString("ioproxy")
+	defer C.free(unsafe.Pointer(c_feature))
+
+	return bool(C.ImageInput_format_supports(c_str, c_feature))
+}
+
+// Return the names of all of the formats that OpenImageIO knows about.
+func formatNames() []string {
+	c_str := C.oiio_extension_list()
+	if c_str == nil {
+		return nil
+	}
+	defer C.free(unsafe.Pointer(c_str))
+
+	var names []string
+	for _, entry := range strings.Split(C.GoString(c_str), ";") {
+		if idx := strings.Index(entry, ":"); idx > 0 {
+			names = append(names, entry[:idx])
+		}
+	}
+	return names
+}
+
+// Return an io.ReaderAt over the remaining contents of r, and their size.
+// A reader that can seek and read at an offset (ie. *os.File or
+// *bytes.Reader) is used directly, and is left at its end. The contents
+// of any other reader are read into memory.
+func readerAtFor(r io.Reader) (io.ReaderAt, int64, error) {
+	if rs, ok := r.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		start, err := rs.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, 0, err
+		}
+		end, err := rs.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, 0, err
+		}
+		return io.NewSectionReader(rs, start, end-start), end - start, nil
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, int64(len(data)), err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// A stream connects an ImageInput or ImageOutput to a Go io.ReaderAt or
+// io.Writer, through an IOProxy.
+type stream struct {
+	proxy unsafe.Pointer
+
+	// The io.ReaderAt of an input stream
+	handle cgo.Handle
+
+	// Destination of an output stream
+	w io.Writer
+}
+
+func newStream() *stream {
+	s := &stream{}
+	runtime.SetFinalizer(s, deleteStream)
+	return s
+}
+
+func deleteStream(s *stream) {
+	s.release()
+}
+
+// Create a stream that reads from r through an IOProxy.
+func newReaderStream(name string, r io.ReaderAt, size int64) *stream {
+	c_str := C.CString(name)
+	defer C.free(unsafe.Pointer(c_str))
+
+	s := newStream()
+	s.handle = cgo.NewHandle(r)
+	s.proxy = unsafe.Pointer(C.IOProxy_NewReader(c_str, C.uintptr_t(s.handle), C.int64_t(size)))
+	return s
+}
+
+// Create a stream that collects the written file in memory, through
+// an IOProxy, and copies it to w on flush().
+func newWriterStream(name string, w io.Writer) *stream {
+	c_str := C.CString(name)
+	defer C.free(unsafe.Pointer(c_str))
+
+	s := newStream()
+	s.w = w
+	s.proxy = unsafe.Pointer(C.IOProxy_NewWriter(c_str))
+	return s
+}
+
+// Copy the written file to the io.Writer of an output stream.
+func (s *stream) flush() error {
+	if s.w == nil {
+		return nil
+	}
+	if s.proxy == nil {
+		return errors.New("Stream has already been released")
+	}
+
+	size := int(C.IOProxy_writer_size(s.proxy))
+	if size == 0 {
+		return nil
+	}
+	data, err := pixelSliceView(unsafe.Pointer(C.IOProxy_writer_data(s.proxy)), size, TypeUint8())
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(data.([]uint8))
+	return err
+}
+
+// Release the IOProxy of the stream.
+func (s *stream) release() {
+	if s.proxy != nil {
+		C.deleteIOProxy(s.proxy)
+		s.proxy = nil
+	}
+	if s.handle != 0 {
+		s.handle.Delete()
+		s.handle = 0
+	}
+	s.w = nil
+}
@@ -0,0 +1,75 @@
+package oiio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+// Skip a test of reading and writing the formats in memory, if the linked
+// OpenImageIO can not do so, after checking that it reports an error.
+func requireIOProxy(t *testing.T, formats ...string) {
+	if checkIOProxy() != nil {
+		if _, err := OpenImageInputReader(formats[0], bytes.NewReader(nil), 0); err == nil {
+			t.Error("Expected an error reading from memory without IOProxy support")
+		}
+		t.Skip("OpenImageIO does not support reading and writing in memory")
+	}
+	for _, format := range formats {
+		if !inputSupportsIOProxy(format) {
+			if _, err := OpenImageInputReader(format, bytes.NewReader(nil), 0); err == nil {
+				t.Errorf("Expected an error reading %s from memory", format)
+			}
+			t.Skipf("The %s format can not be read from memory", format)
+		}
+	}
+}
+
+func TestReaderAtFor(t *testing.T) {
+	data := []byte("header image data")
+
+	// Seekable readers are used from their current offset
+	r := bytes.NewReader(data)
+	if _, err := r.Seek(7, io.SeekStart); err != nil {
+		t.Fatal(err.Error())
+	}
+	ra, size, err := readerAtFor(r)
+	checkFatalError(t, err)
+	if size != int64(len(data)-7) {
+		t.Errorf("Expected size %d; got %d", len(data)-7, size)
+	}
+	actual, err := ioutil.ReadAll(io.NewSectionReader(ra, 0, size))
+	checkFatalError(t, err)
+	if !bytes.Equal(data[7:], actual) {
+		t.Errorf("Expected %q; got %q", data[7:], actual)
+	}
+	if r.Len() != 0 {
+		t.Errorf("Expected the reader to be consumed; %d bytes remain", r.Len())
+	}
+
+	// Other readers are read into memory
+	ra, size, err = readerAtFor(struct{ io.Reader }{bytes.NewBuffer(data)})
+	checkFatalError(t, err)
+	if size != int64(len(data)) {
+		t.Errorf("Expected size %d; got %d", len(data), size)
+	}
+	actual, err = ioutil.ReadAll(io.NewSectionReader(ra, 0, size))
+	checkFatalError(t, err)
+	if !bytes.Equal(data, actual) {
+		t.Errorf("Expected %q; got %q", data, actual)
+	}
+}
+
+func TestFormatNames(t *testing.T) {
+	names := formatNames()
+	found := false
+	for _, name := range names {
+		if name == "png" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected png in the format names; got %v", names)
+	}
+}
@@ -0,0 +1,203 @@
+package oiio
+
+import (
+	"context"
+	"fmt"
+)
+
+// The number of scanlines in each region, when iterating
+// over the tiles of an image that is not tiled.
+const scanlineTileBand = 64
+
+// PixelIterator reads the pixels of the current subimage and MIP level of
+// an ImageInput one region at a time, so that large images can be processed
+// without holding all of their pixels in memory. Regions are read in order
+// of increasing x, then y, then z.
+//
+// A PixelIterator is created with ImageInput.Scanlines or ImageInput.Tiles.
+//
+// Example:
+//
+//     it := in.Scanlines(ctx, 64, TypeFloat())
+//     for it.Next() {
+//         roi := it.ROI()
+//         pixels := it.Pixels().([]float32)
+//         ...
+//     }
+//     if err := it.Err(); err != nil {
+//         panic(err.Error())
+//     }
+//
+type PixelIterator struct {
+	in     *ImageInput
+	ctx    context.Context
+	format TypeDesc
+	tiled  bool
+
+	// The subimage and MIP level being read
+	subimage, miplevel int
+
+	// The pixel data window and the number of channels
+	xbegin, xend, ybegin, yend, zbegin, zend int
+	nchannels                                int
+
+	// The size of each region
+	stepx, stepy, stepz int
+
+	// The origin of the next region
+	x, y, z int
+
+	roi    *ROI
+	pixels interface{}
+	err    error
+}
+
+func newPixelIterator(in *ImageInput, ctx context.Context, format TypeDesc,
+	stepx, stepy, stepz int) *PixelIterator {
+
+	spec := in.Spec()
+
+	it := &PixelIterator{
+		in:        in,
+		ctx:       ctx,
+		format:    format,
+		tiled:     spec.TileWidth() > 0,
+		subimage:  in.CurrentSubimage(),
+		miplevel:  in.CurrentMipLevel(),
+		xbegin:    spec.X(),
+		xend:      spec.X() + spec.Width(),
+		ybegin:    spec.Y(),
+		yend:      spec.Y() + spec.Height(),
+		zbegin:    spec.Z(),
+		zend:      spec.Z() + maxInt(spec.Depth(), 1),
+		nchannels: spec.NumChannels(),
+		stepx:     stepx,
+		stepy:     stepy,
+		stepz:     stepz,
+	}
+	it.x, it.y, it.z = it.xbegin, it.ybegin, it.zbegin
+
+	if ctx == nil {
+		it.ctx = context.Background()
+	}
+	return it
+}
+
+// Scanlines returns a PixelIterator over bands of band scanlines, each
+// spanning the full width of the current subimage and MIP level, with
+// all channels converted to the requested format. The last band of the
+// image may be shorter.
+//
+// If the image is tiled, it is read a row of tiles at a time, and band is
+// rounded up to a multiple of the tile height. Iteration stops early with
+// the error of ctx, once ctx is cancelled.
+func (i *ImageInput) Scanlines(ctx context.Context, band int, format TypeDesc) *PixelIterator {
+	spec := i.Spec()
+
+	if band < 1 {
+		band = 1
+	}
+
+	stepz := 1
+	if th := spec.TileHeight(); spec.TileWidth() > 0 && th > 0 {
+		band = (band + th - 1) / th * th
+		stepz = maxInt(spec.TileDepth(), 1)
+	}
+
+	return newPixelIterator(i, ctx, format, spec.Width(), band, stepz)
+}
+
+// Tiles returns a PixelIterator over the tiles of the current subimage and
+// MIP level, with all channels converted to the requested format. Tiles at
+// the right and bottom edges of the image are clipped to the data window.
+//
+// If the image is not tiled, it is read in bands of 64 scanlines that
+// span the full width of the image, as with Scanlines. Iteration stops
+// early with the error of ctx, once ctx is cancelled.
+func (i *ImageInput) Tiles(ctx context.Context, format TypeDesc) *PixelIterator {
+	spec := i.Spec()
+
+	if spec.TileWidth() <= 0 {
+		return newPixelIterator(i, ctx, format, spec.Width(), scanlineTileBand, 1)
+	}
+
+	return newPixelIterator(i, ctx, format,
+		spec.TileWidth(), maxInt(spec.TileHeight(), 1), maxInt(spec.TileDepth(), 1))
+}
+
+// Next reads the next region of the image, which is then available
+// through ROI and Pixels. It returns false when there are no more
+// regions, or if an error occurred, which is reported by Err.
+func (it *PixelIterator) Next() bool {
+	it.roi, it.pixels = nil, nil
+
+	if it.err != nil || it.z >= it.zend || it.xbegin >= it.xend || it.ybegin >= it.yend {
+		return false
+	}
+
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+
+	// The ImageInput may have been seeked to another subimage
+	// since the iterator was created
+	if it.in.CurrentSubimage() != it.subimage || it.in.CurrentMipLevel() != it.miplevel {
+		if !it.in.SeekMipLevel(it.subimage, it.miplevel, nil) {
+			if it.err = it.in.LastError(); it.err == nil {
+				it.err = fmt.Errorf("Could not seek to subimage %d, MIP level %d", it.subimage, it.miplevel)
+			}
+			return false
+		}
+	}
+
+	xend := minInt(it.x+it.stepx, it.xend)
+	yend := minInt(it.y+it.stepy, it.yend)
+	zend := minInt(it.z+it.stepz, it.zend)
+
+	var pixels interface{}
+	var err error
+
+	if it.tiled {
+		pixels, err = it.in.ReadTilesFormat(it.x, xend, it.y, yend, it.z, zend, 0, it.nchannels, it.format)
+	} else {
+		pixels, err = it.in.ReadScanlinesFormat(it.y, yend, it.z, 0, it.nchannels, it.format)
+	}
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	it.roi = NewROIRegion3D(it.x, xend, it.y, yend, it.z, zend, 0, it.nchannels)
+	it.pixels = pixels
+
+	// Advance to the next region
+	if it.x = xend; it.x >= it.xend {
+		it.x = it.xbegin
+		if it.y = yend; it.y >= it.yend {
+			it.y = it.ybegin
+			it.z = zend
+		}
+	}
+
+	return true
+}
+
+// ROI returns the region of the image that was read by the last call
+// to Next, including its channel range.
+func (it *PixelIterator) ROI() *ROI {
+	return it.roi
+}
+
+// Pixels returns the pixels that were read by the last call to Next,
+// as a slice of the type that ImageInput.ReadImageFormat returns for
+// the format of the iterator.
+func (it *PixelIterator) Pixels() interface{} {
+	return it.pixels
+}
+
+// Err returns the first error that stopped the iteration, if any.
+// It is the error of the context, if the context was cancelled.
+func (it *PixelIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,153 @@
+package oiio
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestImageInputScanlines(t *testing.T) {
+	in, err := OpenImageInput(TEST_IMAGE)
+	checkFatalError(t, err)
+	defer in.Close()
+
+	iface, err := in.ReadImageFormat(TypeFloat(), nil)
+	checkFatalError(t, err)
+	expected := iface.([]float32)
+
+	spec := in.Spec()
+	width, height := spec.Width(), spec.Height()
+
+	// A band that does not evenly divide the height
+	band := 20
+	var actual []float32
+	y := 0
+
+	it := in.Scanlines(context.Background(), band, TypeFloat())
+	for it.Next() {
+		roi := it.ROI()
+		if roi.YBegin() != y || roi.YEnd() != minInt(y+band, height) {
+			t.Fatalf("Expected band [%d,%d); got [%d,%d)", y, minInt(y+band, height), roi.YBegin(), roi.YEnd())
+		}
+		if roi.XBegin() != 0 || roi.XEnd() != width {
+			t.Fatalf("Expected band to span the width [0,%d); got [%d,%d)", width, roi.XBegin(), roi.XEnd())
+		}
+		actual = append(actual, it.Pixels().([]float32)...)
+		y = roi.YEnd()
+	}
+	checkFatalError(t, it.Err())
+
+	if y != height {
+		t.Fatalf("Expected to iterate to scanline %d; stopped at %d", height, y)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Error("Expected bands to match the pixels of ReadImageFormat")
+	}
+
+	// Tiles of a scanline image are bands
+	count := 0
+	it = in.Tiles(context.Background(), TypeUint8())
+	for it.Next() {
+		count++
+		if _, ok := it.Pixels().([]uint8); !ok {
+			t.Fatalf("Expected []uint8 pixels; got %T", it.Pixels())
+		}
+	}
+	checkFatalError(t, it.Err())
+
+	if expected := (height + scanlineTileBand - 1) / scanlineTileBand; count != expected {
+		t.Errorf("Expected %d bands; got %d", expected, count)
+	}
+}
+
+func TestImageInputTiles(t *testing.T) {
+	in, err := OpenImageInput(`testdata/checker_mip.tx`)
+	checkFatalError(t, err)
+	defer in.Close()
+
+	spec := in.Spec()
+	size := spec.TileWidth()
+	expected := (spec.Width() / size) * (spec.Height() / size)
+
+	count := 0
+	it := in.Tiles(context.Background(), TypeFloat())
+	for it.Next() {
+		count++
+		roi := it.ROI()
+		if roi.Width() != size || roi.Height() != size {
+			t.Fatalf("Expected %dx%d tile; got %dx%d", size, size, roi.Width(), roi.Height())
+		}
+
+		tile, err := in.ReadTile(roi.XBegin(), roi.YBegin(), roi.ZBegin())
+		checkFatalError(t, err)
+		if !reflect.DeepEqual(tile, it.Pixels()) {
+			t.Errorf("Tile at %d,%d does not match ReadTile", roi.XBegin(), roi.YBegin())
+		}
+	}
+	checkFatalError(t, it.Err())
+
+	if count != expected {
+		t.Errorf("Expected %d tiles; got %d", expected, count)
+	}
+}
+
+func TestImageInputTilesMipLevel(t *testing.T) {
+	in, err := OpenImageInput(`testdata/checker_mip.tx`)
+	checkFatalError(t, err)
+	defer in.Close()
+
+	if !in.SeekMipLevel(0, 1, nil) {
+		t.Fatal("Failed to seek to MIP level 1")
+	}
+	width, height := in.Spec().Width(), in.Spec().Height()
+
+	it := in.Scanlines(context.Background(), 1, TypeFloat())
+
+	// Iteration continues on the MIP level of the iterator
+	if !in.SeekMipLevel(0, 0, nil) {
+		t.Fatal("Failed to seek to MIP level 0")
+	}
+
+	npixels := 0
+	for it.Next() {
+		roi := it.ROI()
+		if roi.Width() != width {
+			t.Fatalf("Expected MIP level 1 width %d; got %d", width, roi.Width())
+		}
+		npixels += roi.NumPixels()
+	}
+	checkFatalError(t, it.Err())
+
+	if npixels != width*height {
+		t.Errorf("Expected %d pixels; got %d", width*height, npixels)
+	}
+	if in.CurrentMipLevel() != 1 {
+		t.Errorf("Expected iterator to seek to MIP level 1; got %d", in.CurrentMipLevel())
+	}
+}
+
+func TestImageInputScanlinesCancel(t *testing.T) {
+	in, err := OpenImageInput(TEST_IMAGE)
+	checkFatalError(t, err)
+	defer in.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	it := in.Scanlines(ctx, 1, TypeFloat())
+	if !it.Next() {
+		t.Fatalf("Expected to read the first scanline: %v", it.Err())
+	}
+
+	cancel()
+
+	if it.Next() {
+		t.Fatal("Expected iteration to stop once the context is cancelled")
+	}
+	if it.Err() != context.Canceled {
+		t.Errorf("Expected error %v; got %v", context.Canceled, it.Err())
+	}
+	if it.Pixels() != nil || it.ROI() != nil {
+		t.Error("Expected no pixels after the iteration stopped")
+	}
+}
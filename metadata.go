@@ -0,0 +1,235 @@
+package oiio
+
+/*
+#include "stdlib.h"
+
+#include "cpp/oiio.h"
+
+*/
+import "C"
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"unsafe"
+)
+
+// exifHeader prefixes the EXIF block of a JPEG APP1 marker
+var exifHeader = []byte("Exif\x00\x00")
+
+// exifISOName is the name of the ISO attribute that the linked release
+// of OIIO encodes into EXIF blocks
+var exifISOName = C.GoString(C.ImageSpec_exif_iso_name())
+
+// MetadataString returns the value of an attribute formatted as a string,
+// as reported by iinfo. If human is true, the value is formatted for
+// people to read, with units and names of enumerations where known,
+// such as "1/250 s" for an ExposureTime. An empty string is returned if
+// the attribute does not exist.
+func (s *ImageSpec) MetadataString(name string, human bool) string {
+	c_str := C.CString(name)
+	defer C.free(unsafe.Pointer(c_str))
+
+	c_val := C.ImageSpec_metadata_val(s.ptr, c_str, C.bool(human))
+	if c_val == nil {
+		return ""
+	}
+	defer C.free(unsafe.Pointer(c_val))
+	return C.GoString(c_val)
+}
+
+// ExifInfo holds the common camera settings of an image, as stored in its
+// EXIF metadata. Zero values are unknown.
+type ExifInfo struct {
+	Make  string
+	Model string
+
+	// ExposureTime is in seconds
+	ExposureTime float32
+	FNumber      float32
+	ISO          int
+	// FocalLength is in millimeters
+	FocalLength float32
+}
+
+// Exif returns the camera settings found in the attributes of the spec.
+func (s *ImageSpec) Exif() ExifInfo {
+	info := ExifInfo{
+		Make:         s.AttributeString("Make"),
+		Model:        s.AttributeString("Model"),
+		ExposureTime: s.metadataFloat("ExposureTime"),
+		FNumber:      s.metadataFloat("FNumber"),
+		ISO:          s.AttributeInt("Exif:PhotographicSensitivity"),
+		FocalLength:  s.metadataFloat("Exif:FocalLength"),
+	}
+	if info.ISO == 0 {
+		// Older releases of OIIO use the EXIF 2.2 name of the tag
+		info.ISO = s.AttributeInt("Exif:ISOSpeedRatings")
+	}
+	return info
+}
+
+// SetExif sets the attributes of the non-zero camera settings of info.
+// Zero values leave the existing attributes unchanged.
+func (s *ImageSpec) SetExif(info ExifInfo) {
+	if info.Make != "" {
+		s.SetAttribute("Make", info.Make)
+	}
+	if info.Model != "" {
+		s.SetAttribute("Model", info.Model)
+	}
+	if info.ExposureTime != 0 {
+		s.SetAttribute("ExposureTime", info.ExposureTime)
+	}
+	if info.FNumber != 0 {
+		s.SetAttribute("FNumber", info.FNumber)
+	}
+	if info.ISO != 0 {
+		s.SetAttribute(exifISOName, info.ISO)
+	}
+	if info.FocalLength != 0 {
+		s.SetAttribute("Exif:FocalLength", info.FocalLength)
+	}
+}
+
+// metadataFloat returns a numeric attribute as a float32, including
+// rational values. Zero is returned if the attribute does not exist.
+func (s *ImageSpec) metadataFloat(name string) float32 {
+	_, val, ok := s.findAttribute(name)
+	if !ok {
+		return 0
+	}
+	switch v := val.(type) {
+	case float32:
+		return v
+	case float64:
+		return float32(v)
+	case int32:
+		return float32(v)
+	case uint32:
+		return float32(v)
+	case Rational:
+		if v.Denominator == 0 {
+			return 0
+		}
+		return float32(v.Numerator) / float32(v.Denominator)
+	}
+	return s.AttributeFloat(name)
+}
+
+// Caption returns the IPTC caption of the image, which OIIO stores
+// as the "ImageDescription" attribute.
+func (s *ImageSpec) Caption() string {
+	return s.AttributeString("ImageDescription")
+}
+
+// SetCaption sets the IPTC caption of the image.
+func (s *ImageSpec) SetCaption(caption string) {
+	s.SetAttribute("ImageDescription", caption)
+}
+
+// Keywords returns the IPTC keywords of the image, which OIIO stores
+// as a single "Keywords" attribute separated by semicolons.
+func (s *ImageSpec) Keywords() []string {
+	val := s.AttributeString("Keywords")
+	if val == "" {
+		return nil
+	}
+	var keywords []string
+	for _, k := range strings.Split(val, ";") {
+		if k = strings.TrimSpace(k); k != "" {
+			keywords = append(keywords, k)
+		}
+	}
+	return keywords
+}
+
+// SetKeywords sets the IPTC keywords of the image. An empty list of
+// keywords erases the attribute.
+func (s *ImageSpec) SetKeywords(keywords []string) {
+	if len(keywords) == 0 {
+		s.EraseAttribute("Keywords", false)
+		return
+	}
+	s.SetAttribute("Keywords", strings.Join(keywords, "; "))
+}
+
+// DecodeEXIF decodes a raw EXIF block, such as the payload of the APP1
+// marker of a JPEG file or the EXIF IFD of a TIFF file, and adds its tags
+// to the attributes of the spec. A leading "Exif\0\0" header is skipped.
+func (s *ImageSpec) DecodeEXIF(data []byte) error {
+	data = bytes.TrimPrefix(data, exifHeader)
+	if len(data) == 0 {
+		return errors.New("Empty EXIF block")
+	}
+	if !bool(C.ImageSpec_decode_exif(s.ptr, unsafe.Pointer(&data[0]), C.int(len(data)))) {
+		return errors.New("Failed to decode EXIF block")
+	}
+	return nil
+}
+
+// EncodeEXIF encodes the EXIF attributes of the spec into a raw EXIF block,
+// starting at the TIFF header. A nil slice is returned if the spec holds
+// no EXIF metadata.
+func (s *ImageSpec) EncodeEXIF() []byte {
+	var size C.size_t
+	blob := C.ImageSpec_encode_exif(s.ptr, &size)
+	if blob == nil {
+		return nil
+	}
+	defer C.free(blob)
+	return C.GoBytes(blob, C.int(size))
+}
+
+// DecodeIPTC decodes a raw IPTC-IIM block, such as found in the Photoshop
+// APP13 marker of a JPEG file, and adds its records to the attributes
+// of the spec.
+func (s *ImageSpec) DecodeIPTC(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("Empty IPTC block")
+	}
+	if !bool(C.ImageSpec_decode_iptc(s.ptr, unsafe.Pointer(&data[0]), C.int(len(data)))) {
+		return errors.New("Failed to decode IPTC block")
+	}
+	return nil
+}
+
+// EncodeIPTC encodes the IPTC attributes of the spec into a raw IPTC-IIM
+// block. A nil slice is returned if the spec holds no IPTC metadata.
+func (s *ImageSpec) EncodeIPTC() []byte {
+	var size C.size_t
+	blob := C.ImageSpec_encode_iptc(s.ptr, &size)
+	if blob == nil {
+		return nil
+	}
+	defer C.free(blob)
+	return C.GoBytes(blob, C.int(size))
+}
+
+// DecodeXMP decodes an XMP packet and adds its properties to the
+// attributes of the spec.
+func (s *ImageSpec) DecodeXMP(xmp []byte) error {
+	c_str := C.CString(string(xmp))
+	defer C.free(unsafe.Pointer(c_str))
+
+	if !bool(C.ImageSpec_decode_xmp(s.ptr, c_str)) {
+		return errors.New("Failed to decode XMP packet")
+	}
+	return nil
+}
+
+// EncodeXMP encodes the attributes of the spec into an XMP packet. If
+// minimal is true, only the properties that are not also stored in the
+// EXIF or IPTC metadata of a file are encoded. A nil slice is returned
+// if the spec holds no XMP metadata.
+func (s *ImageSpec) EncodeXMP(minimal bool) []byte {
+	c_str := C.ImageSpec_encode_xmp(s.ptr, C.bool(minimal))
+	defer C.free(unsafe.Pointer(c_str))
+
+	xmp := C.GoString(c_str)
+	if xmp == "" {
+		return nil
+	}
+	return []byte(xmp)
+}
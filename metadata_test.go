@@ -0,0 +1,184 @@
+package oiio
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImageSpecMetadataString(t *testing.T) {
+	spec := NewImageSpec(TypeUint8())
+	spec.SetAttribute("ExposureTime", float32(0.004))
+	spec.SetAttribute("Software", "oiio test")
+
+	if actual := spec.MetadataString("Software", false); !strings.Contains(actual, "oiio test") {
+		t.Errorf("Expected Software to contain %q; got %q", "oiio test", actual)
+	}
+
+	raw := spec.MetadataString("ExposureTime", false)
+	if raw == "" {
+		t.Fatal("Expected a value for ExposureTime")
+	}
+	human := spec.MetadataString("ExposureTime", true)
+	if !strings.Contains(human, "1/250") {
+		t.Errorf("Expected human ExposureTime to contain %q; got %q", "1/250", human)
+	}
+
+	if actual := spec.MetadataString("missing", true); actual != "" {
+		t.Errorf("Expected empty string for a missing attribute; got %q", actual)
+	}
+}
+
+func TestImageSpecExif(t *testing.T) {
+	expected := ExifInfo{
+		Make:         "Canon",
+		Model:        "EOS 5D",
+		ExposureTime: 0.004,
+		FNumber:      2.8,
+		ISO:          400,
+		FocalLength:  50,
+	}
+
+	spec := NewImageSpec(TypeUint8())
+	if actual := spec.Exif(); actual != (ExifInfo{}) {
+		t.Errorf("Expected zero ExifInfo; got %+v", actual)
+	}
+
+	spec.SetExif(expected)
+	if actual := spec.Exif(); actual != expected {
+		t.Errorf("Expected %+v; got %+v", expected, actual)
+	}
+	// ISO is stored under the name that encode_exif knows
+	if actual := spec.AttributeInt(exifISOName); actual != expected.ISO {
+		t.Errorf("Expected %s %d; got %d", exifISOName, expected.ISO, actual)
+	}
+
+	// Zero values do not erase existing attributes
+	spec.SetExif(ExifInfo{Model: "EOS 6D"})
+	expected.Model = "EOS 6D"
+	if actual := spec.Exif(); actual != expected {
+		t.Errorf("Expected %+v; got %+v", expected, actual)
+	}
+
+	// Rational values are converted
+	spec.SetAttributeRational("ExposureTime", Rational{1, 125})
+	if actual := spec.Exif().ExposureTime; actual != 0.008 {
+		t.Errorf("Expected ExposureTime 0.008; got %v", actual)
+	}
+}
+
+func TestImageSpecEXIFBlock(t *testing.T) {
+	expected := ExifInfo{
+		Make:         "Canon",
+		Model:        "EOS 5D",
+		ExposureTime: 0.004,
+		FNumber:      2.8,
+		ISO:          400,
+		FocalLength:  50,
+	}
+
+	spec := NewImageSpec(TypeUint8())
+	spec.SetExif(expected)
+
+	blob := spec.EncodeEXIF()
+	if !bytes.HasPrefix(blob, []byte("II")) && !bytes.HasPrefix(blob, []byte("MM")) {
+		t.Fatalf("Expected EXIF block to start with a TIFF header; got % x", blob[:minInt(len(blob), 8)])
+	}
+
+	// The APP1 header of a JPEG is skipped
+	for _, data := range [][]byte{blob, append([]byte("Exif\x00\x00"), blob...)} {
+		decoded := NewImageSpec(TypeUint8())
+		checkFatalError(t, decoded.DecodeEXIF(data))
+
+		actual := decoded.Exif()
+		if actual.Make != expected.Make || actual.Model != expected.Model || actual.ISO != expected.ISO {
+			t.Errorf("Expected %+v; got %+v", expected, actual)
+		}
+		for name, vals := range map[string][2]float32{
+			"ExposureTime": {expected.ExposureTime, actual.ExposureTime},
+			"FNumber":      {expected.FNumber, actual.FNumber},
+			"FocalLength":  {expected.FocalLength, actual.FocalLength},
+		} {
+			if math.Abs(float64(vals[0]-vals[1])) > 1e-4 {
+				t.Errorf("Expected %s %v; got %v", name, vals[0], vals[1])
+			}
+		}
+	}
+
+	spec = NewImageSpec(TypeUint8())
+	if err := spec.DecodeEXIF(nil); err == nil {
+		t.Error("Expected error decoding an empty EXIF block")
+	}
+	if err := spec.DecodeEXIF([]byte("not an exif block")); err == nil {
+		t.Error("Expected error decoding an invalid EXIF block")
+	}
+}
+
+func TestImageSpecIPTCBlock(t *testing.T) {
+	spec := NewImageSpec(TypeUint8())
+	if actual := spec.Keywords(); actual != nil {
+		t.Errorf("Expected no keywords; got %v", actual)
+	}
+
+	spec.SetCaption("A caption")
+	keywords := []string{"sky", "blue sky", "clouds"}
+	spec.SetKeywords(keywords)
+
+	if actual := spec.Caption(); actual != "A caption" {
+		t.Errorf("Expected caption %q; got %q", "A caption", actual)
+	}
+	if actual := spec.Keywords(); !reflect.DeepEqual(keywords, actual) {
+		t.Errorf("Expected keywords %v; got %v", keywords, actual)
+	}
+
+	blob := spec.EncodeIPTC()
+	if len(blob) == 0 {
+		t.Fatal("Expected an IPTC block")
+	}
+
+	decoded := NewImageSpec(TypeUint8())
+	checkFatalError(t, decoded.DecodeIPTC(blob))
+
+	if actual := decoded.Caption(); actual != "A caption" {
+		t.Errorf("Expected caption %q; got %q", "A caption", actual)
+	}
+	if actual := decoded.Keywords(); !reflect.DeepEqual(keywords, actual) {
+		t.Errorf("Expected keywords %v; got %v", keywords, actual)
+	}
+
+	decoded.SetKeywords(nil)
+	if _, ok := decoded.AttributeType("Keywords"); ok {
+		t.Error("Expected empty keywords to erase the attribute")
+	}
+
+	if err := decoded.DecodeIPTC(nil); err == nil {
+		t.Error("Expected error decoding an empty IPTC block")
+	}
+}
+
+func TestImageSpecXMPBlock(t *testing.T) {
+	spec := NewImageSpec(TypeUint8())
+	spec.SetCaption("A caption")
+	spec.SetKeywords([]string{"sky", "clouds"})
+
+	xmp := spec.EncodeXMP(false)
+	if !bytes.Contains(xmp, []byte("x:xmpmeta")) {
+		t.Fatalf("Expected an XMP packet; got %q", xmp)
+	}
+
+	decoded := NewImageSpec(TypeUint8())
+	checkFatalError(t, decoded.DecodeXMP(xmp))
+
+	if actual := decoded.Caption(); actual != "A caption" {
+		t.Errorf("Expected caption %q; got %q", "A caption", actual)
+	}
+	if actual := decoded.Keywords(); !reflect.DeepEqual([]string{"sky", "clouds"}, actual) {
+		t.Errorf("Expected keywords %v; got %v", []string{"sky", "clouds"}, actual)
+	}
+
+	if actual := NewImageSpec(TypeUint8()).EncodeXMP(false); actual != nil {
+		t.Errorf("Expected no XMP packet for an empty spec; got %q", actual)
+	}
+}
@@ -22,46 +22,138 @@ package oiio
 
 #include "cpp/oiio.h"
 
-extern bool image_progress_callback(void* goCallback, float done);
-
 */
 import "C"
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/cgo"
+	"sync"
+	"sync/atomic"
 	"unsafe"
 )
 
-// Various representation formats for image data
-type TypeDesc int
-
-const (
-	TypeUnknown TypeDesc = C.TYPE_UNKNOWN
-	TypeUint8   TypeDesc = C.TYPE_UINT8
-	TypeInt8    TypeDesc = C.TYPE_INT8
-	TypeUint16  TypeDesc = C.TYPE_UINT16
-	TypeInt16   TypeDesc = C.TYPE_INT16
-	TypeUint    TypeDesc = C.TYPE_UINT
-	TypeInt     TypeDesc = C.TYPE_INT
-	TypeUint64  TypeDesc = C.TYPE_UINT64
-	TypeInt64   TypeDesc = C.TYPE_INT64
-	TypeHalf    TypeDesc = C.TYPE_HALF
-	TypeFloat   TypeDesc = C.TYPE_FLOAT
-	TypeDouble  TypeDesc = C.TYPE_DOUBLE
-)
+// AutoStride can be passed for any stride argument, to indicate
+// that the data is contiguous and the stride should be computed
+// from the data format and dimensions.
+const AutoStride int = C.AUTOSTRIDE
 
 // For image processing functions that accept a callback to monitor progress.
 // A function that will be passed a float value indicating the progress
 // percentage of the current operation. If the functon returns true, then
 // the process should be aborted. Return false to allow processing to continue.
+// If the function panics, the process is aborted, and the panic is returned
+// as the error of the operation.
 type ProgressCallback func(done float32) bool
 
+// A progress callback that is registered for the duration of a
+// single operation. A panic in the callback aborts the operation,
+// and is reported as its error.
+type progressEntry struct {
+	fn ProgressCallback
+
+	mu      sync.Mutex
+	failed  bool
+	failure interface{}
+}
+
+// The number of progress callbacks of operations in progress.
+var activeProgress int64
+
+// Register progress for the duration of an operation, and return the
+// cgo.Handle to pass to C, since Go pointers may not be retained by C.
+// A nil progress is registered as the 0 handle, which disables progress
+// reporting.
+func registerProgress(progress *ProgressCallback) uintptr {
+	if progress == nil || *progress == nil {
+		return 0
+	}
+	atomic.AddInt64(&activeProgress, 1)
+	return uintptr(cgo.NewHandle(&progressEntry{fn: *progress}))
+}
+
+func lookupProgress(handle uintptr) *progressEntry {
+	if handle == 0 {
+		return nil
+	}
+	return cgo.Handle(handle).Value().(*progressEntry)
+}
+
+// Unregister the progress callback of a finished operation. If the
+// callback panicked, the panic is returned as an error.
+func releaseProgress(handle uintptr) error {
+	if handle == 0 {
+		return nil
+	}
+	entry := lookupProgress(handle)
+	cgo.Handle(handle).Delete()
+	atomic.AddInt64(&activeProgress, -1)
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.failed {
+		return fmt.Errorf("Progress callback panicked: %v", entry.failure)
+	}
+	return nil
+}
+
+// Call the callback, recovering from a panic, which cancels the
+// operation. Once the callback has panicked, it is not called again.
+func (p *progressEntry) call(done float32) (cancel bool) {
+	p.mu.Lock()
+	failed := p.failed
+	p.mu.Unlock()
+	if failed {
+		return true
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			p.mu.Lock()
+			p.failed, p.failure = true, r
+			p.mu.Unlock()
+			cancel = true
+		}
+	}()
+	return p.fn(done)
+}
+
 //export image_progress_callback
-func image_progress_callback(goCallback unsafe.Pointer, done C.float) C.bool {
-	if goCallback == nil {
+func image_progress_callback(handle C.uintptr_t, done C.float) C.bool {
+	entry := lookupProgress(uintptr(handle))
+	if entry == nil {
 		return C.bool(false)
 	}
+	return C.bool(entry.call(float32(done)))
+}
+
+// Return a ProgressCallback that aborts the operation once ctx is done,
+// and that otherwise forwards to progress, if it is not nil.
+func contextProgress(ctx context.Context, progress *ProgressCallback) *ProgressCallback {
+	var cbk ProgressCallback = func(done float32) bool {
+		select {
+		case <-ctx.Done():
+			return true
+		default:
+		}
+		if progress != nil {
+			return (*progress)(done)
+		}
+		return false
+	}
+	return &cbk
+}
 
-	fn := *(*ProgressCallback)(goCallback)
-	cancel := fn(float32(done))
-	return C.bool(cancel)
+// Return the last global error generated by API calls that do not
+// belong to a specific object (ie. failing to create an ImageInput).
+// A nil error will be returned if no error has occured.
+func globalError() error {
+	c_str := C.oiio_geterror()
+	if c_str == nil {
+		return nil
+	}
+	defer C.free(unsafe.Pointer(c_str))
+	return errors.New(C.GoString(c_str))
 }
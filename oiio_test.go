@@ -8,6 +8,10 @@ import (
 	"image/png"
 	"io/ioutil"
 	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -38,6 +42,10 @@ func init() {
 }
 
 func createOutputFile() string {
+	return createOutputFileExt("png")
+}
+
+func createOutputFileExt(ext string) string {
 	tmpfile, err := ioutil.TempFile("", "oiio_unittest_output_")
 	if err != nil {
 		panic(err.Error())
@@ -45,7 +53,7 @@ func createOutputFile() string {
 
 	defer tmpfile.Close()
 
-	name := fmt.Sprintf("%s.png", tmpfile.Name())
+	name := fmt.Sprintf("%s.%s", tmpfile.Name(), ext)
 	os.Rename(tmpfile.Name(), name)
 	return name
 }
@@ -61,3 +69,106 @@ func checkFatalError(t *testing.T, err error) {
 		t.Fatal(err.Error())
 	}
 }
+
+func progressRegistrySize() int {
+	return int(atomic.LoadInt64(&activeProgress))
+}
+
+func TestProgressConcurrent(t *testing.T) {
+	const count = 8
+
+	var wg sync.WaitGroup
+	calls := make([]int32, count)
+	errs := make([]error, count)
+
+	for n := 0; n < count; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+
+			buf, err := NewImageBufPath(TEST_IMAGE)
+			if err != nil {
+				errs[n] = err
+				return
+			}
+
+			// Each operation counts its own progress
+			var progress ProgressCallback = func(done float32) bool {
+				atomic.AddInt32(&calls[n], 1)
+				return false
+			}
+			errs[n] = buf.ReadFormatCallback(true, TypeFloat(), &progress)
+		}(n)
+	}
+	wg.Wait()
+
+	for n := 0; n < count; n++ {
+		checkError(t, errs[n])
+		if calls[n] == 0 {
+			t.Errorf("Expected the progress callback of read %d to be called", n)
+		}
+	}
+
+	if size := progressRegistrySize(); size != 0 {
+		t.Errorf("Expected all progress callbacks to be released; %d remain", size)
+	}
+}
+
+func TestProgressPanic(t *testing.T) {
+	in, err := OpenImageInput(TEST_IMAGE)
+	checkFatalError(t, err)
+	defer in.Close()
+
+	var calls int
+	var progress ProgressCallback = func(done float32) bool {
+		calls++
+		panic("progress failed")
+	}
+
+	_, err = in.ReadImageFormat(TypeFloat(), &progress)
+	if err == nil {
+		t.Fatal("Expected the panic of the progress callback to be returned as an error")
+	}
+	if !strings.Contains(err.Error(), "progress failed") {
+		t.Errorf("Expected the error to report the panic; got %q", err.Error())
+	}
+	if calls != 1 {
+		t.Errorf("Expected the progress callback to be called once; got %d", calls)
+	}
+
+	if size := progressRegistrySize(); size != 0 {
+		t.Errorf("Expected the progress callback to be released; %d remain", size)
+	}
+
+	// The ImageInput is still usable
+	var ok ProgressCallback = func(done float32) bool { return false }
+	_, err = in.ReadImageFormat(TypeFloat(), &ok)
+	checkError(t, err)
+}
+
+// Run the progress tests again with the full cgo pointer checks, so that
+// any Go pointer that C retains, or writes into Go memory, is reported.
+// The default cgocheck=1 only checks the arguments of each call. The full
+// checks are compiled in with GOEXPERIMENT=cgocheck2, so the tests are
+// rebuilt and run with "go test".
+func TestProgressCgoCheck(t *testing.T) {
+	if os.Getenv("OIIO_TEST_CGOCHECK") != "" {
+		t.Skip("Already running with cgocheck2")
+	}
+	if testing.Short() {
+		t.Skip("Rebuilding the tests with cgocheck2 is slow")
+	}
+	gotool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("The go tool is needed to rebuild the tests with cgocheck2")
+	}
+
+	cmd := exec.Command(gotool, "test", "-count=1",
+		"-run", "^TestProgress(Concurrent|Panic)$|^TestImageBufContext$", ".")
+	cmd.Env = append(os.Environ(), "OIIO_TEST_CGOCHECK=1", "GOEXPERIMENT=cgocheck2")
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Progress tests failed with cgocheck enabled: %v\n%s", err, out)
+	}
+}
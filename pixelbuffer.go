@@ -0,0 +1,107 @@
+package oiio
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"unsafe"
+)
+
+// A PixelBuffer is a block of pixel memory that is pinned in the Go heap,
+// so that it can be shared with OpenImageIO without being copied.
+// It can be wrapped by an ImageBuf with NewImageBufWrap, and read or written
+// directly from Go through the typed slice returned by Pixels().
+//
+// The memory is unpinned when the PixelBuffer is garbage collected, or when
+// Free() is called explicitly. Since the slices returned by Pixels() refer
+// to the memory itself, they remain valid for as long as they are in use.
+type PixelBuffer struct {
+	ptr    unsafe.Pointer
+	pixels interface{}
+	pinner runtime.Pinner
+	size   int
+	format TypeDesc
+}
+
+func deletePixelBuffer(p *PixelBuffer) {
+	if p.ptr != nil {
+		p.pinner.Unpin()
+		p.ptr = nil
+		p.pixels = nil
+	}
+}
+
+// Allocate a PixelBuffer holding size values of the given format.
+// All values are initialized to 0.
+func NewPixelBuffer(size int, format TypeDesc) (*PixelBuffer, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("Invalid size %d; Must be greater than 0", size)
+	}
+
+	typ, err := pixelViewType(format)
+	if err != nil {
+		return nil, err
+	}
+
+	slice := reflect.MakeSlice(typ, size, size)
+	ptr := unsafe.Pointer(slice.Pointer())
+
+	buf := &PixelBuffer{ptr: ptr, pixels: slice.Interface(), size: size, format: format}
+	// OIIO holds on to the memory of a wrapping ImageBuf, which the
+	// garbage collector must not move
+	buf.pinner.Pin(ptr)
+	runtime.SetFinalizer(buf, deletePixelBuffer)
+	return buf, nil
+}
+
+// Allocate a PixelBuffer that is large enough to hold all of the pixels
+// of the ImageSpec, in the data format of the ImageSpec.
+func NewPixelBufferSpec(spec *ImageSpec) (*PixelBuffer, error) {
+	size := spec.Width() * spec.Height() * spec.Depth() * spec.NumChannels()
+	return NewPixelBuffer(size, spec.Format())
+}
+
+// Return the number of values held by the PixelBuffer.
+func (p *PixelBuffer) Len() int {
+	if p.ptr == nil {
+		return 0
+	}
+	return p.size
+}
+
+// Return the data format of the values held by the PixelBuffer.
+func (p *PixelBuffer) Format() TypeDesc {
+	return p.format
+}
+
+// Pixels returns a slice that directly views the memory of the
+// PixelBuffer, without copying. The type of the slice is determined by
+// the format of the PixelBuffer:
+//     TypeUint8()   => []uint8
+//     TypeInt8()    => []int8
+//     TypeUint16()  => []uint16
+//     TypeInt16()   => []int16
+//     TypeUint()    => []uint32
+//     TypeInt()     => []int32
+//     TypeUint64()  => []uint64
+//     TypeInt64()   => []int64
+//     TypeHalf()    => []uint16 (the raw bits of each half value)
+//     TypeFloat()   => []float32
+//     TypeDouble()  => []float64
+//
+// The slice keeps the memory alive on its own, even after the PixelBuffer
+// has been freed, but writes to it are only shared with an ImageBuf that
+// wraps the PixelBuffer until then.
+func (p *PixelBuffer) Pixels() interface{} {
+	if p.ptr == nil {
+		return nil
+	}
+	return p.pixels
+}
+
+// Free unpins the memory of the PixelBuffer immediately, rather than
+// waiting for it to be garbage collected. It must not be called while an
+// ImageBuf that wraps the PixelBuffer is still in use.
+func (p *PixelBuffer) Free() {
+	deletePixelBuffer(p)
+}
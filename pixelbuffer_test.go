@@ -0,0 +1,132 @@
+package oiio
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestNewPixelBuffer(t *testing.T) {
+	if _, err := NewPixelBuffer(0, TypeFloat()); err == nil {
+		t.Error("Expected an error when allocating 0 values")
+	}
+	if _, err := NewPixelBuffer(10, TypeUnknown()); err == nil {
+		t.Error("Expected an error when allocating an unknown TypeDesc")
+	}
+
+	pixbuf, err := NewPixelBuffer(10, TypeHalf())
+	checkFatalError(t, err)
+
+	if pixbuf.Len() != 10 {
+		t.Errorf("Expected 10 values; got %d", pixbuf.Len())
+	}
+	if pixbuf.Format() != TypeHalf() {
+		t.Errorf("Expected format TypeHalf; got %v", pixbuf.Format())
+	}
+
+	pixels, ok := pixbuf.Pixels().([]uint16)
+	if !ok {
+		t.Fatalf("Expected []uint16 pixels; got %T", pixbuf.Pixels())
+	}
+	if !reflect.DeepEqual(pixels, make([]uint16, 10)) {
+		t.Errorf("Expected pixels to be zero initialized; got %v", pixels)
+	}
+
+	pixbuf.Free()
+	if pixbuf.Len() != 0 || pixbuf.Pixels() != nil {
+		t.Error("Expected freed PixelBuffer to be empty")
+	}
+}
+
+func TestNewImageBufWrap(t *testing.T) {
+	spec := NewImageSpecSize(4, 2, 3, TypeFloat())
+
+	small, err := NewPixelBuffer(3, TypeFloat())
+	checkFatalError(t, err)
+	if _, err = NewImageBufWrap(spec, small); err == nil {
+		t.Error("Expected an error when the PixelBuffer is too small")
+	}
+
+	wrongType, err := NewPixelBufferSpec(NewImageSpecSize(4, 2, 3, TypeUint8()))
+	checkFatalError(t, err)
+	if _, err = NewImageBufWrap(spec, wrongType); err == nil {
+		t.Error("Expected an error when the PixelBuffer format does not match")
+	}
+
+	pixbuf, err := NewPixelBufferSpec(spec)
+	checkFatalError(t, err)
+
+	pixels := pixbuf.Pixels().([]float32)
+	pixels[0], pixels[1], pixels[2] = 0.25, 0.5, 1
+
+	buf, err := NewImageBufWrap(spec, pixbuf)
+	checkFatalError(t, err)
+
+	if buf.Storage() != IBStorageAppBuffer {
+		t.Errorf("Expected IBStorageAppBuffer; got %v", buf.Storage())
+	}
+
+	// Go writes are visible to the ImageBuf
+	if p := buf.GetPixel(0, 0, 0); !reflect.DeepEqual(p, []float32{0.25, 0.5, 1}) {
+		t.Errorf("Expected pixel (0,0) to be [0.25 0.5 1]; got %v", p)
+	}
+
+	// ImageBuf writes are visible to Go
+	checkFatalError(t, buf.SetPixel(3, 1, 0, []float32{1, 2, 3}))
+	if actual := pixels[21:24]; !reflect.DeepEqual(actual, []float32{1, 2, 3}) {
+		t.Errorf("Expected last pixel to be [1 2 3]; got %v", actual)
+	}
+
+	local, err := buf.LocalPixels()
+	checkFatalError(t, err)
+	if actual := local.Data.([]float32); &actual[0] != &pixels[0] {
+		t.Error("Expected LocalPixels to view the wrapped PixelBuffer memory")
+	}
+}
+
+func TestImageBufLocalPixels(t *testing.T) {
+	buf, err := NewImageBufSpec(NewImageSpecSize(4, 2, 3, TypeUint16()))
+	checkFatalError(t, err)
+	checkFatalError(t, Zero(buf))
+
+	view, err := buf.LocalPixels()
+	checkFatalError(t, err)
+
+	pixels, ok := view.Data.([]uint16)
+	if !ok {
+		t.Fatalf("Expected []uint16 pixels; got %T", view.Data)
+	}
+	if len(pixels) != 4*2*3 {
+		t.Fatalf("Expected %d values; got %d", 4*2*3, len(pixels))
+	}
+
+	// ImageBuf writes are visible in the view
+	checkFatalError(t, buf.SetPixel(1, 0, 0, []float32{1, 0, 1}))
+	if actual := pixels[3:6]; !reflect.DeepEqual(actual, []uint16{65535, 0, 65535}) {
+		t.Errorf("Expected pixel (1,0) to be [65535 0 65535]; got %v", actual)
+	}
+
+	// Writes to the view are visible to the ImageBuf
+	pixels[3] = 0
+	if p := buf.GetPixel(1, 0, 0); !reflect.DeepEqual(p, []float32{0, 0, 1}) {
+		t.Errorf("Expected pixel (1,0) to be [0 0 1]; got %v", p)
+	}
+
+	// A reachable view keeps its ImageBuf from being freed
+	buf = nil
+	runtime.GC()
+	runtime.GC()
+	if actual := pixels[3:6]; !reflect.DeepEqual(actual, []uint16{0, 0, 65535}) {
+		t.Errorf("Expected pixel (1,0) to be [0 0 65535]; got %v", actual)
+	}
+	runtime.KeepAlive(view)
+
+	cached, err := NewImageBufPath(TEST_IMAGE)
+	checkFatalError(t, err)
+	checkFatalError(t, cached.Read(false))
+	if cached.Storage() == IBStorageImageCache {
+		if _, err = cached.LocalPixels(); err == nil {
+			t.Error("Expected an error getting LocalPixels of an ImageCache backed ImageBuf")
+		}
+	}
+}
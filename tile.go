@@ -0,0 +1,218 @@
+package oiio
+
+/*
+#include "stdlib.h"
+
+#include "cpp/oiio.h"
+
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// Tile is a reference to a tile of pixels held by an ImageCache. The cache
+// counts the references to each tile, and will not free the pixels of a
+// tile while it is referenced, nor be destroyed itself. Each Tile must be
+// released with Release once its pixels are no longer needed. A Tile that
+// is garbage collected without being released is released by its
+// finalizer, but the cache may hold on to its memory for an unpredictable
+// time until then.
+type Tile struct {
+	mu    sync.Mutex
+	ptr   unsafe.Pointer
+	cache *ImageCache
+
+	roi    *ROI
+	format TypeDesc
+	pixels unsafe.Pointer
+	size   int
+}
+
+func newTile(i unsafe.Pointer, cache *ImageCache) *Tile {
+	tile := &Tile{ptr: i, cache: cache}
+	runtime.SetFinalizer(tile, deleteTile)
+	return tile
+}
+
+func deleteTile(t *Tile) {
+	t.Release()
+}
+
+// GetTile finds the tile of a subimage and MIP level of a file that
+// contains the pixel x, y, z, reading it into the cache if it is not
+// already there, and returns a new reference to it.
+func (i *ImageCache) GetTile(filename string, subimage, miplevel, x, y, z int) (*Tile, error) {
+	spec, err := i.ImageSpec(filename, subimage, miplevel, false)
+	if err != nil {
+		return nil, err
+	}
+
+	c_str := C.CString(filename)
+	defer C.free(unsafe.Pointer(c_str))
+
+	ptr := C.ImageCache_get_tile(i.ptr, c_str, C.int(subimage), C.int(miplevel), C.int(x), C.int(y), C.int(z))
+	if ptr == nil {
+		return nil, i.errorOr("No tile of %q contains pixel %d,%d,%d", filename, x, y, z)
+	}
+
+	tile := newTile(ptr, i.acquire())
+
+	var c_format C.FullTypeDesc
+	tile.pixels = C.ImageCache_tile_pixels(i.ptr, ptr, &c_format)
+	tile.format = newTypeDesc(c_format)
+
+	tile.roi = tileROI(spec, x, y, z)
+	tile.size = tile.roi.NumPixels() * tile.roi.NumChannels()
+	return tile, nil
+}
+
+// Return the region of the tile of spec that contains the pixel x, y, z.
+// Images that are not tiled are cached as a single tile.
+func tileROI(spec *ImageSpec, x, y, z int) *ROI {
+	tw, th, td := spec.TileWidth(), spec.TileHeight(), spec.TileDepth()
+	if tw == 0 || th == 0 {
+		tw, th, td = spec.Width(), spec.Height(), spec.Depth()
+	}
+	td = maxInt(td, 1)
+
+	xbegin := spec.X() + floorDiv(x-spec.X(), tw)*tw
+	ybegin := spec.Y() + floorDiv(y-spec.Y(), th)*th
+	zbegin := spec.Z() + floorDiv(z-spec.Z(), td)*td
+	return NewROIRegion3D(xbegin, xbegin+tw, ybegin, ybegin+th, zbegin, zbegin+td, 0, spec.NumChannels())
+}
+
+// Integer division of a by b, rounded towards negative infinity.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// Release drops the reference to the tile, allowing the cache to free its
+// pixels. It is safe to call Release more than once.
+func (t *Tile) Release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.ptr != nil {
+		C.ImageCache_release_tile(t.cache.ptr, t.ptr)
+		t.cache.Destroy(false)
+		t.ptr = nil
+		t.pixels = nil
+	}
+}
+
+// ROI returns the region of the image covered by the tile, including all
+// of its channels. Tiles on the edges of an image may extend past its
+// data window, in which case the pixels outside of the image are padding.
+func (t *Tile) ROI() *ROI {
+	return t.roi.Copy()
+}
+
+// Format returns the pixel format of the tile, as held by the cache.
+func (t *Tile) Format() TypeDesc {
+	return t.format
+}
+
+// TileView is a view of the pixels of a Tile, in the memory of the cache.
+// It holds its Tile, so that the finalizer of the Tile does not release
+// the pixels while the TileView is reachable.
+//
+// The garbage collector does not know that Data points into the memory of
+// the Tile, so code that uses Data after its last use of the TileView must
+// keep the TileView alive with runtime.KeepAlive after its last access to
+// Data. Data must not be modified, nor used after the Tile is released.
+type TileView struct {
+	// The pixels of all channels of the tile, as described by Tile.Pixels
+	Data interface{}
+
+	tile *Tile
+}
+
+// Pixels returns a view of the pixels of the tile, whose Data is a slice
+// that has been casted to an interface, typed according to the pixel
+// format of the tile:
+//
+//	TypeUint8()   => []uint8
+//	TypeInt8()    => []int8
+//	TypeUint16()  => []uint16
+//	TypeInt16()   => []int16
+//	TypeUint()    => []uint32
+//	TypeInt()     => []int32
+//	TypeUint64()  => []uint64
+//	TypeInt64()   => []int64
+//	TypeHalf()    => []uint16 (the bits of each half)
+//	TypeFloat()   => []float32
+//	TypeDouble()  => []float64
+//
+// The slice views the memory of the cache without copying it, and is only
+// valid while the TileView is reachable and the tile is not released.
+// An error is returned if the tile has been released.
+func (t *Tile) Pixels() (*TileView, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pixels == nil {
+		return nil, errors.New("Tile has been released")
+	}
+	data, err := pixelSliceView(t.pixels, t.size, t.format)
+	if err != nil {
+		return nil, err
+	}
+	return &TileView{Data: data, tile: t}, nil
+}
+
+// AddFile adds a synthetic image to the cache under filename, which does
+// not need to exist on disk. The image is described by spec, which should
+// be tiled. Its pixels are black until tiles are added with AddTile.
+func (i *ImageCache) AddFile(filename string, spec *ImageSpec) error {
+	c_str := C.CString(filename)
+	defer C.free(unsafe.Pointer(c_str))
+
+	if !bool(C.ImageCache_add_file(i.ptr, c_str, spec.ptr)) {
+		return i.errorOr("Failed to add file %q", filename)
+	}
+	return nil
+}
+
+// AddTile adds the pixels of the tile of a subimage and MIP level of a file
+// whose origin is the pixel x, y, z, such as a procedurally generated tile
+// of a file added with AddFile. The tile is pinned in the cache and is never
+// freed. The pixels must be a slice of one of the types accepted by
+// ImageBuf.SetPixels, holding the values of all channels of the full tile.
+func (i *ImageCache) AddTile(filename string, subimage, miplevel, x, y, z int, pixels interface{}) error {
+	ptr, size, format, err := pixelBufferInfo(pixels)
+	if err != nil {
+		return err
+	}
+
+	spec, err := i.ImageSpec(filename, subimage, miplevel, false)
+	if err != nil {
+		return err
+	}
+	roi := tileROI(spec, x, y, z)
+	if roi.XBegin() != x || roi.YBegin() != y || roi.ZBegin() != z {
+		return fmt.Errorf("Pixel %d,%d,%d is not the origin of a tile of %q", x, y, z, filename)
+	}
+	if err = checkPixelBufferSize(size, roi.NumPixels()*roi.NumChannels()); err != nil {
+		return err
+	}
+
+	c_str := C.CString(filename)
+	defer C.free(unsafe.Pointer(c_str))
+
+	ok := bool(C.ImageCache_add_tile(i.ptr, c_str, C.int(subimage), C.int(miplevel),
+		C.int(x), C.int(y), C.int(z), format.c(), ptr))
+	if !ok {
+		return i.errorOr("Failed to add tile %d,%d,%d of %q", x, y, z, filename)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package oiio
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestImageCacheGetTile(t *testing.T) {
+	cache := CreateImageCache(false)
+	defer cache.Destroy(true)
+	checkFatalError(t, cache.SetAttribute("autotile", 64))
+
+	tile, err := cache.GetTile(TEST_IMAGE, 0, 0, 70, 10, 0)
+	checkFatalError(t, err)
+	defer tile.Release()
+
+	roi := tile.ROI()
+	if roi.XBegin() != 64 || roi.XEnd() != 128 || roi.YBegin() != 0 || roi.YEnd() != 64 {
+		t.Errorf("Expected tile [64,128)x[0,64); got %v", roi)
+	}
+	if roi.NumChannels() != 4 {
+		t.Errorf("Expected 4 channels; got %d", roi.NumChannels())
+	}
+	if tile.Format() != TypeUint8() {
+		t.Errorf("Expected format %v; got %v", TypeUint8(), tile.Format())
+	}
+
+	view, err := tile.Pixels()
+	checkFatalError(t, err)
+	pixels, ok := view.Data.([]uint8)
+	if !ok {
+		t.Fatalf("Expected []uint8 pixels; got %T", view.Data)
+	}
+	if len(pixels) != 64*64*4 {
+		t.Fatalf("Expected %d values; got %d", 64*64*4, len(pixels))
+	}
+
+	expected, err := cache.GetPixels(TEST_IMAGE, 0, 0, roi, TypeUint8())
+	checkFatalError(t, err)
+	if !reflect.DeepEqual(expected, pixels) {
+		t.Error("Expected the tile pixels to match GetPixels")
+	}
+	runtime.KeepAlive(view)
+
+	tile.Release()
+	if _, err = tile.Pixels(); err == nil {
+		t.Error("Expected error getting the pixels of a released tile")
+	}
+	// Releasing more than once is safe
+	tile.Release()
+
+	// A view keeps its tile from being released by the finalizer
+	view, err = func() (*TileView, error) {
+		tile, err := cache.GetTile(TEST_IMAGE, 0, 0, 0, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		return tile.Pixels()
+	}()
+	checkFatalError(t, err)
+	runtime.GC()
+	runtime.GC()
+	if view.tile.ptr == nil {
+		t.Error("Expected the tile of a reachable view not to be released")
+	}
+	view.tile.Release()
+
+	if _, err = cache.GetTile(TEST_IMAGE, 0, 0, 1000, 0, 0); err == nil {
+		t.Error("Expected error getting a tile outside of the image")
+	}
+	if _, err = cache.GetTile("/does/not/exist.png", 0, 0, 0, 0, 0); err == nil {
+		t.Error("Expected error getting a tile of a missing file")
+	}
+}
+
+func TestImageCacheUntiledTile(t *testing.T) {
+	cache := CreateImageCache(false)
+	defer cache.Destroy(true)
+
+	// An untiled image is cached as a single tile
+	tile, err := cache.GetTile(TEST_IMAGE, 0, 0, 70, 10, 0)
+	checkFatalError(t, err)
+	defer tile.Release()
+
+	roi := tile.ROI()
+	if roi.Width() != 128 || roi.Height() != 64 {
+		t.Errorf("Expected a 128x64 tile; got %dx%d", roi.Width(), roi.Height())
+	}
+}
+
+func TestImageCacheAddTile(t *testing.T) {
+	cache := CreateImageCache(false)
+	defer cache.Destroy(true)
+
+	spec := NewImageSpecSize(64, 64, 1, TypeFloat())
+	spec.SetTileWidth(32)
+	spec.SetTileHeight(32)
+	spec.SetTileDepth(1)
+
+	const filename = "synthetic_tile_test"
+	checkFatalError(t, cache.AddFile(filename, spec))
+
+	tilePixels := make([]float32, 32*32)
+	for i := range tilePixels {
+		tilePixels[i] = float32(i)
+	}
+	checkFatalError(t, cache.AddTile(filename, 0, 0, 32, 0, 0, tilePixels))
+
+	actual, err := cache.GetPixels(filename, 0, 0, NewROIRegion2D(32, 64, 0, 32), TypeFloat())
+	checkFatalError(t, err)
+	if !reflect.DeepEqual(tilePixels, actual) {
+		t.Error("Expected the pixels of the added tile")
+	}
+
+	tile, err := cache.GetTile(filename, 0, 0, 40, 10, 0)
+	checkFatalError(t, err)
+	view, err := tile.Pixels()
+	checkFatalError(t, err)
+	if !reflect.DeepEqual(tilePixels, view.Data) {
+		t.Error("Expected the tile to view the pixels of the added tile")
+	}
+	tile.Release()
+
+	// Tiles that were not added are black
+	actual, err = cache.GetPixels(filename, 0, 0, NewROIRegion2D(0, 32, 32, 64), TypeFloat())
+	checkFatalError(t, err)
+	for _, val := range actual.([]float32) {
+		if val != 0 {
+			t.Fatalf("Expected black pixels; got %v", val)
+		}
+	}
+
+	if err = cache.AddTile(filename, 0, 0, 16, 0, 0, tilePixels); err == nil {
+		t.Error("Expected error adding a tile at a pixel that is not a tile origin")
+	}
+	if err = cache.AddTile(filename, 0, 0, 0, 0, 0, tilePixels[:10]); err == nil {
+		t.Error("Expected error adding a tile with too few pixels")
+	}
+	if err = cache.AddTile(filename, 0, 0, 0, 0, 0, "pixels"); err == nil {
+		t.Error("Expected error adding a tile of an unsupported type")
+	}
+}
@@ -0,0 +1,244 @@
+package oiio
+
+/*
+#include "stdlib.h"
+
+#include "cpp/oiio.h"
+
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// BaseType is the type of the individual values of a TypeDesc.
+// BaseUnknown is the zero value.
+type BaseType int
+
+const (
+	BaseUnknown BaseType = iota
+	BaseNone
+	BaseUint8
+	BaseInt8
+	BaseUint16
+	BaseInt16
+	BaseUint
+	BaseInt
+	BaseUint64
+	BaseInt64
+	BaseHalf
+	BaseFloat
+	BaseDouble
+	BaseString
+	BasePtr
+)
+
+// The values of the C TypeDesc enum, for each BaseType
+var cBaseTypes = [...]C.TypeDesc{
+	BaseUnknown: C.TYPE_UNKNOWN,
+	BaseNone:    C.TYPE_NONE,
+	BaseUint8:   C.TYPE_UINT8,
+	BaseInt8:    C.TYPE_INT8,
+	BaseUint16:  C.TYPE_UINT16,
+	BaseInt16:   C.TYPE_INT16,
+	BaseUint:    C.TYPE_UINT,
+	BaseInt:     C.TYPE_INT,
+	BaseUint64:  C.TYPE_UINT64,
+	BaseInt64:   C.TYPE_INT64,
+	BaseHalf:    C.TYPE_HALF,
+	BaseFloat:   C.TYPE_FLOAT,
+	BaseDouble:  C.TYPE_DOUBLE,
+	BaseString:  C.TYPE_STRING,
+	BasePtr:     C.TYPE_PTR,
+}
+
+func newBaseType(t C.TypeDesc) BaseType {
+	for base, c_base := range cBaseTypes {
+		if c_base == t {
+			return BaseType(base)
+		}
+	}
+	return BaseUnknown
+}
+
+func (b BaseType) c() C.TypeDesc {
+	if b < 0 || int(b) >= len(cBaseTypes) {
+		return C.TYPE_UNKNOWN
+	}
+	return cBaseTypes[b]
+}
+
+// Aggregate is the number of base values that make up a single
+// value of a TypeDesc, such as a vector or a matrix.
+// AggregateScalar, a single value, is the zero value.
+type Aggregate int
+
+const (
+	AggregateScalar   Aggregate = 0
+	AggregateVec2     Aggregate = 2
+	AggregateVec3     Aggregate = 3
+	AggregateVec4     Aggregate = 4
+	AggregateMatrix33 Aggregate = 9
+	AggregateMatrix44 Aggregate = 16
+)
+
+// VecSemantics is a hint about what the values of a TypeDesc represent
+type VecSemantics int
+
+const (
+	SemanticsNoXform  VecSemantics = 0
+	SemanticsColor    VecSemantics = 1
+	SemanticsPoint    VecSemantics = 2
+	SemanticsVector   VecSemantics = 3
+	SemanticsNormal   VecSemantics = 4
+	SemanticsTimeCode VecSemantics = 5
+	SemanticsKeyCode  VecSemantics = 6
+	SemanticsRational VecSemantics = 7
+)
+
+// TypeDesc describes a data type: a base type, optionally aggregated
+// into a vector or matrix, with a hint of its meaning, and optionally
+// made into an array. An ArrayLen of 0 means that the type is not an
+// array, and -1 means that it is an array of unspecified length.
+//
+// TypeDesc values are comparable, and the zero value is TypeUnknown().
+// The named types below may be compared against, and used as, the data
+// format of pixels.
+type TypeDesc struct {
+	BaseType     BaseType
+	Aggregate    Aggregate
+	VecSemantics VecSemantics
+	ArrayLen     int
+}
+
+// Various representation formats for image data.
+// Go has no struct constants, so each is returned by a function.
+func TypeUnknown() TypeDesc { return TypeDesc{} }
+func TypeUint8() TypeDesc   { return NewTypeDesc(BaseUint8) }
+func TypeInt8() TypeDesc    { return NewTypeDesc(BaseInt8) }
+func TypeUint16() TypeDesc  { return NewTypeDesc(BaseUint16) }
+func TypeInt16() TypeDesc   { return NewTypeDesc(BaseInt16) }
+func TypeUint() TypeDesc    { return NewTypeDesc(BaseUint) }
+func TypeInt() TypeDesc     { return NewTypeDesc(BaseInt) }
+func TypeUint64() TypeDesc  { return NewTypeDesc(BaseUint64) }
+func TypeInt64() TypeDesc   { return NewTypeDesc(BaseInt64) }
+func TypeHalf() TypeDesc    { return NewTypeDesc(BaseHalf) }
+func TypeFloat() TypeDesc   { return NewTypeDesc(BaseFloat) }
+func TypeDouble() TypeDesc  { return NewTypeDesc(BaseDouble) }
+func TypeString() TypeDesc  { return NewTypeDesc(BaseString) }
+
+// Common aggregate types of metadata
+func TypeFloat2() TypeDesc {
+	return TypeDesc{BaseType: BaseFloat, Aggregate: AggregateVec2}
+}
+func TypeFloat4() TypeDesc {
+	return TypeDesc{BaseType: BaseFloat, Aggregate: AggregateVec4}
+}
+func TypeColor() TypeDesc {
+	return TypeDesc{BaseType: BaseFloat, Aggregate: AggregateVec3, VecSemantics: SemanticsColor}
+}
+func TypePoint() TypeDesc {
+	return TypeDesc{BaseType: BaseFloat, Aggregate: AggregateVec3, VecSemantics: SemanticsPoint}
+}
+func TypeVector() TypeDesc {
+	return TypeDesc{BaseType: BaseFloat, Aggregate: AggregateVec3, VecSemantics: SemanticsVector}
+}
+func TypeNormal() TypeDesc {
+	return TypeDesc{BaseType: BaseFloat, Aggregate: AggregateVec3, VecSemantics: SemanticsNormal}
+}
+func TypeVector2i() TypeDesc {
+	return TypeDesc{BaseType: BaseInt, Aggregate: AggregateVec2}
+}
+func TypeMatrix33() TypeDesc {
+	return TypeDesc{BaseType: BaseFloat, Aggregate: AggregateMatrix33}
+}
+func TypeMatrix44() TypeDesc {
+	return TypeDesc{BaseType: BaseFloat, Aggregate: AggregateMatrix44}
+}
+func TypeTimeCode() TypeDesc {
+	return TypeDesc{BaseType: BaseUint, VecSemantics: SemanticsTimeCode, ArrayLen: 2}
+}
+func TypeKeyCode() TypeDesc {
+	return TypeDesc{BaseType: BaseInt, VecSemantics: SemanticsKeyCode, ArrayLen: 7}
+}
+func TypeRational() TypeDesc {
+	return TypeDesc{BaseType: BaseInt, Aggregate: AggregateVec2, VecSemantics: SemanticsRational}
+}
+
+// NewTypeDesc returns the TypeDesc of a scalar of the base type
+func NewTypeDesc(base BaseType) TypeDesc {
+	return TypeDesc{BaseType: base}
+}
+
+func newTypeDesc(t C.FullTypeDesc) TypeDesc {
+	agg := Aggregate(t.aggregate)
+	if agg == 1 {
+		agg = AggregateScalar
+	}
+	return TypeDesc{
+		BaseType:     newBaseType(t.basetype),
+		Aggregate:    agg,
+		VecSemantics: VecSemantics(t.vecsemantics),
+		ArrayLen:     int(t.arraylen),
+	}
+}
+
+func (t TypeDesc) c() C.FullTypeDesc {
+	agg := t.Aggregate
+	if agg == AggregateScalar {
+		agg = 1
+	}
+	return C.FullTypeDesc{
+		basetype:     t.BaseType.c(),
+		aggregate:    C.int(agg),
+		vecsemantics: C.int(t.VecSemantics),
+		arraylen:     C.int(t.ArrayLen),
+	}
+}
+
+// ParseTypeDesc parses a type name, as returned by TypeDesc.String,
+// such as "float", "color", "matrix", "timecode" or "int[4]".
+func ParseTypeDesc(s string) (TypeDesc, error) {
+	c_str := C.CString(s)
+	defer C.free(unsafe.Pointer(c_str))
+
+	var c_type C.FullTypeDesc
+	if !bool(C.TypeDesc_fromstring(c_str, &c_type)) {
+		return TypeUnknown(), fmt.Errorf("%q is not a valid TypeDesc", s)
+	}
+	return newTypeDesc(c_type), nil
+}
+
+// String returns the name of the type, such as "float", "color",
+// "matrix", "timecode" or "int[4]".
+func (t TypeDesc) String() string {
+	return C.GoString(C.TypeDesc_c_str(t.c()))
+}
+
+// Size returns the size in bytes of a single value of the type,
+// including all of its aggregate and array elements.
+func (t TypeDesc) Size() int {
+	return int(C.TypeDesc_size(t.c()))
+}
+
+// Scalar returns the TypeDesc of a single base value of the type
+func (t TypeDesc) Scalar() TypeDesc {
+	return NewTypeDesc(t.BaseType)
+}
+
+// NumValues returns the number of base values in a single value of
+// the type, including all of its aggregate and array elements.
+func (t TypeDesc) NumValues() int {
+	agg := int(t.Aggregate)
+	if t.Aggregate == AggregateScalar {
+		agg = 1
+	}
+	return agg * maxInt(t.ArrayLen, 1)
+}
+
+// IsArray returns true if the type is an array
+func (t TypeDesc) IsArray() bool {
+	return t.ArrayLen != 0
+}
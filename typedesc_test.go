@@ -0,0 +1,186 @@
+package oiio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTypeDescString(t *testing.T) {
+	names := map[string]TypeDesc{
+		"float":    TypeFloat(),
+		"uint8":    TypeUint8(),
+		"string":   TypeString(),
+		"color":    TypeColor(),
+		"point":    TypePoint(),
+		"matrix":   TypeMatrix44(),
+		"timecode": TypeTimeCode(),
+		"int[4]":   {BaseType: BaseInt, Aggregate: AggregateScalar, ArrayLen: 4},
+	}
+
+	for name, typ := range names {
+		if actual := typ.String(); actual != name {
+			t.Errorf("Expected %q; got %q", name, actual)
+		}
+
+		parsed, err := ParseTypeDesc(name)
+		checkError(t, err)
+		if parsed != typ {
+			t.Errorf("Expected %q to parse as %#v; got %#v", name, typ, parsed)
+		}
+	}
+
+	// Every named type survives a round trip
+	for _, typ := range []TypeDesc{
+		TypeHalf(), TypeDouble(), TypeFloat2(), TypeFloat4(), TypeVector(), TypeNormal(),
+		TypeVector2i(), TypeMatrix33(), TypeKeyCode(), TypeRational(),
+	} {
+		parsed, err := ParseTypeDesc(typ.String())
+		checkError(t, err)
+		if parsed != typ {
+			t.Errorf("Expected %q to parse as %#v; got %#v", typ.String(), typ, parsed)
+		}
+	}
+
+	if _, err := ParseTypeDesc("not a type"); err == nil {
+		t.Error("Expected an error parsing an invalid type name")
+	}
+}
+
+func TestTypeDescZeroValue(t *testing.T) {
+	var zero TypeDesc
+	if zero != TypeUnknown() {
+		t.Errorf("Expected the zero TypeDesc to be %#v; got %#v", TypeUnknown(), zero)
+	}
+	if name := zero.String(); name != "unknown" {
+		t.Errorf("Expected the zero TypeDesc to be named %q; got %q", "unknown", name)
+	}
+
+	// Unknown types that come back from OpenImageIO equal the zero value
+	spec := NewImageSpec(TypeUnknown())
+	if spec.Format() != zero {
+		t.Errorf("Expected format %#v; got %#v", zero, spec.Format())
+	}
+
+	// Scalars are the same with, or without, an explicit aggregate
+	if (TypeDesc{BaseType: BaseFloat}) != TypeFloat() {
+		t.Errorf("Expected a float without an aggregate to be %#v", TypeFloat())
+	}
+	parsed, err := ParseTypeDesc("float")
+	checkFatalError(t, err)
+	if parsed != (TypeDesc{BaseType: BaseFloat}) {
+		t.Errorf("Expected float to parse as a scalar; got %#v", parsed)
+	}
+}
+
+func TestTypeDescBaseTypes(t *testing.T) {
+	// Each BaseType survives the translation to the C enum and back
+	for base := BaseUnknown; base <= BasePtr; base++ {
+		if actual := newBaseType(base.c()); actual != base {
+			t.Errorf("Expected base type %d; got %d", base, actual)
+		}
+	}
+	if BaseType(100).c() != BaseUnknown.c() {
+		t.Error("Expected an invalid base type to be unknown")
+	}
+}
+
+func TestTypeDescSize(t *testing.T) {
+	sizes := map[TypeDesc]int{
+		TypeUint8():    1,
+		TypeHalf():     2,
+		TypeFloat():    4,
+		TypeDouble():   8,
+		TypeColor():    12,
+		TypeMatrix44(): 64,
+		TypeTimeCode(): 8,
+		TypeKeyCode():  28,
+		TypeRational(): 8,
+		{BaseType: BaseUint16, Aggregate: AggregateVec2, ArrayLen: 3}: 12,
+	}
+
+	for typ, size := range sizes {
+		if actual := typ.Size(); actual != size {
+			t.Errorf("Expected %v to be %d bytes; got %d", typ, size, actual)
+		}
+		if n := typ.NumValues(); n*typ.Scalar().Size() != size {
+			t.Errorf("Expected %v to hold %d bytes of base values; got %d values", typ, size, n)
+		}
+	}
+
+	if TypeColor().Scalar() != TypeFloat() {
+		t.Errorf("Expected the scalar of color to be float; got %v", TypeColor().Scalar())
+	}
+	if NewTypeDesc(BaseInt16) != TypeInt16() {
+		t.Errorf("Expected NewTypeDesc(BaseInt16) to be %v", TypeInt16())
+	}
+}
+
+func TestTypeDescFormats(t *testing.T) {
+	spec := NewImageSpecSize(4, 4, 2, TypeHalf())
+	if spec.Format() != TypeHalf() {
+		t.Errorf("Expected format %v; got %v", TypeHalf(), spec.Format())
+	}
+
+	checkFatalError(t, spec.SetChannelFormats([]TypeDesc{TypeUint8(), TypeFloat()}))
+	if !reflect.DeepEqual(spec.ChannelFormats(), []TypeDesc{TypeUint8(), TypeFloat()}) {
+		t.Errorf("Expected channel formats [uint8 float]; got %v", spec.ChannelFormats())
+	}
+	if spec.ChannelFormat(1) != TypeFloat() {
+		t.Errorf("Expected channel 1 format %v; got %v", TypeFloat(), spec.ChannelFormat(1))
+	}
+
+	// The named types work as pixel formats
+	switch spec.ChannelFormat(0) {
+	case TypeUint8():
+	default:
+		t.Errorf("Expected channel 0 format %v; got %v", TypeUint8(), spec.ChannelFormat(0))
+	}
+}
+
+func TestImageSpecAttributeType(t *testing.T) {
+	spec := NewImageSpecSize(4, 4, 3, TypeFloat())
+
+	checkFatalError(t, spec.SetAttributeType("tint", TypeColor(), []float32{1, 0.5, 0.25}))
+	checkFatalError(t, spec.SetAttributeType("keycode", TypeKeyCode(), []int{1, 2, 3, 4, 5, 6, 7}))
+	checkFatalError(t, spec.SetAttributeType("names", TypeDesc{BaseType: BaseString, Aggregate: AggregateScalar, ArrayLen: 2},
+		[]string{"a", "b"}))
+	checkFatalError(t, spec.SetAttributeType("half", TypeHalf(), []uint16{0x3c00}))
+
+	expected := []Attribute{
+		{"tint", TypeColor(), Vec3{1, 0.5, 0.25}},
+		{"keycode", TypeKeyCode(), []int{1, 2, 3, 4, 5, 6, 7}},
+		{"names", TypeDesc{BaseType: BaseString, Aggregate: AggregateScalar, ArrayLen: 2}, []string{"a", "b"}},
+		{"half", TypeHalf(), uint16(0x3c00)},
+	}
+	if actual := spec.Attributes(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected attributes:\n%v\ngot:\n%v", expected, actual)
+	}
+
+	if typ, ok := spec.AttributeType("TINT"); !ok || typ != TypeColor() {
+		t.Errorf("Expected attribute type %v; got %v (%v)", TypeColor(), typ, ok)
+	}
+	if _, ok := spec.AttributeType("missing"); ok {
+		t.Error("Expected a missing attribute not to have a type")
+	}
+
+	// Values that do not match the type
+	if err := spec.SetAttributeType("bad", TypeColor(), []float32{1}); err == nil {
+		t.Error("Expected an error setting a color from 1 value")
+	}
+	if err := spec.SetAttributeType("bad", TypeColor(), []float64{1, 2, 3}); err == nil {
+		t.Error("Expected an error setting a color from float64 values")
+	}
+	if err := spec.SetAttributeType("bad", TypeColor(), nil); err == nil {
+		t.Error("Expected an error setting a color from nil")
+	}
+
+	// Erasing matches the full type
+	spec.EraseAttributeType("tint", TypePoint(), false)
+	if _, ok := spec.AttributeVec3("tint"); !ok {
+		t.Error("Expected the color not to be erased by a point search type")
+	}
+	spec.EraseAttributeType("tint", TypeColor(), false)
+	if _, ok := spec.AttributeVec3("tint"); ok {
+		t.Error("Expected the color to be erased")
+	}
+}
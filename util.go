@@ -3,7 +3,9 @@ package oiio
 import (
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"strconv"
 	"unsafe"
 )
 
@@ -32,52 +34,52 @@ func allocatePixelBufferSize(size int, format TypeDesc) (interface{}, unsafe.Poi
 
 	switch format {
 
-	case TypeUint8:
+	case TypeUint8():
 		pixels := make([]uint8, size)
 		pixel_iface = reflect.ValueOf(pixels).Interface()
 		ptr = unsafe.Pointer(&pixels[0])
 
-	case TypeInt8:
+	case TypeInt8():
 		pixels := make([]int8, size)
 		pixel_iface = reflect.ValueOf(pixels).Interface()
 		ptr = unsafe.Pointer(&pixels[0])
 
-	case TypeUint16:
+	case TypeUint16():
 		pixels := make([]uint16, size)
 		pixel_iface = reflect.ValueOf(pixels).Interface()
 		ptr = unsafe.Pointer(&pixels[0])
 
-	case TypeInt16:
+	case TypeInt16():
 		pixels := make([]int16, size)
 		pixel_iface = reflect.ValueOf(pixels).Interface()
 		ptr = unsafe.Pointer(&pixels[0])
 
-	case TypeUint:
+	case TypeUint():
 		pixels := make([]uint, size)
 		pixel_iface = reflect.ValueOf(pixels).Interface()
 		ptr = unsafe.Pointer(&pixels[0])
 
-	case TypeInt:
+	case TypeInt():
 		pixels := make([]int, size)
 		pixel_iface = reflect.ValueOf(pixels).Interface()
 		ptr = unsafe.Pointer(&pixels[0])
 
-	case TypeUint64:
+	case TypeUint64():
 		pixels := make([]uint64, size)
 		pixel_iface = reflect.ValueOf(pixels).Interface()
 		ptr = unsafe.Pointer(&pixels[0])
 
-	case TypeInt64:
+	case TypeInt64():
 		pixels := make([]int64, size)
 		pixel_iface = reflect.ValueOf(pixels).Interface()
 		ptr = unsafe.Pointer(&pixels[0])
 
-	case TypeFloat, TypeHalf:
+	case TypeFloat(), TypeHalf():
 		pixels := make([]float32, size)
 		pixel_iface = reflect.ValueOf(pixels).Interface()
 		ptr = unsafe.Pointer(&pixels[0])
 
-	case TypeDouble:
+	case TypeDouble():
 		pixels := make([]float64, size)
 		pixel_iface = reflect.ValueOf(pixels).Interface()
 		ptr = unsafe.Pointer(&pixels[0])
@@ -89,3 +91,299 @@ func allocatePixelBufferSize(size int, format TypeDesc) (interface{}, unsafe.Poi
 
 	return pixel_iface, ptr, nil
 }
+
+// Given a slice of pixel values, return a pointer to the first element,
+// the number of elements in the slice, and the TypeDesc that describes
+// how the values are laid out in memory.
+// Accepted slice types are:
+//     []uint8   => TypeUint8()
+//     []int8    => TypeInt8()
+//     []uint16  => TypeUint16()
+//     []int16   => TypeInt16()
+//     []uint32  => TypeUint()
+//     []int32   => TypeInt()
+//     []uint64  => TypeUint64()
+//     []int64   => TypeInt64()
+//     []uint    => TypeUint() or TypeUint64(), depending on the platform
+//     []int     => TypeInt() or TypeInt64(), depending on the platform
+//     []float32 => TypeFloat()
+//     []float64 => TypeDouble()
+func pixelBufferInfo(pixels interface{}) (unsafe.Pointer, int, TypeDesc, error) {
+	var (
+		ptr    unsafe.Pointer
+		size   int
+		format TypeDesc
+	)
+
+	switch t := pixels.(type) {
+
+	case []uint8:
+		size, format = len(t), TypeUint8()
+		if size > 0 {
+			ptr = unsafe.Pointer(&t[0])
+		}
+
+	case []int8:
+		size, format = len(t), TypeInt8()
+		if size > 0 {
+			ptr = unsafe.Pointer(&t[0])
+		}
+
+	case []uint16:
+		size, format = len(t), TypeUint16()
+		if size > 0 {
+			ptr = unsafe.Pointer(&t[0])
+		}
+
+	case []int16:
+		size, format = len(t), TypeInt16()
+		if size > 0 {
+			ptr = unsafe.Pointer(&t[0])
+		}
+
+	case []uint32:
+		size, format = len(t), TypeUint()
+		if size > 0 {
+			ptr = unsafe.Pointer(&t[0])
+		}
+
+	case []int32:
+		size, format = len(t), TypeInt()
+		if size > 0 {
+			ptr = unsafe.Pointer(&t[0])
+		}
+
+	case []uint64:
+		size, format = len(t), TypeUint64()
+		if size > 0 {
+			ptr = unsafe.Pointer(&t[0])
+		}
+
+	case []int64:
+		size, format = len(t), TypeInt64()
+		if size > 0 {
+			ptr = unsafe.Pointer(&t[0])
+		}
+
+	case []uint:
+		size, format = len(t), TypeUint64()
+		if strconv.IntSize == 32 {
+			format = TypeUint()
+		}
+		if size > 0 {
+			ptr = unsafe.Pointer(&t[0])
+		}
+
+	case []int:
+		size, format = len(t), TypeInt64()
+		if strconv.IntSize == 32 {
+			format = TypeInt()
+		}
+		if size > 0 {
+			ptr = unsafe.Pointer(&t[0])
+		}
+
+	case []float32:
+		size, format = len(t), TypeFloat()
+		if size > 0 {
+			ptr = unsafe.Pointer(&t[0])
+		}
+
+	case []float64:
+		size, format = len(t), TypeDouble()
+		if size > 0 {
+			ptr = unsafe.Pointer(&t[0])
+		}
+
+	default:
+		return nil, 0, TypeUnknown(), fmt.Errorf("Pixel type %T is not a supported slice type", t)
+
+	}
+
+	if size == 0 {
+		return nil, 0, TypeUnknown(), errors.New("Pixel slice is empty")
+	}
+
+	return ptr, size, format, nil
+}
+
+// Report whether the type of a slice of pixel values is the
+// one that allocatePixelBufferSize produces for the TypeDesc.
+func pixelBufferMatchesFormat(pixels interface{}, format TypeDesc) bool {
+	var ok bool
+
+	switch format {
+	case TypeUint8():
+		_, ok = pixels.([]uint8)
+	case TypeInt8():
+		_, ok = pixels.([]int8)
+	case TypeUint16():
+		_, ok = pixels.([]uint16)
+	case TypeInt16():
+		_, ok = pixels.([]int16)
+	case TypeUint():
+		_, ok = pixels.([]uint)
+	case TypeInt():
+		_, ok = pixels.([]int)
+	case TypeUint64():
+		_, ok = pixels.([]uint64)
+	case TypeInt64():
+		_, ok = pixels.([]int64)
+	case TypeFloat(), TypeHalf():
+		_, ok = pixels.([]float32)
+	case TypeDouble():
+		_, ok = pixels.([]float64)
+	}
+
+	return ok
+}
+
+// Return the TypeDesc that describes how the values of a slice
+// allocated by allocatePixelBufferSize for the TypeDesc are laid
+// out in memory.
+func pixelBufferMemoryFormat(format TypeDesc) TypeDesc {
+	switch format {
+	case TypeHalf():
+		return TypeFloat()
+	case TypeUint():
+		if strconv.IntSize == 64 {
+			return TypeUint64()
+		}
+	case TypeInt():
+		if strconv.IntSize == 64 {
+			return TypeInt64()
+		}
+	}
+	return format
+}
+
+// Return the slice type that matches the in-memory layout
+// of values of the given TypeDesc.
+func pixelViewType(format TypeDesc) (reflect.Type, error) {
+	switch format {
+	case TypeUint8():
+		return reflect.TypeOf([]uint8(nil)), nil
+	case TypeInt8():
+		return reflect.TypeOf([]int8(nil)), nil
+	case TypeUint16(), TypeHalf():
+		return reflect.TypeOf([]uint16(nil)), nil
+	case TypeInt16():
+		return reflect.TypeOf([]int16(nil)), nil
+	case TypeUint():
+		return reflect.TypeOf([]uint32(nil)), nil
+	case TypeInt():
+		return reflect.TypeOf([]int32(nil)), nil
+	case TypeUint64():
+		return reflect.TypeOf([]uint64(nil)), nil
+	case TypeInt64():
+		return reflect.TypeOf([]int64(nil)), nil
+	case TypeFloat():
+		return reflect.TypeOf([]float32(nil)), nil
+	case TypeDouble():
+		return reflect.TypeOf([]float64(nil)), nil
+	default:
+		return nil, errors.New("TypeDesc is not valid for this operation")
+	}
+}
+
+// Return the size in bytes of a single value of the given TypeDesc.
+func pixelViewElemSize(format TypeDesc) (int, error) {
+	typ, err := pixelViewType(format)
+	if err != nil {
+		return 0, err
+	}
+	return int(typ.Elem().Size()), nil
+}
+
+// Create a slice of size values that views the memory at ptr,
+// typed according to pixelViewType. The memory is not copied, and
+// must not be on the Go heap.
+// Returns the slice, casted to an interface.
+func pixelSliceView(ptr unsafe.Pointer, size int, format TypeDesc) (interface{}, error) {
+	typ, err := pixelViewType(format)
+	if err != nil {
+		return nil, err
+	}
+
+	slice := reflect.New(typ)
+	hdr := (*reflect.SliceHeader)(unsafe.Pointer(slice.Pointer()))
+	hdr.Data = uintptr(ptr)
+	hdr.Len = size
+	hdr.Cap = size
+
+	return slice.Elem().Interface(), nil
+}
+
+// Return a []byte that views the memory of a slice value,
+// without copying it.
+func byteView(slice reflect.Value) []byte {
+	var b []byte
+	hdr := (*reflect.SliceHeader)(unsafe.Pointer(&b))
+	hdr.Data = slice.Pointer()
+	hdr.Len = slice.Len() * int(slice.Type().Elem().Size())
+	hdr.Cap = hdr.Len
+	return b
+}
+
+// Check that a pixel buffer holds at least the expected
+// number of values.
+func checkPixelBufferSize(size, expected int) error {
+	if size < expected {
+		return fmt.Errorf("Pixel slice length %d is less than the required %d values", size, expected)
+	}
+	return nil
+}
+
+// Return the number of values of elemsize bytes required to hold a
+// block of width x height x depth pixels of nchannels values each, laid
+// out with the given byte strides. Any stride may be AutoStride, to have
+// it computed for contiguous data.
+func stridedBufferSize(width, height, depth, nchannels, elemsize, xstride, ystride, zstride int) (int, error) {
+	if width <= 0 || height <= 0 || depth <= 0 || nchannels <= 0 {
+		return 0, fmt.Errorf("Invalid region of %dx%dx%d pixels with %d channels",
+			width, height, depth, nchannels)
+	}
+
+	pixelsize := nchannels * elemsize
+	if xstride == AutoStride {
+		xstride = pixelsize
+	}
+	if ystride == AutoStride {
+		ystride = xstride * width
+	}
+	if zstride == AutoStride {
+		zstride = ystride * height
+	}
+	if xstride < 0 || ystride < 0 || zstride < 0 {
+		return 0, errors.New("Negative strides are not supported")
+	}
+
+	nbytes := (depth-1)*zstride + (height-1)*ystride + (width-1)*xstride + pixelsize
+	return (nbytes + elemsize - 1) / elemsize, nil
+}
+
+// An io.Writer that counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}